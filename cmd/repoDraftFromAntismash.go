@@ -0,0 +1,100 @@
+/*
+Copyright © 2025 Technical University of Denmark - written by Kai Blin <kblin@biosustain.dtu.dk>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"secondarymetabolites.org/mibig-api/internal/antismash"
+)
+
+var (
+	draftRecord       string
+	draftProtocluster int
+	draftAccession    string
+	draftTaxId        int64
+	draftOutput       string
+)
+
+// repoDraftFromAntismashCmd represents the repoDraftFromAntismash command
+var repoDraftFromAntismashCmd = &cobra.Command{
+	Use:   "draft-from-antismash <results.json>",
+	Short: "Draft a MIBiG entry from an antiSMASH protocluster",
+	Long: `Draft a MIBiG entry from an antiSMASH protocluster.
+
+Fills in the locus, organism, biosynthetic class and genes with their detected
+domains from a protocluster of an antiSMASH results JSON. Protoclusters are
+numbered from 1 within their record. The record can be left out if the results
+only have one.
+
+antiSMASH results carry no taxid or compound names, so placeholders are used
+for those and any accession not given with --accession. The fields a curator
+still has to fill in are listed on stderr.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		input, err := os.Open(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %s\n", args[0], err)
+			os.Exit(1)
+		}
+		defer input.Close()
+		entry, err := antismash.SelectRecord(antismash.NewReader(input), draftRecord)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %s\n", args[0], err)
+			os.Exit(1)
+		}
+
+		draft, err := antismash.Draft(entry, antismash.DraftOptions{
+			Record:       draftRecord,
+			Protocluster: draftProtocluster,
+			Accession:    draftAccession,
+			TaxId:        draftTaxId,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error drafting entry: %s\n", err)
+			os.Exit(1)
+		}
+
+		out, err := json.MarshalIndent(draft.Entry, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		out = append(out, '\n')
+		if draftOutput == "" {
+			os.Stdout.Write(out)
+		} else if err = os.WriteFile(draftOutput, out, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s: %s\n", draftOutput, err)
+			os.Exit(1)
+		}
+
+		for _, todo := range draft.Todo {
+			fmt.Fprintf(os.Stderr, "TODO %s: %s\n", todo.Pointer, todo.Message)
+		}
+	},
+}
+
+func init() {
+	repoCmd.AddCommand(repoDraftFromAntismashCmd)
+	repoDraftFromAntismashCmd.Flags().StringVarP(&draftRecord, "record", "r", "", "Id of the record holding the protocluster")
+	repoDraftFromAntismashCmd.Flags().IntVarP(&draftProtocluster, "protocluster", "p", 1, "Number of the protocluster in the record")
+	repoDraftFromAntismashCmd.Flags().StringVarP(&draftAccession, "accession", "a", "", "Accession of the new entry, e.g. from \"repo reserve\"")
+	repoDraftFromAntismashCmd.Flags().Int64Var(&draftTaxId, "taxid", 0, "NCBI taxid of the organism")
+	repoDraftFromAntismashCmd.Flags().StringVarP(&draftOutput, "output", "o", "", "File to write the draft to instead of stdout")
+}
@@ -16,82 +16,468 @@ limitations under the License.
 package cmd
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"secondarymetabolites.org/mibig-api/internal/data"
 	"secondarymetabolites.org/mibig-api/internal/models"
+	"secondarymetabolites.org/mibig-api/internal/schema"
+)
+
+var (
+	importWorkers    int
+	importBatchSize  int
+	importFailFast   bool
+	importDryRun     bool
+	importReportFile string
 )
 
 // repoImportCmd represents the repoImport command
 var repoImportCmd = &cobra.Command{
-	Use:   "import <json file>",
-	Short: "Import a MIBiG JSON file into the database",
-	Long: `Import a MIBiG JSON file into the database.
+	Use:   "import <json file|directory|glob|tarball>...",
+	Short: "Import MIBiG JSON files into the database",
+	Long: `Import MIBiG JSON files into the database.
+
+Arguments can be single JSON files, directories (searched recursively for
+*.json files), glob patterns or tarballs (.tar, .tar.gz, .tgz) of JSON files.
+Files are read one at a time and handed to a pool of workers as soon as a
+batch is complete, entries are imported in one transaction per batch.
+A failing entry does not stop the import unless --fail-fast is given.
+
+At the end, a JSON report of imported, embargoed, taxid-updated and failed
+accessions is written to stdout, or to the file given with --report.
 
-JSON files are assumed to validate against the JSON schema.
+Embargoed entries are imported, but hidden from the public until their
+embargo_until date, when the server publishes them.
+
+JSON files are validated against the MIBiG JSON schema, entries that don't
+validate are reported as failed.
 `,
 	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		jsonFileName := args[0]
-
-		jsonBytes, err := os.ReadFile(jsonFileName)
+		taxonCache, err := loadTaxonCache()
 		if err != nil {
 			panic(err)
 		}
 
-		var Entry data.MibigEntry
+		db, err := InitDb()
+		if err != nil {
+			panic(fmt.Errorf("error opening database: %s", err))
+		}
+
+		m := models.NewModels(db)
 
-		if err = json.Unmarshal(jsonBytes, &Entry); err != nil {
-			panic(err)
+		report, importErr := runImport(m.Entries, args, taxonCache)
+
+		if err = report.write(importReportFile); err != nil {
+			panic(fmt.Errorf("error writing import report: %s", err))
 		}
 
-		if Entry.Status == "embargoed" {
-			fmt.Printf("Entry %s is embargoed, skipping\n", Entry.Accession)
-			return
+		if importErr != nil {
+			fmt.Fprintf(os.Stderr, "Error reading import sources: %s\n", importErr)
+			os.Exit(1)
 		}
 
-		cacheFileName := viper.GetString("taxa.cache")
+		if len(report.Failed) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	repoCmd.AddCommand(repoImportCmd)
+	addImportFlags(repoImportCmd)
+}
+
+func addImportFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVarP(&importWorkers, "workers", "w", 4, "Number of parallel import workers")
+	cmd.Flags().IntVarP(&importBatchSize, "batch-size", "b", 100, "Number of entries imported per transaction")
+	cmd.Flags().BoolVar(&importFailFast, "fail-fast", false, "Stop the import at the first failing entry")
+	cmd.Flags().BoolVarP(&importDryRun, "dry-run", "n", false, "Run the import, but roll back all transactions")
+	cmd.Flags().StringVarP(&importReportFile, "report", "R", "", "Write the import report to this file instead of stdout")
+}
+
+func loadTaxonCache() (*data.TaxonCache, error) {
+	cacheFileName := viper.GetString("taxa.cache")
+
+	cacheBytes, err := os.ReadFile(cacheFileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open taxon cache: %s %s", cacheFileName, err)
+	}
+
+	var taxonCache data.TaxonCache = data.TaxonCache{}
+
+	if err = json.Unmarshal(cacheBytes, &taxonCache); err != nil {
+		return nil, err
+	}
+	return &taxonCache, nil
+}
 
-		cacheBytes, err := os.ReadFile(cacheFileName)
+type importSource struct {
+	Name string
+	Raw  []byte
+}
+
+// walkImportSources calls fn for every JSON file given by args, reading one
+// file at a time. Stops at the first error returned by fn.
+func walkImportSources(args []string, fn func(importSource) error) error {
+	var paths []string
+
+	for _, arg := range args {
+		if !strings.ContainsAny(arg, "*?[") {
+			paths = append(paths, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
 		if err != nil {
-			panic(fmt.Errorf("Failed to open taxon cache: %s %s", cacheFileName, err))
+			return err
 		}
+		if len(matches) == 0 {
+			return fmt.Errorf("no files matching %s", arg)
+		}
+		paths = append(paths, matches...)
+	}
 
-		var taxonCache data.TaxonCache = data.TaxonCache{}
+	sort.Strings(paths)
 
-		if err = json.Unmarshal(cacheBytes, &taxonCache); err != nil {
-			panic(err)
+	for _, path := range paths {
+		if err := walkImportPath(path, fn); err != nil {
+			return err
 		}
-		db, err := InitDb()
-		if err != nil {
-			panic(fmt.Errorf("error opening database: %s", err))
+	}
+
+	return nil
+}
+
+func walkImportPath(path string, fn func(importSource) error) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !strings.HasSuffix(name, ".json") {
+				return nil
+			}
+			raw, err := os.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			return fn(importSource{Name: name, Raw: raw})
+		})
+	}
+
+	if isTarball(path) {
+		return walkTarball(path, fn)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return fn(importSource{Name: path, Raw: raw})
+}
+
+func isTarball(path string) bool {
+	for _, suffix := range []string{".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(path, suffix) {
+			return true
 		}
+	}
+	return false
+}
 
-		m := models.NewModels(db)
+func walkTarball(path string, fn func(importSource) error) error {
+	handle, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
 
-		tax_id, err := m.Entries.LoadTaxonEntry(Entry.Taxonomy.Name, Entry.Taxonomy.NcbiTaxId, &taxonCache)
+	var reader io.Reader = handle
+	if !strings.HasSuffix(path, ".tar") {
+		gz, err := gzip.NewReader(handle)
 		if err != nil {
-			panic(fmt.Errorf("error loading taxonomy info for %s %d: %s", Entry.Accession, Entry.Taxonomy.NcbiTaxId, err))
+			return err
 		}
+		defer gz.Close()
+		reader = gz
+	}
 
-		if tax_id != Entry.Taxonomy.NcbiTaxId {
-			fmt.Printf("Updating entry %s taxid from outdated %d to %d\n", Entry.Accession, Entry.Taxonomy.NcbiTaxId, tax_id)
-			Entry.Taxonomy.NcbiTaxId = tax_id
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading %s: %s", path, err)
 		}
+		if header.Typeflag != tar.TypeReg || !strings.HasSuffix(header.Name, ".json") {
+			continue
+		}
+		raw, err := io.ReadAll(tarReader)
+		if err != nil {
+			return fmt.Errorf("error reading %s from %s: %s", header.Name, path, err)
+		}
+		if err = fn(importSource{Name: fmt.Sprintf("%s:%s", path, header.Name), Raw: raw}); err != nil {
+			return err
+		}
+	}
+}
 
-		err = m.Entries.Add(Entry, jsonBytes, &taxonCache)
+type taxIdUpdate struct {
+	Accession string `json:"accession"`
+	OldTaxId  int64  `json:"old_tax_id"`
+	NewTaxId  int64  `json:"new_tax_id"`
+}
+
+type importFailure struct {
+	Accession string `json:"accession,omitempty"`
+	File      string `json:"file"`
+	Error     string `json:"error"`
+}
+
+type importReport struct {
+	DryRun       bool            `json:"dry_run"`
+	Imported     []string        `json:"imported"`
+	Embargoed    []string        `json:"embargoed"`
+	TaxIdUpdated []taxIdUpdate   `json:"taxid_updated"`
+	Failed       []importFailure `json:"failed"`
+	lock         sync.Mutex
+}
+
+func newImportReport(dryRun bool) *importReport {
+	return &importReport{
+		DryRun:       dryRun,
+		Imported:     []string{},
+		Embargoed:    []string{},
+		TaxIdUpdated: []taxIdUpdate{},
+		Failed:       []importFailure{},
+	}
+}
+
+func (r *importReport) imported(entry data.MibigEntry) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.Imported = append(r.Imported, entry.Accession)
+	if entry.Status == "embargoed" {
+		r.Embargoed = append(r.Embargoed, entry.Accession)
+	}
+}
+
+func (r *importReport) failed(accession, file string, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.Failed = append(r.Failed, importFailure{Accession: accession, File: file, Error: err.Error()})
+}
+
+func (r *importReport) taxIdUpdated(update taxIdUpdate) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.TaxIdUpdated = append(r.TaxIdUpdated, update)
+}
+
+func (r *importReport) write(fileName string) error {
+	sort.Strings(r.Imported)
+	sort.Strings(r.Embargoed)
+	sort.Slice(r.Failed, func(i, j int) bool {
+		return r.Failed[i].File < r.Failed[j].File
+	})
+
+	out, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	out = append(out, '\n')
+
+	if fileName == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	return os.WriteFile(fileName, out, 0644)
+}
+
+type pendingEntry struct {
+	data.RawEntry
+	File string
+}
+
+var errImportAborted = errors.New("import aborted")
+
+// runImport reads the sources given by args one at a time, and hands them to a
+// pool of workers in batches as soon as a batch is full. Taxa are created in
+// the transaction of the batch that first references them.
+func runImport(entries models.EntryModel, args []string, taxCache *data.TaxonCache) (*importReport, error) {
+	report := newImportReport(importDryRun)
+
+	batchSize := max(importBatchSize, 1)
+	batches := make(chan []pendingEntry)
+	var aborted atomic.Bool
+	var wg sync.WaitGroup
+
+	for range max(importWorkers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				importBatch(entries, batch, taxCache, report, &aborted)
+			}
+		}()
+	}
+
+	resolved := make(map[data.MibigTaxonomy]int64)
+	batch := make([]pendingEntry, 0, batchSize)
+
+	err := walkImportSources(args, func(source importSource) error {
+		if aborted.Load() {
+			return errImportAborted
+		}
+
+		p, ok := prepareEntry(source, taxCache, resolved, report)
+		if !ok {
+			if importFailFast {
+				aborted.Store(true)
+				return errImportAborted
+			}
+			return nil
+		}
+
+		batch = append(batch, p)
+		if len(batch) == batchSize {
+			batches <- batch
+			batch = make([]pendingEntry, 0, batchSize)
+		}
+		return nil
+	})
+
+	if len(batch) > 0 {
+		if aborted.Load() {
+			for _, p := range batch {
+				report.failed(p.Entry.Accession, p.File, fmt.Errorf("not imported, import was aborted"))
+			}
+		} else {
+			batches <- batch
+		}
+	}
+	close(batches)
+	wg.Wait()
+
+	if errors.Is(err, errImportAborted) {
+		err = nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Imported %d (%d embargoed), failed %d entries\n", len(report.Imported), len(report.Embargoed), len(report.Failed))
+	return report, err
+}
+
+// prepareEntry validates and parses a source and updates its taxid to the current one
+// in the taxon cache. Failures are added to the report.
+func prepareEntry(source importSource, taxCache *data.TaxonCache, resolved map[data.MibigTaxonomy]int64, report *importReport) (pendingEntry, bool) {
+	var entry data.MibigEntry
+
+	if err := schema.Validate(source.Raw); err != nil {
+		report.failed(accessionOf(source.Raw), source.Name, err)
+		return pendingEntry{}, false
+	}
+
+	if err := json.Unmarshal(source.Raw, &entry); err != nil {
+		report.failed("", source.Name, err)
+		return pendingEntry{}, false
+	}
+
+	tax_id, found := resolved[entry.Taxonomy]
+	if !found {
+		var err error
+		tax_id, err = currentTaxId(entry.Taxonomy.NcbiTaxId, taxCache)
 		if err != nil {
-			panic(fmt.Errorf("error writing entry %s %d to database: %s", Entry.Accession, Entry.Taxonomy.NcbiTaxId, err))
+			report.failed(entry.Accession, source.Name, fmt.Errorf("error loading taxonomy info for %d: %s", entry.Taxonomy.NcbiTaxId, err))
+			return pendingEntry{}, false
 		}
+		resolved[entry.Taxonomy] = tax_id
+	}
 
-	},
+	if tax_id != entry.Taxonomy.NcbiTaxId {
+		report.taxIdUpdated(taxIdUpdate{Accession: entry.Accession, OldTaxId: entry.Taxonomy.NcbiTaxId, NewTaxId: tax_id})
+		entry.Taxonomy.NcbiTaxId = tax_id
+	}
+
+	return pendingEntry{RawEntry: data.RawEntry{Entry: entry, Raw: source.Raw}, File: source.Name}, true
 }
 
-func init() {
-	repoCmd.AddCommand(repoImportCmd)
+// accessionOf tries to get the accession of an entry that failed to validate, for error reports
+func accessionOf(raw []byte) string {
+	var entry struct {
+		Accession any `json:"accession"`
+	}
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return ""
+	}
+	if accession, ok := entry.Accession.(string); ok {
+		return accession
+	}
+	return ""
+}
+
+// currentTaxId follows merged taxids in the taxon cache to the current NCBI taxid.
+// This only consults the cache, the taxa are written to the database when their entries are.
+func currentTaxId(ncbi_taxid int64, taxCache *data.TaxonCache) (int64, error) {
+	for {
+		taxEntry, err := taxCache.EntryForTaxId(ncbi_taxid)
+		if err != nil {
+			return -1, err
+		}
+		if taxEntry.TaxId == ncbi_taxid {
+			return ncbi_taxid, nil
+		}
+		ncbi_taxid = taxEntry.TaxId
+	}
+}
+
+func importBatch(entries models.EntryModel, batch []pendingEntry, taxCache *data.TaxonCache, report *importReport, aborted *atomic.Bool) {
+	if aborted.Load() {
+		for _, p := range batch {
+			report.failed(p.Entry.Accession, p.File, fmt.Errorf("not imported, import was aborted"))
+		}
+		return
+	}
+
+	raw := make([]data.RawEntry, len(batch))
+	for i, p := range batch {
+		raw[i] = p.RawEntry
+	}
+
+	errs, err := entries.AddBatch(raw, taxCache, models.BatchOptions{FailFast: importFailFast, DryRun: importDryRun})
+	if err != nil && importFailFast {
+		aborted.Store(true)
+	}
+
+	for i, p := range batch {
+		switch {
+		case errs != nil && errs[i] != nil:
+			report.failed(p.Entry.Accession, p.File, errs[i])
+		case err != nil:
+			report.failed(p.Entry.Accession, p.File, fmt.Errorf("batch rolled back: %s", err))
+		default:
+			report.imported(p.Entry)
+		}
+	}
 }
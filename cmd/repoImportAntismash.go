@@ -0,0 +1,94 @@
+/*
+Copyright © 2025 Technical University of Denmark - written by Kai Blin <kblin@biosustain.dtu.dk>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"secondarymetabolites.org/mibig-api/internal/antismash"
+	"secondarymetabolites.org/mibig-api/internal/data"
+	"secondarymetabolites.org/mibig-api/internal/models"
+)
+
+// repoImportAntismashCmd represents the repoImportAntismash command
+var repoImportAntismashCmd = &cobra.Command{
+	Use:   "import-antismash <accession> <results.json>",
+	Short: "Store the antiSMASH results of an entry",
+	Long: `Store the antiSMASH results of an entry.
+
+Stores the protoclusters, the CDSes they cover and the domain hits of an
+antiSMASH results JSON for the latest version of an entry, or the version given
+with the accession. Results stored earlier for the same version are replaced.
+The domain hits can be searched with the [hmm] and [pfam] categories, where
+[pfam] only covers the detection profiles taken from Pfam.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		accession := args[0]
+
+		input, err := os.Open(args[1])
+		if err != nil {
+			panic(fmt.Errorf("error reading %s: %s", args[1], err))
+		}
+		defer input.Close()
+
+		db, err := InitDb()
+		if err != nil {
+			panic(fmt.Errorf("error opening database: %s", err))
+		}
+
+		m := models.NewModels(db)
+
+		records := &countingReader{AsRecordReader: antismash.NewReader(input)}
+		entryId, err := m.Entries.AddAntismash(accession, records)
+		if err != nil {
+			var parseErr *antismash.ParseError
+			if errors.As(err, &parseErr) {
+				fmt.Fprintf(os.Stderr, "Error parsing %s: %s\n", args[1], err)
+			} else {
+				fmt.Fprintf(os.Stderr, "Error storing antiSMASH results for %s: %s\n", accession, err)
+			}
+			os.Exit(1)
+		}
+
+		fmt.Printf("Stored %d protoclusters with %d CDSes for %s\n", records.protoclusters, records.cdses, entryId)
+	},
+}
+
+// countingReader counts the protoclusters and CDSes of the records passing through it
+type countingReader struct {
+	data.AsRecordReader
+	protoclusters int
+	cdses         int
+}
+
+func (r *countingReader) Next() (*data.AsRecord, error) {
+	record, err := r.AsRecordReader.Next()
+	if err == nil {
+		for _, cbp := range record.Modules.HmmDetection.RuleResults.CdsByProtocluster {
+			r.protoclusters++
+			r.cdses += len(cbp.Cdses)
+		}
+	}
+	return record, err
+}
+
+func init() {
+	repoCmd.AddCommand(repoImportAntismashCmd)
+}
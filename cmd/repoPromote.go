@@ -0,0 +1,69 @@
+/*
+Copyright © 2025 Technical University of Denmark - written by Kai Blin <kblin@biosustain.dtu.dk>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"secondarymetabolites.org/mibig-api/internal/models"
+)
+
+var forcePromote bool
+
+// repoPromoteCmd represents the repoPromote command
+var repoPromoteCmd = &cobra.Command{
+	Use:   "promote",
+	Short: "Make the staged release live",
+	Long: `Make the staged release live.
+
+This re-runs the sanity checks on the staging schema and then swaps it with
+the live schema in a single transaction. The previous live release is kept
+for "repo rollback".`,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := InitDb()
+		if err != nil {
+			panic(fmt.Errorf("error opening database: %s", err))
+		}
+
+		m := models.NewModels(db)
+
+		check, err := m.Staging.Check(minActiveRatio)
+		if err != nil {
+			panic(fmt.Errorf("error checking staging schema: %s", err))
+		}
+
+		printStagingCheck(check)
+
+		if !check.Ok() && !forcePromote {
+			fmt.Println("Sanity checks failed, not promoting. Use --force to promote anyway.")
+			os.Exit(1)
+		}
+
+		if err = m.Staging.Promote(); err != nil {
+			panic(fmt.Errorf("error promoting staging schema: %s", err))
+		}
+		fmt.Println("Promoted staged release.")
+	},
+}
+
+func init() {
+	repoCmd.AddCommand(repoPromoteCmd)
+	repoPromoteCmd.Flags().BoolVarP(&forcePromote, "force", "f", false, "Promote even if sanity checks fail")
+	repoPromoteCmd.Flags().Float64Var(&minActiveRatio, "min-ratio", 0.9, "Minimal ratio of staged to live active entries")
+}
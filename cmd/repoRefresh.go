@@ -19,14 +19,20 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"secondarymetabolites.org/mibig-api/internal/models"
+	"secondarymetabolites.org/mibig-api/internal/seqsearch"
 )
 
 // repoRefreshCmd represents the repoRefresh command
 var repoRefreshCmd = &cobra.Command{
 	Use:   "refresh",
 	Short: "Refresh all materialised views and other maintenance",
-	Long:  `Refresh all materialised views and other maintenance.`,
+	Long: `Refresh all materialised views and other maintenance.
+
+This also rebuilds the sequence search index from the genes of all active
+entries and writes it to the search.sequence_index path, where a running
+server picks it up.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		db, err := InitDb()
 		if err != nil {
@@ -37,6 +43,17 @@ var repoRefreshCmd = &cobra.Command{
 		if err != nil {
 			panic(fmt.Errorf("error refreshing views: %s", err))
 		}
+
+		proteins, err := m.Entries.ActiveProteins()
+		if err != nil {
+			panic(fmt.Errorf("error loading gene translations: %s", err))
+		}
+		index := seqsearch.NewIndex(seqsearch.TargetsFromFasta(proteins))
+		if err = index.WriteFile(viper.GetString("search.sequence_index")); err != nil {
+			panic(fmt.Errorf("error writing sequence index: %s", err))
+		}
+		fmt.Printf("Indexed %d genes for sequence search\n", len(index.Targets))
+
 		fmt.Println("Done.")
 	},
 }
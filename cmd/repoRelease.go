@@ -0,0 +1,69 @@
+/*
+Copyright © 2025 Technical University of Denmark - written by Kai Blin <kblin@biosustain.dtu.dk>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"secondarymetabolites.org/mibig-api/internal/models"
+)
+
+// repoReleaseCmd represents the repoRelease command
+var repoReleaseCmd = &cobra.Command{
+	Use:   "release",
+	Short: "Manage MIBiG releases",
+	Long: `Manage MIBiG releases.
+
+Releases record which entries were part of a MIBiG data release, so
+changelogs between releases can be generated. Without a subcommand,
+all releases are listed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		repoReleaseListCmd.Run(cmd, args)
+	},
+}
+
+// repoReleaseListCmd represents the repoReleaseList command
+var repoReleaseListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all MIBiG releases",
+	Long:  `List all MIBiG releases with their entry counts.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := InitDb()
+		if err != nil {
+			panic(fmt.Errorf("error opening database: %s", err))
+		}
+
+		m := models.NewModels(db)
+
+		releases, err := m.Releases.List()
+		if err != nil {
+			panic(fmt.Errorf("error listing releases: %s", err))
+		}
+
+		fmt.Printf("Name\tCreated\tTotal\tActive\tRetired\n")
+		for _, release := range releases {
+			fmt.Printf("%s\t%s\t%d\t%d\t%d\n", release.Name, release.Created.Format(time.DateOnly), release.Counts.Total, release.Counts.Active, release.Counts.Retired)
+		}
+	},
+}
+
+func init() {
+	repoCmd.AddCommand(repoReleaseCmd)
+	repoReleaseCmd.AddCommand(repoReleaseListCmd)
+}
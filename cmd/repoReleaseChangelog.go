@@ -0,0 +1,69 @@
+/*
+Copyright © 2025 Technical University of Denmark - written by Kai Blin <kblin@biosustain.dtu.dk>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"secondarymetabolites.org/mibig-api/internal/models"
+)
+
+var changelogMarkdown bool
+
+// repoReleaseChangelogCmd represents the repoReleaseChangelog command
+var repoReleaseChangelogCmd = &cobra.Command{
+	Use:   "changelog <from> <to>",
+	Short: "Show the changes between two MIBiG releases",
+	Long: `Show the changes between two MIBiG releases.
+
+Reports entries added, updated, retired and changed in quality or
+completeness, as JSON or with --markdown as Markdown.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := InitDb()
+		if err != nil {
+			panic(fmt.Errorf("error opening database: %s", err))
+		}
+
+		m := models.NewModels(db)
+
+		changelog, err := m.Releases.Changelog(args[0], args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating changelog from %s to %s: %s\n", args[0], args[1], err)
+			os.Exit(1)
+		}
+
+		if changelogMarkdown {
+			fmt.Print(changelog.Markdown())
+			return
+		}
+
+		out, err := json.MarshalIndent(changelog, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(out))
+	},
+}
+
+func init() {
+	repoReleaseCmd.AddCommand(repoReleaseChangelogCmd)
+	repoReleaseChangelogCmd.Flags().BoolVarP(&changelogMarkdown, "markdown", "m", false, "Output Markdown instead of JSON")
+}
@@ -0,0 +1,59 @@
+/*
+Copyright © 2025 Technical University of Denmark - written by Kai Blin <kblin@biosustain.dtu.dk>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"secondarymetabolites.org/mibig-api/internal/models"
+)
+
+var releaseNotes string
+
+// repoReleaseCreateCmd represents the repoReleaseCreate command
+var repoReleaseCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Record a new MIBiG release",
+	Long: `Record a new MIBiG release.
+
+Snapshots the entry ids of all public entries currently live under the
+given release name, e.g. 4.0. Embargoed entries are not part of a release.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := InitDb()
+		if err != nil {
+			panic(fmt.Errorf("error opening database: %s", err))
+		}
+
+		m := models.NewModels(db)
+
+		release, err := m.Releases.Create(args[0], releaseNotes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating release %s: %s\n", args[0], err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Created release %s with %d entries\n", release.Name, release.Counts.Total)
+	},
+}
+
+func init() {
+	repoReleaseCmd.AddCommand(repoReleaseCreateCmd)
+	repoReleaseCreateCmd.Flags().StringVarP(&releaseNotes, "notes", "n", "", "Notes on the release")
+}
@@ -0,0 +1,94 @@
+/*
+Copyright © 2025 Technical University of Denmark - written by Kai Blin <kblin@biosustain.dtu.dk>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+	"secondarymetabolites.org/mibig-api/internal/models"
+)
+
+var (
+	reserveTicket  string
+	reserveTTL     time.Duration
+	reserveRelease string
+	reserveList    bool
+	reserveStatus  string
+)
+
+// repoReserveCmd represents the repoReserve command
+var repoReserveCmd = &cobra.Command{
+	Use:   "reserve",
+	Short: "Reserve the next free MIBiG accession",
+	Long: `Reserve the next free MIBiG accession.
+
+Allocates the next BGC accession not used by any entry or earlier reservation.
+With --ticket, the accession request is resolved with the new accession.
+Use --release to give up a reservation and --list to show reservations.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := InitDb()
+		if err != nil {
+			panic(fmt.Errorf("error opening database: %s", err))
+		}
+
+		m := models.NewModels(db)
+
+		switch {
+		case reserveList:
+			if reserveStatus != "" && !data.ValidReservationStatus(reserveStatus) {
+				panic(fmt.Errorf("invalid reservation status %s", reserveStatus))
+			}
+			reservations, err := m.Reservations.List(reserveStatus)
+			if err != nil {
+				panic(fmt.Errorf("error listing reservations: %s", err))
+			}
+			for _, reservation := range reservations {
+				expires := "never"
+				if reservation.Expires != nil {
+					expires = reservation.Expires.Format(time.DateOnly)
+				}
+				fmt.Printf("%s\t%s\t%s\t%s\t%s\n", reservation.Accession, reservation.Status, reservation.Reserved.Format(time.DateOnly), expires, reservation.Ticket)
+			}
+
+		case reserveRelease != "":
+			if err = m.Reservations.Release(reserveRelease); err != nil {
+				panic(fmt.Errorf("error releasing %s: %s", reserveRelease, err))
+			}
+			fmt.Printf("Released %s\n", reserveRelease)
+
+		default:
+			reservation, err := m.Reservations.Reserve(reserveTicket, nil, reserveTTL)
+			if err != nil {
+				panic(fmt.Errorf("error reserving accession: %s", err))
+			}
+			fmt.Println(reservation.Accession)
+		}
+	},
+}
+
+func init() {
+	repoCmd.AddCommand(repoReserveCmd)
+	repoReserveCmd.Flags().StringVarP(&reserveTicket, "ticket", "t", "", "Accession request ticket to resolve with the reserved accession")
+	repoReserveCmd.Flags().DurationVar(&reserveTTL, "ttl", models.DefaultReservationTTL, "Time until the reservation expires, 0 to never expire")
+	repoReserveCmd.Flags().StringVar(&reserveRelease, "release", "", "Release the reservation of this accession")
+	repoReserveCmd.Flags().BoolVarP(&reserveList, "list", "l", false, "List reservations")
+	repoReserveCmd.Flags().StringVar(&reserveStatus, "status", "", "Only list reservations with this status")
+}
@@ -0,0 +1,97 @@
+/*
+Copyright © 2025 Technical University of Denmark - written by Kai Blin <kblin@biosustain.dtu.dk>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"secondarymetabolites.org/mibig-api/internal/models"
+)
+
+var (
+	retireReasons []string
+	retireSeeAlso []string
+	retireBy      string
+)
+
+// repoRetireCmd represents the repoRetire command
+var repoRetireCmd = &cobra.Command{
+	Use:   "retire <accession>",
+	Short: "Retire an entry",
+	Long: `Retire an entry.
+
+Adds a new version of the entry with the status "retired" and the given
+reasons. Entries listed with --see-also are offered as successors, the
+/go/ redirect sends users of a retired entry with a single successor there.
+Every retirement is recorded with the person who retired the entry.
+Embargoed entries can only be retired once they are released.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		accession := args[0]
+
+		if len(retireReasons) == 0 {
+			fmt.Fprintln(os.Stderr, "At least one --reason is required")
+			os.Exit(1)
+		}
+
+		retiredBy := retireBy
+		if retiredBy == "" {
+			current, err := user.Current()
+			if err != nil {
+				panic(fmt.Errorf("error getting current user, use --by: %s", err))
+			}
+			retiredBy = current.Username
+		}
+
+		seeAlso := []string{}
+		for _, successor := range retireSeeAlso {
+			if !slices.Contains(seeAlso, successor) {
+				seeAlso = append(seeAlso, successor)
+			}
+		}
+
+		db, err := InitDb()
+		if err != nil {
+			panic(fmt.Errorf("error opening database: %s", err))
+		}
+
+		m := models.NewModels(db)
+
+		retirement, err := m.Entries.Retire(accession, retireReasons, seeAlso, retiredBy)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error retiring %s: %s\n", accession, err)
+			os.Exit(1)
+		}
+
+		if err = m.Entries.Refresh(); err != nil {
+			panic(fmt.Errorf("error refreshing views: %s", err))
+		}
+
+		fmt.Printf("Retired %s as %s\n", retirement.RetiredEntryId, retirement.EntryId)
+	},
+}
+
+func init() {
+	repoCmd.AddCommand(repoRetireCmd)
+	repoRetireCmd.Flags().StringArrayVar(&retireReasons, "reason", nil, "Reason for the retirement, can be given multiple times")
+	repoRetireCmd.Flags().StringSliceVar(&retireSeeAlso, "see-also", nil, "Accession of a successor entry, can be given multiple times")
+	repoRetireCmd.Flags().StringVar(&retireBy, "by", "", "Who retired the entry, defaults to the current user")
+}
@@ -0,0 +1,51 @@
+/*
+Copyright © 2025 Technical University of Denmark - written by Kai Blin <kblin@biosustain.dtu.dk>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"secondarymetabolites.org/mibig-api/internal/models"
+)
+
+// repoRollbackCmd represents the repoRollback command
+var repoRollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Go back to the previous release",
+	Long: `Go back to the previous release.
+
+This swaps the live release with the one that was live before the last
+"repo promote". Running it again restores the promoted release.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := InitDb()
+		if err != nil {
+			panic(fmt.Errorf("error opening database: %s", err))
+		}
+
+		m := models.NewModels(db)
+
+		if err = m.Staging.Rollback(); err != nil {
+			panic(fmt.Errorf("error rolling back release: %s", err))
+		}
+		fmt.Println("Rolled back to previous release.")
+	},
+}
+
+func init() {
+	repoCmd.AddCommand(repoRollbackCmd)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright © 2025 Technical University of Denmark - written by Kai Blin <kblin@biosustain.dtu.dk>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+	"secondarymetabolites.org/mibig-api/internal/models"
+)
+
+var minActiveRatio float64
+
+// repoStageCmd represents the repoStage command
+var repoStageCmd = &cobra.Command{
+	Use:   "stage <json file|directory|glob|tarball>...",
+	Short: "Import a full release into the staging schema",
+	Long: `Import a full release into the staging schema.
+
+This recreates the staging schema next to the live schema, imports all
+given entries into it, rebuilds the materialized views and runs sanity
+checks. The live repository is not touched, use "repo promote" to make
+the staged release live.
+
+Arguments are handled like in "repo import".`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		taxonCache, err := loadTaxonCache()
+		if err != nil {
+			panic(err)
+		}
+
+		db, err := InitDb()
+		if err != nil {
+			panic(fmt.Errorf("error opening database: %s", err))
+		}
+
+		m := models.NewModels(db)
+
+		if err = m.Staging.Prepare(); err != nil {
+			panic(fmt.Errorf("error preparing staging schema: %s", err))
+		}
+
+		entries := m.Staging.Entries()
+		report, importErr := runImport(entries, args, taxonCache)
+
+		if err = report.write(importReportFile); err != nil {
+			panic(fmt.Errorf("error writing import report: %s", err))
+		}
+
+		if importErr != nil {
+			fmt.Fprintf(os.Stderr, "Error reading import sources: %s\n", importErr)
+			os.Exit(1)
+		}
+
+		if err = entries.Refresh(); err != nil {
+			panic(fmt.Errorf("error refreshing staging views: %s", err))
+		}
+
+		check, err := m.Staging.Check(minActiveRatio)
+		if err != nil {
+			panic(fmt.Errorf("error checking staging schema: %s", err))
+		}
+
+		printStagingCheck(check)
+
+		if len(report.Failed) > 0 || !check.Ok() {
+			os.Exit(1)
+		}
+	},
+}
+
+func printStagingCheck(check *data.StagingCheck) {
+	fmt.Fprintf(os.Stderr, "Staging: %d entries (%d active, %d retired)\n", check.Staging.Total, check.Staging.Active, check.Staging.Retired)
+	fmt.Fprintf(os.Stderr, "Live:    %d entries (%d active, %d retired)\n", check.Live.Total, check.Live.Active, check.Live.Retired)
+	for _, problem := range check.Problems {
+		fmt.Fprintf(os.Stderr, "Problem: %s\n", problem)
+	}
+}
+
+func init() {
+	repoCmd.AddCommand(repoStageCmd)
+	addImportFlags(repoStageCmd)
+	repoStageCmd.Flags().Float64Var(&minActiveRatio, "min-ratio", 0.9, "Minimal ratio of staged to live active entries")
+}
@@ -21,10 +21,10 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 
 	"secondarymetabolites.org/mibig-api/internal/data"
 	"secondarymetabolites.org/mibig-api/internal/models"
+	"secondarymetabolites.org/mibig-api/internal/schema"
 )
 
 // repoUpdateCmd represents the repoUpdate command
@@ -33,7 +33,7 @@ var repoUpdateCmd = &cobra.Command{
 	Short: "Update an entry with a JSON file",
 	Long: `Update an entry with a JSON file.
 
-JSON files are assumed to validate against the JSON schema.`,
+The JSON file has to validate against the MIBiG JSON schema.`,
 	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		jsonFileName := args[0]
@@ -43,29 +43,22 @@ JSON files are assumed to validate against the JSON schema.`,
 			panic(err)
 		}
 
+		if err = schema.Validate(jsonBytes); err != nil {
+			printValidationError(jsonFileName, err)
+			os.Exit(1)
+		}
+
 		var Entry data.MibigEntry
 
 		if err = json.Unmarshal(jsonBytes, &Entry); err != nil {
 			panic(err)
 		}
 
-		if Entry.Status == "embargoed" {
-			fmt.Printf("Entry %s is embargoed, skipping\n", Entry.Accession)
-			return
-		}
-
-		cacheFileName := viper.GetString("taxa.cache")
-
-		cacheBytes, err := os.ReadFile(cacheFileName)
+		taxonCache, err := loadTaxonCache()
 		if err != nil {
-			panic(fmt.Errorf("failed to open taxon cache: %s %s", cacheFileName, err))
-		}
-
-		var taxonCache data.TaxonCache = data.TaxonCache{}
-
-		if err = json.Unmarshal(cacheBytes, &taxonCache); err != nil {
 			panic(err)
 		}
+
 		db, err := InitDb()
 		if err != nil {
 			panic(fmt.Errorf("error opening database: %s", err))
@@ -73,7 +66,7 @@ JSON files are assumed to validate against the JSON schema.`,
 
 		m := models.NewModels(db)
 
-		tax_id, err := m.Entries.LoadTaxonEntry(Entry.Taxonomy.Name, Entry.Taxonomy.NcbiTaxId, &taxonCache)
+		tax_id, err := m.Entries.LoadTaxonEntry(Entry.Taxonomy.Name, Entry.Taxonomy.NcbiTaxId, taxonCache)
 		if err != nil {
 			panic(fmt.Errorf("error loading taxonomy info for %s %d: %s", Entry.Accession, Entry.Taxonomy.NcbiTaxId, err))
 		}
@@ -83,7 +76,7 @@ JSON files are assumed to validate against the JSON schema.`,
 			Entry.Taxonomy.NcbiTaxId = tax_id
 		}
 
-		err = m.Entries.Update(Entry, jsonBytes, &taxonCache)
+		err = m.Entries.Update(Entry, jsonBytes, taxonCache)
 		if err != nil {
 			panic(fmt.Errorf("error writing entry %s %d to database: %s", Entry.Accession, Entry.Taxonomy.NcbiTaxId, err))
 		}
@@ -0,0 +1,72 @@
+/*
+Copyright © 2025 Technical University of Denmark - written by Kai Blin <kblin@biosustain.dtu.dk>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"secondarymetabolites.org/mibig-api/internal/schema"
+)
+
+// repoValidateCmd represents the repoValidate command
+var repoValidateCmd = &cobra.Command{
+	Use:   "validate <json file|directory|glob|tarball>...",
+	Short: "Validate MIBiG JSON files against the schema",
+	Long: `Validate MIBiG JSON files against the schema.
+
+Takes the same arguments as "repo import", but only checks the files against
+the MIBiG JSON schema built into this binary, without touching the database.
+Exits with a non-zero status if any file fails to validate.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		total, failed := 0, 0
+		err := walkImportSources(args, func(source importSource) error {
+			total++
+			if err := schema.Validate(source.Raw); err != nil {
+				printValidationError(source.Name, err)
+				failed++
+			}
+			return nil
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Fprintf(os.Stderr, "%d of %d files valid\n", total-failed, total)
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	repoCmd.AddCommand(repoValidateCmd)
+}
+
+func printValidationError(fileName string, err error) {
+	var invalid *schema.ValidationError
+	if !errors.As(err, &invalid) {
+		fmt.Printf("%s: %s\n", fileName, err)
+		return
+	}
+	for _, field := range invalid.Errors {
+		fmt.Printf("%s: %s\n", fileName, field)
+	}
+}
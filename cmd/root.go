@@ -54,6 +54,7 @@ func Execute() {
 
 func init() {
 	cobra.OnInitialize(initConfig)
+	viper.SetDefault("search.sequence_index", "sequence_index.gob")
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.mibig-api.toml)")
 }
@@ -16,6 +16,8 @@ limitations under the License.
 package cmd
 
 import (
+	"time"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
@@ -51,6 +53,7 @@ func init() {
 	rootCmd.AddCommand(serveCmd)
 	//load defaults from viper
 	viper.SetDefault("server.repository", "repository")
+	viper.SetDefault("server.publish_interval", time.Hour)
 
 	serveCmd.Flags().BoolVarP(&debug, "debug", "d", false, "Enable debug info")
 	serveCmd.Flags().StringVarP(&repository, "repository", "r", viper.GetString("server.repository"), "Set the repository path")
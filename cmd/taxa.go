@@ -0,0 +1,34 @@
+/*
+Copyright © 2025 Technical University of Denmark - written by Kai Blin <kblin@biosustain.dtu.dk>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// taxaCmd represents the taxa command
+var taxaCmd = &cobra.Command{
+	Use:   "taxa",
+	Short: "Manage the NCBI taxonomy data",
+	Long: `Manage the NCBI taxonomy data.
+
+This command can be used to build the taxon cache used by the imports from
+the NCBI taxdump files, and to update the taxa in the database to it.`,
+}
+
+func init() {
+	rootCmd.AddCommand(taxaCmd)
+}
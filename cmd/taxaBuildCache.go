@@ -0,0 +1,155 @@
+/*
+Copyright © 2025 Technical University of Denmark - written by Kai Blin <kblin@biosustain.dtu.dk>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+	"secondarymetabolites.org/mibig-api/internal/models"
+)
+
+var (
+	buildCacheFull   bool
+	buildCacheNoDb   bool
+	buildCacheOutput string
+	buildCacheFiles  data.TaxDumpFiles
+)
+
+// taxaBuildCacheCmd represents the taxaBuildCache command
+var taxaBuildCacheCmd = &cobra.Command{
+	Use:   "build-cache [json file|directory|glob|tarball]...",
+	Short: "Build the taxon cache from the NCBI taxdump",
+	Long: `Build the taxon cache from the NCBI taxdump.
+
+Parses rankedlineage.dmp, merged.dmp and delnodes.dmp from the NCBI taxdump
+once and writes the taxon cache used by the import commands. Paths default to
+taxa.lineage, taxa.merged and taxa.delnodes from the config.
+
+Unless --full is given, the cache only covers the taxids referenced in the
+database and in the MIBiG JSON files given as arguments. Taxids NCBI deleted
+are flagged in the cache, so imports fail with a clear error.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		configured := data.TaxDumpFilesFromConfig()
+		if buildCacheFiles.Lineage == "" {
+			buildCacheFiles.Lineage = configured.Lineage
+		}
+		if buildCacheFiles.Merged == "" {
+			buildCacheFiles.Merged = configured.Merged
+		}
+		if buildCacheFiles.Delnodes == "" {
+			buildCacheFiles.Delnodes = configured.Delnodes
+		}
+		if buildCacheOutput == "" {
+			buildCacheOutput = viper.GetString("taxa.cache")
+		}
+		if buildCacheOutput == "" {
+			fmt.Fprintln(os.Stderr, "No output file given, use --output or set taxa.cache")
+			os.Exit(1)
+		}
+
+		if buildCacheFiles.Lineage == "" {
+			fmt.Fprintln(os.Stderr, "No rankedlineage.dmp file given, use --lineage or set taxa.lineage")
+			os.Exit(1)
+		}
+
+		var wanted map[int64]bool
+		if !buildCacheFull {
+			var err error
+			wanted, err = referencedTaxIds(args, !buildCacheNoDb)
+			if err != nil {
+				panic(err)
+			}
+			fmt.Fprintf(os.Stderr, "Building cache for %d referenced taxids\n", len(wanted))
+		}
+
+		cache, err := data.BuildTaxonCache(buildCacheFiles, wanted)
+		if err != nil {
+			panic(fmt.Errorf("error reading taxdump: %s", err))
+		}
+
+		for taxId := range wanted {
+			if _, err := cache.EntryForTaxId(taxId); err != nil {
+				fmt.Fprintf(os.Stderr, "Taxid %d: %s\n", taxId, err)
+			}
+		}
+
+		out, err := json.Marshal(cache)
+		if err != nil {
+			panic(err)
+		}
+		if err = os.WriteFile(buildCacheOutput, out, 0644); err != nil {
+			panic(fmt.Errorf("error writing taxon cache: %s", err))
+		}
+
+		fmt.Fprintf(os.Stderr, "Wrote %d taxa, %d merged and %d deleted taxids to %s\n",
+			len(cache.Mappings), len(cache.DeprecatedIds), len(cache.DeletedIds), buildCacheOutput)
+	},
+}
+
+// referencedTaxIds collects the taxids of the given MIBiG JSON files and, if
+// fromDb is set, the taxids already in the database
+func referencedTaxIds(args []string, fromDb bool) (map[int64]bool, error) {
+	wanted := map[int64]bool{}
+
+	if len(args) > 0 {
+		err := walkImportSources(args, func(source importSource) error {
+			var entry data.MibigEntry
+			if err := json.Unmarshal(source.Raw, &entry); err != nil {
+				fmt.Fprintf(os.Stderr, "Skipping %s: %s\n", source.Name, err)
+				return nil
+			}
+			wanted[entry.Taxonomy.NcbiTaxId] = true
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if fromDb {
+		db, err := InitDb()
+		if err != nil {
+			return nil, fmt.Errorf("error opening database: %s", err)
+		}
+		m := models.NewModels(db)
+
+		taxIds, err := m.Taxa.ReferencedTaxIds()
+		if err != nil {
+			return nil, fmt.Errorf("error loading taxids: %s", err)
+		}
+		for _, taxId := range taxIds {
+			wanted[taxId] = true
+		}
+	}
+
+	return wanted, nil
+}
+
+func init() {
+	taxaCmd.AddCommand(taxaBuildCacheCmd)
+	taxaBuildCacheCmd.Flags().BoolVar(&buildCacheFull, "full", false, "Include all taxa of the taxdump, not just referenced ones")
+	taxaBuildCacheCmd.Flags().BoolVar(&buildCacheNoDb, "no-db", false, "Don't include the taxids from the database")
+	taxaBuildCacheCmd.Flags().StringVarP(&buildCacheOutput, "output", "o", "", "File to write the taxon cache to, defaults to taxa.cache")
+	taxaBuildCacheCmd.Flags().StringVar(&buildCacheFiles.Lineage, "lineage", "", "Path of rankedlineage.dmp, defaults to taxa.lineage")
+	taxaBuildCacheCmd.Flags().StringVar(&buildCacheFiles.Merged, "merged", "", "Path of merged.dmp, defaults to taxa.merged")
+	taxaBuildCacheCmd.Flags().StringVar(&buildCacheFiles.Delnodes, "delnodes", "", "Path of delnodes.dmp, defaults to taxa.delnodes")
+}
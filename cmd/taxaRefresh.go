@@ -0,0 +1,95 @@
+/*
+Copyright © 2025 Technical University of Denmark - written by Kai Blin <kblin@biosustain.dtu.dk>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"secondarymetabolites.org/mibig-api/internal/models"
+)
+
+var (
+	taxaRefreshDryRun bool
+	taxaRefreshCache  string
+	taxaRefreshReport string
+)
+
+// taxaRefreshCmd represents the taxaRefresh command
+var taxaRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "Re-resolve all taxa in the database against a taxon cache",
+	Long: `Re-resolve all taxa in the database against a taxon cache.
+
+Updates the lineages of all taxa to the ones in the taxon cache and moves
+taxids NCBI merged to their replacement, including the taxid in the data of
+the affected entries. Taxids NCBI deleted or that are missing from the
+cache are reported for manual attention and left alone.
+
+A JSON report of all changes is written to stdout, or to the file given
+with --report. Use --dry-run to review the changes without applying them.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if taxaRefreshCache != "" {
+			viper.Set("taxa.cache", taxaRefreshCache)
+		}
+		taxonCache, err := loadTaxonCache()
+		if err != nil {
+			panic(err)
+		}
+
+		db, err := InitDb()
+		if err != nil {
+			panic(fmt.Errorf("error opening database: %s", err))
+		}
+
+		m := models.NewModels(db)
+
+		report, err := m.Taxa.Refresh(taxonCache, taxaRefreshDryRun)
+		if err != nil {
+			panic(fmt.Errorf("error refreshing taxa: %s", err))
+		}
+
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		out = append(out, '\n')
+		if taxaRefreshReport == "" {
+			os.Stdout.Write(out)
+		} else if err = os.WriteFile(taxaRefreshReport, out, 0644); err != nil {
+			panic(fmt.Errorf("error writing report: %s", err))
+		}
+
+		verb := "Changed"
+		if taxaRefreshDryRun {
+			verb = "Would change"
+		}
+		fmt.Fprintf(os.Stderr, "%s %d taxa, %d unchanged, %d deleted and %d missing taxids need attention\n",
+			verb, len(report.Changed), report.Unchanged, len(report.Deleted), len(report.Missing))
+	},
+}
+
+func init() {
+	taxaCmd.AddCommand(taxaRefreshCmd)
+	taxaRefreshCmd.Flags().BoolVarP(&taxaRefreshDryRun, "dry-run", "n", false, "Only report the changes, don't apply them")
+	taxaRefreshCmd.Flags().StringVarP(&taxaRefreshCache, "cache", "c", "", "Taxon cache to resolve against, defaults to taxa.cache")
+	taxaRefreshCmd.Flags().StringVarP(&taxaRefreshReport, "report", "R", "", "Write the report to this file instead of stdout")
+}
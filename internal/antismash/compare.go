@@ -0,0 +1,257 @@
+// Package antismash relates antiSMASH results to MIBiG entries
+package antismash
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+// Weights of the partial scores in the overall score of a match
+const (
+	classWeight    = 0.5
+	domainWeight   = 0.3
+	taxonomyWeight = 0.2
+)
+
+// classByCategory maps antiSMASH product categories to MIBiG classes
+var classByCategory = map[string]string{
+	"nrps":       "NRPS",
+	"pks":        "PKS",
+	"ripp":       "ribosomal",
+	"terpene":    "terpene",
+	"saccharide": "saccharide",
+	"other":      "other",
+}
+
+// classByProduct maps antiSMASH products to MIBiG classes, for results without categories
+var classByProduct = map[string]string{
+	"NRPS": "NRPS", "NRPS-like": "NRPS", "NAPAA": "NRPS", "thioamide-NRP": "NRPS", "isocyanide-nrp": "NRPS",
+	"T1PKS": "PKS", "T2PKS": "PKS", "T3PKS": "PKS", "transAT-PKS": "PKS", "transAT-PKS-like": "PKS",
+	"PKS-like": "PKS", "hglE-KS": "PKS", "prodigiosin": "PKS", "arylpolyene": "PKS", "resorcinol": "PKS",
+	"lanthipeptide": "ribosomal", "lanthipeptide-class-i": "ribosomal", "lanthipeptide-class-ii": "ribosomal",
+	"lanthipeptide-class-iii": "ribosomal", "lanthipeptide-class-iv": "ribosomal", "lanthipeptide-class-v": "ribosomal",
+	"lassopeptide": "ribosomal", "sactipeptide": "ribosomal", "thiopeptide": "ribosomal", "LAP": "ribosomal",
+	"bottromycin": "ribosomal", "cyanobactin": "ribosomal", "lipolanthine": "ribosomal", "RaS-RiPP": "ribosomal",
+	"ranthipeptide": "ribosomal", "redox-cofactor": "ribosomal", "proteusin": "ribosomal", "RiPP-like": "ribosomal",
+	"microviridin": "ribosomal", "glycocin": "ribosomal", "epipeptide": "ribosomal", "guanidinotides": "ribosomal",
+	"terpene": "terpene", "amglyccycl": "saccharide", "oligosaccharide": "saccharide", "aminoglycoside": "saccharide",
+}
+
+// moduleDomainByProfile maps antiSMASH detection profiles to the domain types of MIBiG modules
+var moduleDomainByProfile = map[string]string{
+	"AMP-binding": "A",
+	"A-OX":        "A",
+
+	"Condensation":         "C",
+	"Cglyc":                "C",
+	"Condensation_DCL":     "C",
+	"Condensation_LCL":     "C",
+	"Condensation_Starter": "C",
+	"Condensation_Dual":    "C",
+	"Heterocyclization":    "C",
+
+	"Epimerization": "E",
+
+	"PKS_KS":  "KS",
+	"ene_KS":  "KS",
+	"mod_KS":  "KS",
+	"hyb_KS":  "KS",
+	"itr_KS":  "KS",
+	"tra_KS":  "KS",
+	"hglE_KS": "KS",
+	"hglD_KS": "KS",
+	"t2ks":    "KS",
+
+	"PKS_AT": "AT",
+
+	"PKS_KR": "KR",
+
+	"PKS_DH":  "DH",
+	"PKS_DH2": "DH",
+	"PKS_DHt": "DH",
+
+	"PKS_ER": "ER",
+
+	"Thioesterase": "TE",
+
+	"TD": "TD",
+}
+
+// lineageRanks are the ranks of the lineages in entry profiles
+var lineageRanks = []string{"superkingdom", "kingdom", "phylum", "class", "order", "family", "genus", "species"}
+
+// query is a protocluster prepared for comparison
+type query struct {
+	comparison data.ClusterComparison
+	domains    []string
+	taxonomy   []string
+	organism   string
+}
+
+// Compare ranks the entries by their similarity to every protocluster of an antiSMASH result.
+// Entries sharing neither a class nor a domain type with a protocluster are left out.
+func Compare(entry *data.AsEntry, profiles []data.EntryProfile, maxMatches int) []data.ClusterComparison {
+	comparisons := []data.ClusterComparison{}
+	for i := range entry.Records {
+		comparisons = append(comparisons, CompareRecord(&entry.Records[i], profiles, maxMatches)...)
+	}
+	return comparisons
+}
+
+// CompareRecord ranks the entries by their similarity to the protoclusters of a single record,
+// so records can be compared as they are read
+func CompareRecord(record *data.AsRecord, profiles []data.EntryProfile, maxMatches int) []data.ClusterComparison {
+	comparisons := []data.ClusterComparison{}
+	for _, q := range protoclusters(record) {
+		matches := []data.ClusterMatch{}
+		for _, profile := range profiles {
+			if match, ok := q.match(profile); ok {
+				matches = append(matches, match)
+			}
+		}
+		sort.SliceStable(matches, func(i, j int) bool {
+			if matches[i].Score != matches[j].Score {
+				return matches[i].Score > matches[j].Score
+			}
+			return matches[i].Accession < matches[j].Accession
+		})
+		if maxMatches > 0 && len(matches) > maxMatches {
+			matches = matches[:maxMatches]
+		}
+		q.comparison.Matches = matches
+		comparisons = append(comparisons, q.comparison)
+	}
+	return comparisons
+}
+
+func protoclusters(record *data.AsRecord) []query {
+	queries := []query{}
+	for _, cbp := range record.Modules.HmmDetection.RuleResults.CdsByProtocluster {
+		pc := cbp.Protocluster
+		product := firstQualifier(pc.Qualifiers, "product")
+		if product == "" {
+			product = pc.Type
+		}
+
+		profiles := []string{}
+		domains := []string{}
+		for _, cds := range cbp.Cdses {
+			for _, domain := range cds.Domains {
+				if !slices.Contains(profiles, domain.Name) {
+					profiles = append(profiles, domain.Name)
+				}
+				if moduleDomain, ok := moduleDomainByProfile[domain.Name]; ok && !slices.Contains(domains, moduleDomain) {
+					domains = append(domains, moduleDomain)
+				}
+			}
+		}
+		sort.Strings(profiles)
+		sort.Strings(domains)
+
+		queries = append(queries, query{
+			comparison: data.ClusterComparison{
+				Record:   record.Id,
+				Location: pc.Location,
+				Product:  product,
+				Class:    mibigClass(firstQualifier(pc.Qualifiers, "category"), product),
+				Domains:  profiles,
+			},
+			domains:  domains,
+			taxonomy: record.Annotations.Taxonomy,
+			organism: record.Annotations.Organism,
+		})
+	}
+	return queries
+}
+
+func firstQualifier(qualifiers map[string][]string, name string) string {
+	if values := qualifiers[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func mibigClass(category, product string) string {
+	if class, ok := classByCategory[strings.ToLower(category)]; ok {
+		return class
+	}
+	if class, ok := classByProduct[product]; ok {
+		return class
+	}
+	return "other"
+}
+
+func (q *query) match(profile data.EntryProfile) (data.ClusterMatch, bool) {
+	match := data.ClusterMatch{Accession: profile.Accession, Compounds: profile.Compounds, Reasons: []string{}}
+	if match.Compounds == nil {
+		match.Compounds = []string{}
+	}
+
+	if slices.Contains(profile.Classes, q.comparison.Class) {
+		match.ClassScore = 1 / float64(len(profile.Classes))
+		match.Reasons = append(match.Reasons, fmt.Sprintf("shares class %s", q.comparison.Class))
+	}
+
+	shared := intersection(q.domains, profile.Domains)
+	if len(shared) > 0 {
+		match.DomainScore = float64(len(shared)) / float64(len(q.domains)+len(profile.Domains)-len(shared))
+		match.Reasons = append(match.Reasons, fmt.Sprintf("shares %d of %d module domain types: %s",
+			len(shared), len(q.domains), strings.Join(shared, ", ")))
+	}
+
+	if match.ClassScore == 0 && match.DomainScore == 0 {
+		return match, false
+	}
+
+	if rank := q.sharedRank(profile.Lineage); rank >= 0 {
+		match.TaxonomyScore = float64(rank+1) / float64(len(lineageRanks))
+		match.Reasons = append(match.Reasons, fmt.Sprintf("same %s %s", lineageRanks[rank], profile.Lineage[rank]))
+	}
+
+	match.Score = round(classWeight*match.ClassScore + domainWeight*match.DomainScore + taxonomyWeight*match.TaxonomyScore)
+	match.ClassScore = round(match.ClassScore)
+	match.DomainScore = round(match.DomainScore)
+	match.TaxonomyScore = round(match.TaxonomyScore)
+	return match, true
+}
+
+// sharedRank is the index of the most specific rank of an entry lineage that the
+// protocluster's organism shares, or -1
+func (q *query) sharedRank(lineage []string) int {
+	deepest := -1
+	for rank, name := range lineage {
+		if name == "" || name == "Unknown" {
+			continue
+		}
+		if lineageRanks[rank] == "species" {
+			genus := lineage[rank-1]
+			if strings.HasPrefix(q.organism, genus+" "+name) || strings.HasPrefix(q.organism, name) {
+				deepest = rank
+			}
+			continue
+		}
+		if slices.Contains(q.taxonomy, name) {
+			deepest = rank
+		}
+	}
+	return deepest
+}
+
+func intersection(a, b []string) []string {
+	shared := []string{}
+	for _, value := range a {
+		if slices.Contains(b, value) {
+			shared = append(shared, value)
+		}
+	}
+	return shared
+}
+
+func round(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
@@ -0,0 +1,104 @@
+package antismash
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+func loadEntry(t *testing.T, fileName string) *data.AsEntry {
+	raw, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry data.AsEntry
+	if err = json.Unmarshal(raw, &entry); err != nil {
+		t.Fatal(err)
+	}
+	return &entry
+}
+
+var testProfiles = []data.EntryProfile{
+	{
+		Accession: "BGC0000001.1", Classes: []string{"NRPS"}, Domains: []string{"A", "C", "PCP"}, Compounds: []string{"foomycin"},
+		Lineage: []string{"Bacteria", "Bacillati", "Actinomycetota", "Actinomycetes", "Kitasatosporales", "Streptomycetaceae", "Streptomyces", "coelicolor"},
+	},
+	{
+		Accession: "BGC0000002.1", Classes: []string{"NRPS", "PKS"}, Domains: []string{"A", "KS"},
+		Lineage: []string{"Bacteria", "Bacillati", "Bacillota", "Bacilli", "Bacillales", "Bacillaceae", "Bacillus", "subtilis"},
+	},
+	{
+		Accession: "BGC0000003.1", Classes: []string{"terpene"}, Domains: []string{},
+		Lineage: []string{"Bacteria", "Bacillati", "Actinomycetota", "Actinomycetes", "Kitasatosporales", "Streptomycetaceae", "Streptomyces", "griseus"},
+	},
+	{
+		Accession: "BGC0000004.1", Classes: []string{"PKS"}, Domains: []string{"KS", "AT"},
+		Lineage: []string{"Eukaryota", "Fungi", "Ascomycota", "Eurotiomycetes", "Eurotiales", "Aspergillaceae", "Aspergillus", "nidulans"},
+	},
+}
+
+func TestCompare(t *testing.T) {
+	entry := loadEntry(t, "testdata/minimal.json")
+
+	expected := []data.ClusterComparison{
+		{
+			Record: "NC_003888.3", Location: "[1000:40000]", Product: "NRPS", Class: "NRPS",
+			Domains: []string{"AMP-binding", "Condensation"},
+			Matches: []data.ClusterMatch{
+				{
+					Accession: "BGC0000001.1", Compounds: []string{"foomycin"},
+					Score: 0.9, ClassScore: 1, DomainScore: 0.667, TaxonomyScore: 1,
+					Reasons: []string{"shares class NRPS", "shares 2 of 2 module domain types: A, C", "same species coelicolor"},
+				},
+				{
+					Accession: "BGC0000002.1", Compounds: []string{},
+					Score: 0.375, ClassScore: 0.5, DomainScore: 0.333, TaxonomyScore: 0.125,
+					Reasons: []string{"shares class NRPS", "shares 1 of 2 module domain types: A", "same superkingdom Bacteria"},
+				},
+			},
+		},
+		{
+			Record: "NC_003888.3", Location: "[50000:70000]", Product: "terpene", Class: "terpene",
+			Domains: []string{"Terpene_synth_C"},
+			Matches: []data.ClusterMatch{
+				{
+					Accession: "BGC0000003.1", Compounds: []string{},
+					Score: 0.675, ClassScore: 1, TaxonomyScore: 0.875,
+					Reasons: []string{"shares class terpene", "same genus Streptomyces"},
+				},
+			},
+		},
+	}
+
+	actual := Compare(entry, testProfiles, 10)
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected comparison (-want +got):\n%s", diff)
+	}
+
+	limited := Compare(entry, testProfiles, 1)
+	if len(limited[0].Matches) != 1 {
+		t.Errorf("expected matches to be limited to 1, got %d", len(limited[0].Matches))
+	}
+}
+
+func TestMibigClass(t *testing.T) {
+	tests := []struct {
+		Category string
+		Product  string
+		Expected string
+	}{
+		{"RiPP", "lanthipeptide-class-i", "ribosomal"},
+		{"", "T1PKS", "PKS"},
+		{"", "NRPS-like", "NRPS"},
+		{"", "something-new", "other"},
+	}
+	for _, tt := range tests {
+		if actual := mibigClass(tt.Category, tt.Product); actual != tt.Expected {
+			t.Errorf("mibigClass(%q, %q): expected %s, got %s", tt.Category, tt.Product, tt.Expected, actual)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package antismash
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+	"secondarymetabolites.org/mibig-api/internal/schema"
+)
+
+// Placeholders of required fields antiSMASH results have no value for
+const (
+	placeholderAccession = "BGC0000000"
+	placeholderTaxId     = 1
+	placeholderName      = "TODO"
+)
+
+// ErrNoProtocluster is returned when the record or protocluster to draft from does not exist
+var ErrNoProtocluster = errors.New("protocluster not found")
+
+// DraftOptions select the protocluster to draft from and the values antiSMASH results lack.
+// Protocluster is the 1-based number of the protocluster in its record.
+type DraftOptions struct {
+	Record       string
+	Protocluster int
+	Accession    string
+	TaxId        int64
+}
+
+var locationPattern = regexp.MustCompile(`^\[<?(\d+):>?(\d+)\]`)
+
+// Draft fills in a new MIBiG entry from a protocluster of an antiSMASH result.
+// Placeholders and anything failing schema validation are listed in the todo list of the draft.
+func Draft(entry *data.AsEntry, opts DraftOptions) (*data.AntismashDraft, error) {
+	record, err := findRecord(entry, opts.Record)
+	if err != nil {
+		return nil, err
+	}
+	protoclusters := record.Modules.HmmDetection.RuleResults.CdsByProtocluster
+	if opts.Protocluster < 1 || opts.Protocluster > len(protoclusters) {
+		return nil, fmt.Errorf("%w: record %s has %d protoclusters, not %d", ErrNoProtocluster, record.Id, len(protoclusters), opts.Protocluster)
+	}
+	cbp := protoclusters[opts.Protocluster-1]
+	pc := cbp.Protocluster
+	product := firstQualifier(pc.Qualifiers, "product")
+	if product == "" {
+		product = pc.Type
+	}
+
+	draft := data.AntismashDraft{Todo: []data.DraftTodo{}}
+	todo := func(pointer, message string) {
+		draft.Todo = append(draft.Todo, data.DraftTodo{Pointer: pointer, Message: message})
+	}
+
+	mibig := &draft.Entry
+	mibig.Accession = opts.Accession
+	if mibig.Accession == "" {
+		mibig.Accession = placeholderAccession
+		todo("/accession", "reserve an accession for the entry")
+	}
+	mibig.Version = 1
+	mibig.Status = "pending"
+	mibig.Quality = "questionable"
+	mibig.Completeness = "unknown"
+	mibig.Comment = draftComment(entry, record, pc.Location, product)
+
+	mibig.Taxonomy.Name = record.Annotations.Organism
+	if mibig.Taxonomy.Name == "" {
+		mibig.Taxonomy.Name = placeholderName
+		todo("/taxonomy/name", "the antiSMASH record has no organism")
+	}
+	mibig.Taxonomy.NcbiTaxId = opts.TaxId
+	if mibig.Taxonomy.NcbiTaxId < 1 {
+		mibig.Taxonomy.NcbiTaxId = placeholderTaxId
+		todo("/taxonomy/ncbiTaxId", fmt.Sprintf("set the NCBI taxid of %s", mibig.Taxonomy.Name))
+	}
+
+	locus := data.MibigLocus{Accession: record.Id}
+	if match := locationPattern.FindStringSubmatch(pc.Location); match != nil {
+		start, _ := strconv.ParseInt(match[1], 10, 64)
+		end, _ := strconv.ParseInt(match[2], 10, 64)
+		// antiSMASH locations are 0-based and end-exclusive
+		locus.Location = &data.MibigLocation{From: start + 1, To: end}
+	} else {
+		todo("/loci/0/location", fmt.Sprintf("could not parse protocluster location %q", pc.Location))
+	}
+	mibig.Loci = []data.MibigLocus{locus}
+
+	mibig.Biosynthesis.Classes = []data.MibigClass{{Class: mibigClass(firstQualifier(pc.Qualifiers, "category"), product)}}
+
+	mibig.Compounds = []data.MibigCompound{{Name: placeholderName}}
+	todo("/compounds/0/name", "name the compounds produced by the cluster")
+
+	mibig.Genes.Annotations = []data.MibigGeneAnnotation{}
+	for _, cds := range cbp.Cdses {
+		gene := data.MibigGeneAnnotation{Id: cds.Name}
+		for _, domain := range cds.Domains {
+			if !slices.Contains(gene.Domains, domain.Name) {
+				gene.Domains = append(gene.Domains, domain.Name)
+			}
+		}
+		sort.Strings(gene.Domains)
+		mibig.Genes.Annotations = append(mibig.Genes.Annotations, gene)
+	}
+
+	raw, err := json.Marshal(mibig)
+	if err != nil {
+		return nil, err
+	}
+	if err = schema.Validate(raw); err != nil {
+		var invalid *schema.ValidationError
+		if !errors.As(err, &invalid) {
+			return nil, err
+		}
+		for _, field := range invalid.Errors {
+			todo(field.Pointer, field.Message)
+		}
+	}
+
+	return &draft, nil
+}
+
+// SelectRecord reads the record to draft from, keeping only that one in memory.
+// Without an id, the result must only have one record.
+func SelectRecord(r data.AsRecordReader, id string) (*data.AsEntry, error) {
+	var selected *data.AsRecord
+	count := 0
+	for {
+		record, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		count++
+		if selected == nil && (id == "" || record.Id == id) {
+			selected = record
+		}
+	}
+	if id == "" && count != 1 {
+		return nil, fmt.Errorf("%w: result has %d records, select one", ErrNoProtocluster, count)
+	}
+	if selected == nil {
+		return nil, fmt.Errorf("%w: no record %s", ErrNoProtocluster, id)
+	}
+
+	entry := r.Entry()
+	entry.Records = []data.AsRecord{*selected}
+	return &entry, nil
+}
+
+// findRecord looks up a record by id. Without an id, the result must only have one record.
+func findRecord(entry *data.AsEntry, id string) (*data.AsRecord, error) {
+	if id == "" {
+		if len(entry.Records) != 1 {
+			return nil, fmt.Errorf("%w: result has %d records, select one", ErrNoProtocluster, len(entry.Records))
+		}
+		return &entry.Records[0], nil
+	}
+	for i := range entry.Records {
+		if entry.Records[i].Id == id {
+			return &entry.Records[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no record %s", ErrNoProtocluster, id)
+}
+
+// draftComment notes the origin of a draft and the lineage of its organism, which
+// MIBiG takes from the taxid instead
+func draftComment(entry *data.AsEntry, record *data.AsRecord, location, product string) string {
+	comment := fmt.Sprintf("Drafted from the %s protocluster at %s %s", product, record.Id, location)
+	if entry.Version != "" {
+		comment += fmt.Sprintf(" by antiSMASH %s", entry.Version)
+	}
+	comment += "."
+	if len(record.Annotations.Taxonomy) > 0 {
+		comment += fmt.Sprintf(" Lineage: %s.", strings.Join(record.Annotations.Taxonomy, "; "))
+	}
+	return comment
+}
@@ -0,0 +1,122 @@
+package antismash
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+	"secondarymetabolites.org/mibig-api/internal/schema"
+)
+
+func TestDraft(t *testing.T) {
+	entry := loadEntry(t, "testdata/minimal.json")
+
+	draft, err := Draft(entry, DraftOptions{Protocluster: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := data.MibigDraft{
+		MibigEntry: data.MibigEntry{
+			Accession:    "BGC0000000",
+			Version:      1,
+			Status:       "pending",
+			Quality:      "questionable",
+			Completeness: "unknown",
+			Taxonomy:     data.MibigTaxonomy{Name: "Streptomyces coelicolor A3(2)", NcbiTaxId: 1},
+			Comment: "Drafted from the NRPS protocluster at NC_003888.3 [1000:40000] by antiSMASH 7.1.0. " +
+				"Lineage: Bacteria; Actinomycetota; Actinomycetes; Kitasatosporales; Streptomycetaceae; Streptomyces.",
+		},
+		Loci:         []data.MibigLocus{{Accession: "NC_003888.3", Location: &data.MibigLocation{From: 1001, To: 40000}}},
+		Biosynthesis: data.MibigBiosynthesis{Classes: []data.MibigClass{{Class: "NRPS"}}},
+		Compounds:    []data.MibigCompound{{Name: "TODO"}},
+		Genes: data.MibigGenes{Annotations: []data.MibigGeneAnnotation{
+			{Id: "SCO3230", Domains: []string{"AMP-binding", "Condensation"}},
+			{Id: "SCO3231", Domains: []string{"AMP-binding"}},
+		}},
+	}
+	if diff := cmp.Diff(expected, draft.Entry); diff != "" {
+		t.Errorf("unexpected draft (-want +got):\n%s", diff)
+	}
+
+	pointers := []string{}
+	for _, todo := range draft.Todo {
+		pointers = append(pointers, todo.Pointer)
+	}
+	if diff := cmp.Diff([]string{"/accession", "/taxonomy/ncbiTaxId", "/compounds/0/name"}, pointers); diff != "" {
+		t.Errorf("unexpected todo list (-want +got):\n%s", diff)
+	}
+
+	raw, err := json.Marshal(draft.Entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = schema.Validate(raw); err != nil {
+		t.Errorf("draft does not validate: %s", err)
+	}
+}
+
+func TestDraftOptions(t *testing.T) {
+	entry := loadEntry(t, "testdata/minimal.json")
+
+	draft, err := Draft(entry, DraftOptions{Record: "NC_003888.3", Protocluster: 2, Accession: "BGC0001234", TaxId: 100226})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if draft.Entry.Accession != "BGC0001234" || draft.Entry.Taxonomy.NcbiTaxId != 100226 {
+		t.Errorf("expected the given accession and taxid, got %s %d", draft.Entry.Accession, draft.Entry.Taxonomy.NcbiTaxId)
+	}
+	if draft.Entry.Biosynthesis.Classes[0].Class != "terpene" || draft.Entry.Loci[0].Location.From != 50001 {
+		t.Errorf("expected the second protocluster, got %+v", draft.Entry)
+	}
+	if len(draft.Todo) != 1 || draft.Todo[0].Pointer != "/compounds/0/name" {
+		t.Errorf("expected only the compounds to do, got %+v", draft.Todo)
+	}
+
+	// records without a versioned accession fail validation, which ends up in the todo list
+	entry.Records[0].Id = "contig_1"
+	draft, err = Draft(entry, DraftOptions{Protocluster: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if last := draft.Todo[len(draft.Todo)-1]; last.Pointer != "/loci/0/accession" {
+		t.Errorf("expected the locus accession to fail validation, got %+v", draft.Todo)
+	}
+
+	for _, opts := range []DraftOptions{{Protocluster: 3}, {Protocluster: 0}, {Record: "missing", Protocluster: 1}} {
+		if _, err = Draft(entry, opts); !errors.Is(err, ErrNoProtocluster) {
+			t.Errorf("%+v: expected ErrNoProtocluster, got %v", opts, err)
+		}
+	}
+}
+
+func TestSelectRecord(t *testing.T) {
+	input := testResult("7.1.0", `["AMP-binding", 1e-10, 50.5, 20, "tool"]`)
+
+	entry, err := SelectRecord(NewReader(strings.NewReader(input)), "rec2.1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(entry.Records) != 1 || entry.Records[0].Id != "rec2.1" {
+		t.Errorf("expected only record rec2.1, got %+v", entry.Records)
+	}
+	// the fields following the records are kept
+	if entry.Version != "7.1.0" || entry.Taxon != "bacteria" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+
+	for _, id := range []string{"", "missing"} {
+		if _, err = SelectRecord(NewReader(strings.NewReader(input)), id); !errors.Is(err, ErrNoProtocluster) {
+			t.Errorf("%q: expected ErrNoProtocluster, got %v", id, err)
+		}
+	}
+
+	var parseErr *ParseError
+	if _, err = SelectRecord(NewReader(strings.NewReader(`{"records": [`)), "rec1.1"); !errors.As(err, &parseErr) {
+		t.Errorf("expected a ParseError, got %v", err)
+	}
+}
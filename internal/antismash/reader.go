@@ -0,0 +1,366 @@
+package antismash
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+// hmmDetectionModule is the key of the hmm_detection results in the modules of a record
+const hmmDetectionModule = "antismash.detection.hmm_detection"
+
+var (
+	// ErrUnsupportedVersion is returned for results of antiSMASH versions or schemas the reader does not know
+	ErrUnsupportedVersion = errors.New("unsupported antiSMASH version")
+	// ErrMalformed is returned for results not matching the format of their antiSMASH version
+	ErrMalformed = errors.New("malformed antiSMASH result")
+)
+
+// ParseError is an error reading an antiSMASH result, with the record and the
+// slash-separated path of the value that failed to parse
+type ParseError struct {
+	Record string
+	Path   string
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	if e.Record == "" {
+		return fmt.Sprintf("%s: %s", e.Path, e.Err)
+	}
+	return fmt.Sprintf("record %s, %s: %s", e.Record, e.Path, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// format describes the results of a major antiSMASH version
+type format struct {
+	// number of fields of the domain hits of a CDS
+	domainFields int
+	// newest schema versions of the results and of the hmm_detection module and its rule results
+	resultsSchema      int64
+	hmmDetectionSchema int64
+	ruleResultsSchema  int64
+}
+
+// formats are the supported antiSMASH major versions
+var formats = map[int]format{
+	6: {domainFields: 4, resultsSchema: 1, hmmDetectionSchema: 2, ruleResultsSchema: 2},
+	7: {domainFields: 5, resultsSchema: 1, hmmDetectionSchema: 2, ruleResultsSchema: 2},
+	8: {domainFields: 5, resultsSchema: 1, hmmDetectionSchema: 2, ruleResultsSchema: 2},
+}
+
+// currentMajor is the format assumed for records preceding the version in a result
+const currentMajor = 8
+
+// Reader streams the records of an antiSMASH results JSON, so only one record is in memory at a time.
+// Of the modules, only hmm_detection is read.
+type Reader struct {
+	dec     *json.Decoder
+	entry   data.AsEntry
+	format  *format
+	index   int
+	started bool
+	done    bool
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{dec: json.NewDecoder(r)}
+}
+
+// Next returns the next record, or io.EOF after the last one
+func (r *Reader) Next() (*data.AsRecord, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+	if !r.started {
+		r.started = true
+		if err := r.expectDelim('{', ""); err != nil {
+			return nil, err
+		}
+		if err := r.readFields(); err != nil {
+			return nil, err
+		}
+		if r.done {
+			return nil, io.EOF
+		}
+	}
+
+	if !r.dec.More() {
+		if err := r.expectDelim(']', "records"); err != nil {
+			return nil, err
+		}
+		if err := r.readFields(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+
+	var raw json.RawMessage
+	path := fmt.Sprintf("records/%d", r.index)
+	if err := r.dec.Decode(&raw); err != nil {
+		return nil, &ParseError{Path: path, Err: err}
+	}
+	r.index++
+	return r.decodeRecord(raw, path)
+}
+
+// Entry is the result without its records. The fields following the records
+// are only set once Next returned io.EOF.
+func (r *Reader) Entry() data.AsEntry {
+	return r.entry
+}
+
+// Read reads a whole antiSMASH result into memory. Use a Reader to handle one record at a time.
+func Read(input io.Reader) (*data.AsEntry, error) {
+	r := NewReader(input)
+	records := []data.AsRecord{}
+	for {
+		record, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, *record)
+	}
+	entry := r.Entry()
+	entry.Records = records
+	return &entry, nil
+}
+
+// readFields reads the top-level fields up to the start of the records or the end of the result
+func (r *Reader) readFields() error {
+	for r.dec.More() {
+		token, err := r.dec.Token()
+		if err != nil {
+			return &ParseError{Err: err}
+		}
+		key, _ := token.(string)
+
+		switch key {
+		case "records":
+			if err = r.expectDelim('[', key); err != nil {
+				return err
+			}
+			if r.format == nil {
+				current := formats[currentMajor]
+				r.format = &current
+			}
+			return nil
+		case "version":
+			err = r.dec.Decode(&r.entry.Version)
+			if err == nil {
+				err = r.setFormat()
+			}
+		case "input_file":
+			err = r.dec.Decode(&r.entry.InputFile)
+		case "taxon":
+			err = r.dec.Decode(&r.entry.Taxon)
+		case "timings":
+			err = r.dec.Decode(&r.entry.Timings)
+		case "schema":
+			err = r.dec.Decode(&r.entry.Schema)
+		default:
+			var skipped json.RawMessage
+			err = r.dec.Decode(&skipped)
+		}
+		if err != nil {
+			return asParseError(err, "", key)
+		}
+	}
+
+	if err := r.expectDelim('}', ""); err != nil {
+		return err
+	}
+	r.done = true
+
+	if r.entry.Version == "" {
+		return &ParseError{Path: "version", Err: fmt.Errorf("%w: no version given", ErrUnsupportedVersion)}
+	}
+	if r.entry.Schema > r.format.resultsSchema {
+		return &ParseError{Path: "schema", Err: fmt.Errorf("%w: schema %d is newer than %d", ErrUnsupportedVersion, r.entry.Schema, r.format.resultsSchema)}
+	}
+	return nil
+}
+
+// setFormat picks the format of the major version of the result
+func (r *Reader) setFormat() error {
+	majorPart, _, _ := strings.Cut(r.entry.Version, ".")
+	major, err := strconv.Atoi(majorPart)
+	if err != nil {
+		return &ParseError{Path: "version", Err: fmt.Errorf("%w: %q", ErrUnsupportedVersion, r.entry.Version)}
+	}
+	found, ok := formats[major]
+	if !ok {
+		return &ParseError{Path: "version", Err: fmt.Errorf("%w: %s", ErrUnsupportedVersion, r.entry.Version)}
+	}
+	if r.format != nil && *r.format != found {
+		return &ParseError{Path: "version", Err: fmt.Errorf("%w: records before the version were read as antiSMASH %d", ErrUnsupportedVersion, currentMajor)}
+	}
+	r.format = &found
+	return nil
+}
+
+func (r *Reader) expectDelim(delim json.Delim, path string) error {
+	token, err := r.dec.Token()
+	if err != nil {
+		return &ParseError{Path: path, Err: err}
+	}
+	if token != delim {
+		return &ParseError{Path: path, Err: fmt.Errorf("%w: expected %s, got %v", ErrMalformed, delim, token)}
+	}
+	return nil
+}
+
+func (r *Reader) decodeRecord(raw json.RawMessage, path string) (*data.AsRecord, error) {
+	var fields struct {
+		Id          string                     `json:"id"`
+		Annotations json.RawMessage            `json:"annotations"`
+		Modules     map[string]json.RawMessage `json:"modules"`
+	}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, asParseError(err, "", path)
+	}
+
+	record := data.AsRecord{Id: fields.Id}
+	if len(fields.Annotations) > 0 {
+		if err := json.Unmarshal(fields.Annotations, &record.Annotations); err != nil {
+			return nil, asParseError(err, record.Id, path+"/annotations")
+		}
+	}
+	if module, ok := fields.Modules[hmmDetectionModule]; ok {
+		err := r.decodeHmmDetection(module, &record.Modules.HmmDetection, record.Id, path+"/modules/"+hmmDetectionModule)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &record, nil
+}
+
+func (r *Reader) decodeHmmDetection(raw json.RawMessage, results *data.AsHmmDetectionResults, recordId, path string) error {
+	var fields struct {
+		RecordId      string          `json:"record_id"`
+		EnabledTypes  []string        `json:"enabled_types"`
+		SchemaVersion int64           `json:"schema_version"`
+		RuleResults   json.RawMessage `json:"rule_results"`
+	}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return asParseError(err, recordId, path)
+	}
+	if fields.SchemaVersion > r.format.hmmDetectionSchema {
+		return &ParseError{Record: recordId, Path: path + "/schema_version", Err: fmt.Errorf("%w: schema version %d is newer than %d",
+			ErrUnsupportedVersion, fields.SchemaVersion, r.format.hmmDetectionSchema)}
+	}
+	results.RecordId = fields.RecordId
+	results.EnabledTypes = fields.EnabledTypes
+	results.SchemaVersion = fields.SchemaVersion
+
+	if len(fields.RuleResults) == 0 {
+		return nil
+	}
+	path += "/rule_results"
+	var rules struct {
+		SchemaVersion     int64             `json:"schema_version"`
+		Tool              string            `json:"tool"`
+		CdsByProtocluster []json.RawMessage `json:"cds_by_protocluster"`
+	}
+	if err := json.Unmarshal(fields.RuleResults, &rules); err != nil {
+		return asParseError(err, recordId, path)
+	}
+	if rules.SchemaVersion > r.format.ruleResultsSchema {
+		return &ParseError{Record: recordId, Path: path + "/schema_version", Err: fmt.Errorf("%w: schema version %d is newer than %d",
+			ErrUnsupportedVersion, rules.SchemaVersion, r.format.ruleResultsSchema)}
+	}
+	results.RuleResults.SchemaVersion = rules.SchemaVersion
+	results.RuleResults.Tool = rules.Tool
+
+	results.RuleResults.CdsByProtocluster = make([]data.CdsByProtocluster, 0, len(rules.CdsByProtocluster))
+	for i, rawCbp := range rules.CdsByProtocluster {
+		cbp, err := r.decodeCdsByProtocluster(rawCbp, recordId, fmt.Sprintf("%s/cds_by_protocluster/%d", path, i))
+		if err != nil {
+			return err
+		}
+		results.RuleResults.CdsByProtocluster = append(results.RuleResults.CdsByProtocluster, cbp)
+	}
+	return nil
+}
+
+func (r *Reader) decodeCdsByProtocluster(raw json.RawMessage, recordId, path string) (data.CdsByProtocluster, error) {
+	var cbp data.CdsByProtocluster
+	var pair []json.RawMessage
+	if err := json.Unmarshal(raw, &pair); err != nil {
+		return cbp, asParseError(err, recordId, path)
+	}
+	if len(pair) != 2 {
+		return cbp, &ParseError{Record: recordId, Path: path, Err: fmt.Errorf("%w: expected a protocluster and its CDSes, got %d values", ErrMalformed, len(pair))}
+	}
+	if err := json.Unmarshal(pair[0], &cbp.Protocluster); err != nil {
+		return cbp, asParseError(err, recordId, path+"/0")
+	}
+
+	var cdses []json.RawMessage
+	if err := json.Unmarshal(pair[1], &cdses); err != nil {
+		return cbp, asParseError(err, recordId, path+"/1")
+	}
+	cbp.Cdses = make([]data.ProtoclusterCds, 0, len(cdses))
+	for i, rawCds := range cdses {
+		cdsPath := fmt.Sprintf("%s/1/%d", path, i)
+		var fields struct {
+			Name              string              `json:"cds_name"`
+			Domains           []json.RawMessage   `json:"domains"`
+			DefinitionDomains map[string][]string `json:"definition_domains"`
+		}
+		if err := json.Unmarshal(rawCds, &fields); err != nil {
+			return cbp, asParseError(err, recordId, cdsPath)
+		}
+
+		cds := data.ProtoclusterCds{Name: fields.Name, DefinitionDomains: fields.DefinitionDomains, Domains: make([]data.CdsDomain, 0, len(fields.Domains))}
+		for j, rawDomain := range fields.Domains {
+			domain, err := r.decodeDomain(rawDomain)
+			if err != nil {
+				return cbp, asParseError(err, recordId, fmt.Sprintf("%s/domains/%d", cdsPath, j))
+			}
+			cds.Domains = append(cds.Domains, domain)
+		}
+		cbp.Cdses = append(cbp.Cdses, cds)
+	}
+	return cbp, nil
+}
+
+// decodeDomain reads a domain hit, which antiSMASH 7 extended by the tool finding it
+func (r *Reader) decodeDomain(raw json.RawMessage) (data.CdsDomain, error) {
+	var domain data.CdsDomain
+	var values []json.RawMessage
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return domain, err
+	}
+	if len(values) != r.format.domainFields {
+		return domain, fmt.Errorf("%w: expected %d domain fields, got %d", ErrMalformed, r.format.domainFields, len(values))
+	}
+
+	targets := []any{&domain.Name, &domain.EValue, &domain.Bitscore, &domain.NumSeeds, &domain.Tool}
+	for i, value := range values {
+		if err := json.Unmarshal(value, targets[i]); err != nil {
+			return domain, fmt.Errorf("domain field %d: %w", i, err)
+		}
+	}
+	return domain, nil
+}
+
+// asParseError adds the location to errors that do not have one yet
+func asParseError(err error, recordId, path string) error {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return err
+	}
+	return &ParseError{Record: recordId, Path: path, Err: err}
+}
@@ -0,0 +1,140 @@
+package antismash
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+// testResult is a result with one protocluster in each of two records, with the given domain hit
+func testResult(version, domain string) string {
+	record := func(id string) string {
+		return fmt.Sprintf(`{"id": "%s", "seq": {"data": "ACGT"}, "features": [],
+			"annotations": {"organism": "Streptomyces coelicolor"},
+			"modules": {"antismash.detection.hmm_detection": {"record_id": "%s", "schema_version": 2,
+				"rule_results": {"schema_version": 2, "tool": "rule-based-clusters", "cds_by_protocluster": [
+					[{"location": "[10:200]", "type": "protocluster", "qualifiers": {"product": ["NRPS"]}},
+					 [{"cds_name": "gene1", "domains": [%s], "definition_domains": {}}]]]}}}}`, id, id, domain)
+	}
+	return fmt.Sprintf(`{"version": "%s", "input_file": "test.gbk", "records": [%s, %s], "taxon": "bacteria", "schema": 1}`,
+		version, record("rec1.1"), record("rec2.1"))
+}
+
+func TestRead(t *testing.T) {
+	raw, err := os.ReadFile("testdata/minimal.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := loadEntry(t, "testdata/minimal.json")
+	actual, err := Read(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected result (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadVersions(t *testing.T) {
+	tests := []struct {
+		Version  string
+		Domain   string
+		Expected data.CdsDomain
+	}{
+		{"6.1.1", `["AMP-binding", 1e-10, 50.5, 20]`, data.CdsDomain{Name: "AMP-binding", EValue: 1e-10, Bitscore: 50.5, NumSeeds: 20}},
+		{"7.1.0", `["AMP-binding", 1e-10, 50.5, 20, "rule-based-clusters"]`, data.CdsDomain{Name: "AMP-binding", EValue: 1e-10, Bitscore: 50.5, NumSeeds: 20, Tool: "rule-based-clusters"}},
+		{"8.0.0-abcdef", `["AMP-binding", 1e-10, 50.5, 20, "rule-based-clusters"]`, data.CdsDomain{Name: "AMP-binding", EValue: 1e-10, Bitscore: 50.5, NumSeeds: 20, Tool: "rule-based-clusters"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Version, func(t *testing.T) {
+			entry, err := Read(strings.NewReader(testResult(tt.Version, tt.Domain)))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if entry.Version != tt.Version || entry.Taxon != "bacteria" || len(entry.Records) != 2 {
+				t.Fatalf("unexpected result %+v", entry)
+			}
+			actual := entry.Records[1].Modules.HmmDetection.RuleResults.CdsByProtocluster[0].Cdses[0].Domains[0]
+			if diff := cmp.Diff(tt.Expected, actual); diff != "" {
+				t.Errorf("unexpected domain (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	domainPath := "records/0/modules/antismash.detection.hmm_detection/rule_results/cds_by_protocluster/0/1/0/domains/0"
+	tests := []struct {
+		Name     string
+		Input    string
+		Record   string
+		Path     string
+		Expected error
+	}{
+		{"short domain", testResult("7.1.0", `["AMP-binding", 1e-10]`), "rec1.1", domainPath, ErrMalformed},
+		{"previous format domain", testResult("7.1.0", `["AMP-binding", 1e-10, 50.5, 20]`), "rec1.1", domainPath, ErrMalformed},
+		{"mistyped domain", testResult("7.1.0", `[1, 1e-10, 50.5, 20, "tool"]`), "rec1.1", domainPath, nil},
+		{"unsupported version", testResult("5.2.0", ""), "", "version", ErrUnsupportedVersion},
+		{"invalid version", testResult("latest", ""), "", "version", ErrUnsupportedVersion},
+		{"missing version", `{"records": [], "schema": 1}`, "", "version", ErrUnsupportedVersion},
+		{"newer schema", strings.Replace(testResult("7.1.0", ""), `"schema": 1`, `"schema": 3`, 1), "", "schema", ErrUnsupportedVersion},
+		{
+			"newer module schema", strings.Replace(testResult("7.1.0", ""), `"rec1.1", "schema_version": 2`, `"rec1.1", "schema_version": 9`, 1),
+			"rec1.1", "records/0/modules/antismash.detection.hmm_detection/schema_version", ErrUnsupportedVersion,
+		},
+		{
+			"missing cdses", strings.Replace(testResult("7.1.0", ""), `"qualifiers": {"product": ["NRPS"]}},`, `"qualifiers": {}}],[{},`, 1),
+			"rec1.1", "records/0/modules/antismash.detection.hmm_detection/rule_results/cds_by_protocluster/0", ErrMalformed,
+		},
+		{"not an object", `[]`, "", "", ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.Input))
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected a ParseError, got %v", err)
+			}
+			if parseErr.Record != tt.Record || parseErr.Path != tt.Path {
+				t.Errorf("expected error at %q %q, got %q %q", tt.Record, tt.Path, parseErr.Record, parseErr.Path)
+			}
+			if tt.Expected != nil && !errors.Is(err, tt.Expected) {
+				t.Errorf("expected %v, got %v", tt.Expected, err)
+			}
+		})
+	}
+}
+
+func TestReaderNext(t *testing.T) {
+	r := NewReader(strings.NewReader(testResult("7.1.0", `["AMP-binding", 1e-10, 50.5, 20, "tool"]`)))
+	ids := []string{}
+	for {
+		record, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		ids = append(ids, record.Id)
+	}
+	if diff := cmp.Diff([]string{"rec1.1", "rec2.1"}, ids); diff != "" {
+		t.Errorf("unexpected records (-want +got):\n%s", diff)
+	}
+	// the fields following the records are read at the end
+	if entry := r.Entry(); entry.Taxon != "bacteria" || entry.Schema != 1 || entry.InputFile != "test.gbk" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF after the last record, got %v", err)
+	}
+}
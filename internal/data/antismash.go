@@ -1,6 +1,9 @@
 package data
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 type AsEntry struct {
 	Version   string     `json:"version"`
@@ -11,6 +14,13 @@ type AsEntry struct {
 	Schema    int64      `json:"schema"`
 }
 
+// AsRecordReader iterates over the records of an antiSMASH result, returning io.EOF after the last one.
+// Entry holds the fields of the result besides its records.
+type AsRecordReader interface {
+	Next() (*AsRecord, error)
+	Entry() AsEntry
+}
+
 type Timings map[string]map[string]float64
 
 type AsRecord struct {
@@ -53,9 +63,12 @@ func (cbp *CdsByProtocluster) UnmarshalJSON(bs []byte) error {
 	if err != nil {
 		return err
 	}
+	if len(arr) != 2 {
+		return fmt.Errorf("expected a protocluster and its CDSes, got %d values", len(arr))
+	}
 
 	pc := Protocluster{}
-	cdses := make([]ProtoclusterCds, 5)
+	cdses := []ProtoclusterCds{}
 
 	err = json.Unmarshal(arr[0], &pc)
 	if err != nil {
@@ -96,18 +109,23 @@ type CdsDomain struct {
 	Tool     string
 }
 
+// UnmarshalJSON reads domain hits with or without the tool, which antiSMASH 7 added
 func (cd *CdsDomain) UnmarshalJSON(bs []byte) error {
-	arr := []interface{}{}
+	arr := []json.RawMessage{}
 	err := json.Unmarshal(bs, &arr)
 	if err != nil {
 		return err
 	}
+	if len(arr) != 4 && len(arr) != 5 {
+		return fmt.Errorf("expected 4 or 5 domain fields, got %d", len(arr))
+	}
 
-	cd.Name = arr[0].(string)
-	cd.EValue = arr[1].(float64)
-	cd.Bitscore = arr[2].(float64)
-	cd.NumSeeds = arr[3].(float64)
-	cd.Tool = arr[4].(string)
+	fields := []interface{}{&cd.Name, &cd.EValue, &cd.Bitscore, &cd.NumSeeds, &cd.Tool}
+	for i, value := range arr {
+		if err = json.Unmarshal(value, fields[i]); err != nil {
+			return fmt.Errorf("domain field %d: %w", i, err)
+		}
+	}
 
 	return nil
 }
@@ -0,0 +1,33 @@
+package data
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCdsDomainUnmarshal(t *testing.T) {
+	var domain CdsDomain
+	if err := json.Unmarshal([]byte(`["PKS_KS", 1e-20, 80.5, 12, "rule-based-clusters"]`), &domain); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := CdsDomain{Name: "PKS_KS", EValue: 1e-20, Bitscore: 80.5, NumSeeds: 12, Tool: "rule-based-clusters"}
+	if diff := cmp.Diff(expected, domain); diff != "" {
+		t.Errorf("unexpected domain (-want +got):\n%s", diff)
+	}
+
+	for _, invalid := range []string{`["PKS_KS", 1e-20]`, `[1, 1e-20, 80.5, 12]`, `{"name": "PKS_KS"}`} {
+		if err := json.Unmarshal([]byte(invalid), &CdsDomain{}); err == nil {
+			t.Errorf("expected an error reading %s", invalid)
+		}
+	}
+}
+
+func TestCdsByProtoclusterUnmarshal(t *testing.T) {
+	for _, invalid := range []string{`[]`, `[{"location": "[1:10]"}]`, `[{}, {}]`} {
+		if err := json.Unmarshal([]byte(invalid), &CdsByProtocluster{}); err == nil {
+			t.Errorf("expected an error reading %s", invalid)
+		}
+	}
+}
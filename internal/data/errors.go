@@ -4,10 +4,20 @@ import "errors"
 
 var (
 	ErrInvalidCategory    = errors.New("invalid search category")
+	ErrInvalidSortOrder   = errors.New("invalid sort order")
 	ErrInvalidCredentials = errors.New("models: invalid credentials")
 	ErrDuplicateEmail     = errors.New("models: duplicate email address")
 	ErrNoCredentails      = errors.New("no credentials found")
 	ErrNotImplemented     = errors.New("not implemented")
 	ErrRecordNotFound     = errors.New("record not found")
 	ErrEditConflict       = errors.New("edit condflict, please try again")
+	ErrNoSchema           = errors.New("schema does not exist")
+	ErrRequestClosed      = errors.New("request already resolved")
+	ErrReservationClosed  = errors.New("reservation no longer active")
+	ErrEntryRetired       = errors.New("entry already retired")
+	ErrEntryEmbargoed     = errors.New("entry is under embargo")
+	ErrUnknownSuccessor   = errors.New("unknown successor entry")
+	ErrDuplicateRelease   = errors.New("release already exists")
+	ErrTaxonDeleted       = errors.New("taxid was deleted by NCBI")
+	ErrNoSequenceIndex    = errors.New("sequence search index not built yet")
 )
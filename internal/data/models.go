@@ -67,6 +67,154 @@ type RepositoryEntry struct {
 	Products     []Product    `json:"products"`
 	ProductTags  []ProductTag `json:"classes"`
 	OrganismName string       `json:"organism"`
+	// only set for retired entries
+	RetirementReasons []string `json:"retirement_reasons,omitempty"`
+	SeeAlso           []string `json:"see_also,omitempty"`
+}
+
+// EntryRecord is the full MIBiG record of one entry version, as stored
+type EntryRecord struct {
+	EntryId   string
+	Accession string
+	Version   int
+	UpdatedAt time.Time
+	Data      []byte
+}
+
+// EntryVersion describes one version in the history of an entry
+type EntryVersion struct {
+	EntryId          string    `json:"entry_id"`
+	Version          int       `json:"version"`
+	Status           string    `json:"status"`
+	Quality          string    `json:"quality"`
+	Completeness     string    `json:"completeness"`
+	RetirementReason []string  `json:"retirement_reason,omitempty"`
+	SeeAlso          []string  `json:"see_also,omitempty"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// TaxonomyEntry is the lineage of an entry, from superkingdom to species, with its BGC classes
+type TaxonomyEntry struct {
+	Lineage []string
+	Classes []string
+}
+
+// Retirement is the audit record of retiring an entry
+type Retirement struct {
+	Accession      string    `json:"accession"`
+	RetiredEntryId string    `json:"retired_entry_id"`
+	EntryId        string    `json:"entry_id"`
+	Reasons        []string  `json:"reasons"`
+	SeeAlso        []string  `json:"see_also"`
+	RetiredBy      string    `json:"retired_by"`
+	RetiredAt      time.Time `json:"retired_at"`
+}
+
+// Pagination selects a sorted window of a result set. Paginate = 0 means no limit.
+type Pagination struct {
+	Sort       string
+	Descending bool
+	Paginate   int
+	Offset     int
+}
+
+type FastaRecord struct {
+	Id          string
+	Description string
+	Sequence    string
+}
+
+// CdsHit is a gene matching a search of type "cds"
+type CdsHit struct {
+	Accession string   `json:"accession"`
+	LocusTag  string   `json:"locus_tag"`
+	Name      string   `json:"name,omitempty"`
+	Product   string   `json:"product,omitempty"`
+	Functions []string `json:"functions"`
+}
+
+// DomainHit is a module domain matching a search of type "domain"
+type DomainHit struct {
+	Accession  string   `json:"accession"`
+	LocusTag   string   `json:"locus_tag"`
+	Module     string   `json:"module,omitempty"`
+	Domain     string   `json:"domain"`
+	Substrates []string `json:"substrates"`
+}
+
+// SequenceHit is a gene similar to the query of a sequence search.
+// Query coordinates are 1-based and inclusive, in nucleotides for nucleotide
+// queries with the reading frame of the hit, target coordinates are in amino acids.
+type SequenceHit struct {
+	Accession      string  `json:"accession"`
+	LocusTag       string  `json:"locus_tag"`
+	Product        string  `json:"product,omitempty"`
+	Identity       float64 `json:"identity"`
+	Coverage       float64 `json:"coverage"`
+	TargetCoverage float64 `json:"target_coverage"`
+	Bitscore       float64 `json:"bitscore"`
+	Evalue         float64 `json:"evalue"`
+	QueryStart     int     `json:"query_start"`
+	QueryEnd       int     `json:"query_end"`
+	TargetStart    int     `json:"target_start"`
+	TargetEnd      int     `json:"target_end"`
+	Frame          int     `json:"frame,omitempty"`
+}
+
+// SequenceQueryResult holds the hits for one sequence of a sequence search, best first
+type SequenceQueryResult struct {
+	Query       string        `json:"query"`
+	Description string        `json:"description,omitempty"`
+	Type        string        `json:"type"`
+	Length      int           `json:"length"`
+	Hits        []SequenceHit `json:"hits"`
+}
+
+// EntryProfile is what an entry is compared on: its classes, the domain types
+// of its modules, its taxonomy from superkingdom to species and its compounds
+type EntryProfile struct {
+	Accession string
+	Classes   []string
+	Domains   []string
+	Lineage   []string
+	Compounds []string
+}
+
+// ClusterComparison lists the entries most similar to an antiSMASH protocluster
+type ClusterComparison struct {
+	Record   string         `json:"record"`
+	Location string         `json:"location"`
+	Product  string         `json:"product"`
+	Class    string         `json:"class"`
+	Domains  []string       `json:"domains"`
+	Matches  []ClusterMatch `json:"matches"`
+}
+
+// ClusterMatch is an entry similar to a protocluster, with the reasons it matched.
+// The partial scores range from 0 to 1.
+type ClusterMatch struct {
+	Accession     string   `json:"accession"`
+	Compounds     []string `json:"compounds"`
+	Score         float64  `json:"score"`
+	ClassScore    float64  `json:"class_score"`
+	DomainScore   float64  `json:"domain_score"`
+	TaxonomyScore float64  `json:"taxonomy_score"`
+	Reasons       []string `json:"reasons"`
+}
+
+// SearchPlan is the compiled statement of a search and its query plan
+type SearchPlan struct {
+	Statement string   `json:"statement"`
+	Arguments []string `json:"arguments"`
+	Plan      []string `json:"plan"`
+}
+
+// TextMatch is the relevance of an entry to the full-text terms of a search,
+// with a snippet of the matching text
+type TextMatch struct {
+	EntryId string  `json:"entry_id"`
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet,omitempty"`
 }
 
 type LabelsAndCounts struct {
@@ -85,7 +233,7 @@ type MibigModel interface {
 	GenusStats() ([]TaxonStats, error)
 	Repository() ([]RepositoryEntry, error)
 	Search(t queries.QueryTerm) ([]string, error)
-	Get(ids []string) ([]RepositoryEntry, error)
+	Get(ids []string, page Pagination) ([]RepositoryEntry, error)
 	Available(category string, term string) ([]AvailableTerm, error)
 	ResultStats(ids []string) (*ResultStats, error)
 	GuessCategories(query *queries.Query) error
@@ -138,3 +286,43 @@ type Contributor struct {
 	Org3  string `json:"organisation_3,omitempty"`
 	Orcid string `json:"orcid,omitempty"`
 }
+
+// AntismashDraft is an entry drafted from an antiSMASH protocluster,
+// with the fields a curator still has to fill in
+type AntismashDraft struct {
+	Entry MibigDraft  `json:"entry"`
+	Todo  []DraftTodo `json:"todo"`
+}
+
+// DraftTodo is a field of a draft holding a placeholder or failing validation
+type DraftTodo struct {
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+// AntismashSummary lists the protoclusters antiSMASH detected in an entry
+type AntismashSummary struct {
+	EntryId       string                  `json:"entry_id"`
+	Version       string                  `json:"antismash_version"`
+	ImportedAt    time.Time               `json:"imported_at"`
+	Protoclusters []AntismashProtocluster `json:"protoclusters"`
+}
+
+// AntismashProtocluster is a protocluster of an entry with the CDSes it covers.
+// Number is the position of the protocluster in its record, starting at 1.
+type AntismashProtocluster struct {
+	Record        string         `json:"record"`
+	Number        int            `json:"number"`
+	Location      string         `json:"location"`
+	Product       string         `json:"product"`
+	Category      string         `json:"category,omitempty"`
+	DetectionRule string         `json:"detection_rule,omitempty"`
+	Cdses         []AntismashCds `json:"cdses"`
+}
+
+// AntismashCds is a CDS of a protocluster with the names of its domain hits
+type AntismashCds struct {
+	Name              string              `json:"name"`
+	Domains           []string            `json:"domains"`
+	DefinitionDomains map[string][]string `json:"definition_domains,omitempty"`
+}
@@ -0,0 +1,86 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Release is a MIBiG data release, with the counts of the entries it contains
+type Release struct {
+	Name    string     `json:"name"`
+	Notes   string     `json:"notes,omitempty"`
+	Created time.Time  `json:"created"`
+	Counts  StatCounts `json:"counts"`
+}
+
+// ReleaseEntry is an entry version as it was in a release
+type ReleaseEntry struct {
+	EntryId      string
+	Accession    string
+	Version      int
+	Status       string
+	Quality      string
+	Completeness string
+}
+
+// ChangelogEntry is one change of an entry between two releases.
+// From and To are the entry ids in the older and newer release,
+// OldValue and NewValue are set for quality and completeness changes.
+type ChangelogEntry struct {
+	Accession string `json:"accession"`
+	From      string `json:"from,omitempty"`
+	To        string `json:"to,omitempty"`
+	OldValue  string `json:"old_value,omitempty"`
+	NewValue  string `json:"new_value,omitempty"`
+}
+
+// Changelog lists the changes between two releases
+type Changelog struct {
+	From                string           `json:"from"`
+	To                  string           `json:"to"`
+	Added               []ChangelogEntry `json:"added"`
+	Updated             []ChangelogEntry `json:"updated"`
+	Retired             []ChangelogEntry `json:"retired"`
+	QualityChanged      []ChangelogEntry `json:"quality_changed"`
+	CompletenessChanged []ChangelogEntry `json:"completeness_changed"`
+}
+
+// Markdown renders the changelog for release notes
+func (c *Changelog) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Changes from MIBiG %s to %s\n", c.From, c.To)
+
+	sections := []struct {
+		Title   string
+		Entries []ChangelogEntry
+		Format  func(ChangelogEntry) string
+	}{
+		{"Added", c.Added, func(e ChangelogEntry) string { return e.To }},
+		{"Updated", c.Updated, func(e ChangelogEntry) string { return fmt.Sprintf("%s → %s", e.From, e.To) }},
+		{"Retired", c.Retired, func(e ChangelogEntry) string {
+			if e.To == "" {
+				return fmt.Sprintf("%s (removed)", e.From)
+			}
+			return e.To
+		}},
+		{"Quality changed", c.QualityChanged, func(e ChangelogEntry) string {
+			return fmt.Sprintf("%s: %s → %s", e.Accession, e.OldValue, e.NewValue)
+		}},
+		{"Completeness changed", c.CompletenessChanged, func(e ChangelogEntry) string {
+			return fmt.Sprintf("%s: %s → %s", e.Accession, e.OldValue, e.NewValue)
+		}},
+	}
+
+	for _, section := range sections {
+		fmt.Fprintf(&b, "\n## %s (%d)\n\n", section.Title, len(section.Entries))
+		if len(section.Entries) == 0 {
+			b.WriteString("None.\n")
+			continue
+		}
+		for _, entry := range section.Entries {
+			fmt.Fprintf(&b, "- %s\n", section.Format(entry))
+		}
+	}
+	return b.String()
+}
@@ -1,5 +1,14 @@
 package data
 
+import "time"
+
+const (
+	RequestReceived          = "received"
+	RequestUnderReview       = "under_review"
+	RequestAccessionAssigned = "accession_assigned"
+	RequestRejected          = "rejected"
+)
+
 type AccessionRequest struct {
 	Name      string                  `json:"name"`
 	Email     string                  `json:"email"`
@@ -12,3 +21,54 @@ type AccessionRequestLocus struct {
 	Start            int    `json:"start"`
 	End              int    `json:"end"`
 }
+
+// StoredAccessionRequest is an accession request as tracked by the reviewers
+type StoredAccessionRequest struct {
+	AccessionRequest
+	Ticket    string    `json:"ticket"`
+	Status    string    `json:"status"`
+	Reviewer  string    `json:"reviewer,omitempty"`
+	Accession string    `json:"accession,omitempty"`
+	Comment   string    `json:"comment,omitempty"`
+	Submitted time.Time `json:"submitted"`
+	Updated   time.Time `json:"updated"`
+}
+
+// IsOpen returns true if the request still waits for a decision
+func (r *StoredAccessionRequest) IsOpen() bool {
+	return r.Status == RequestReceived || r.Status == RequestUnderReview
+}
+
+func ValidRequestStatus(status string) bool {
+	switch status {
+	case RequestReceived, RequestUnderReview, RequestAccessionAssigned, RequestRejected:
+		return true
+	}
+	return false
+}
+
+const (
+	ReservationActive   = "active"
+	ReservationUsed     = "used"
+	ReservationExpired  = "expired"
+	ReservationReleased = "released"
+)
+
+// Reservation is an accession handed out before the entry exists
+type Reservation struct {
+	Accession  string     `json:"accession"`
+	Status     string     `json:"status"`
+	Ticket     string     `json:"ticket,omitempty"`
+	ReservedBy string     `json:"reserved_by,omitempty"`
+	Reserved   time.Time  `json:"reserved"`
+	Expires    *time.Time `json:"expires,omitempty"`
+	Released   *time.Time `json:"released,omitempty"`
+}
+
+func ValidReservationStatus(status string) bool {
+	switch status {
+	case ReservationActive, ReservationUsed, ReservationExpired, ReservationReleased:
+		return true
+	}
+	return false
+}
@@ -15,4 +15,58 @@ type MibigEntry struct {
 	RetirementReasons []string      `json:"retirement_reasons,omitempty"`
 	SeeAlso           []string      `json:"see_also,omitempty"`
 	Comment           string        `json:"comment,omitempty"`
+	EmbargoUntil      string        `json:"embargo_until,omitempty"`
+}
+
+// RawEntry keeps the parsed entry next to the JSON it was parsed from,
+// as the raw JSON is what ends up in the database.
+type RawEntry struct {
+	Entry MibigEntry
+	Raw   []byte
+}
+
+// MibigLocus is the sequence region of an entry
+type MibigLocus struct {
+	Accession string         `json:"accession"`
+	Location  *MibigLocation `json:"location,omitempty"`
+}
+
+// MibigLocation is a 1-based, inclusive range of a locus
+type MibigLocation struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+type MibigClass struct {
+	Class string `json:"class"`
+}
+
+type MibigBiosynthesis struct {
+	Classes []MibigClass `json:"classes"`
+}
+
+type MibigCompound struct {
+	Name string `json:"name"`
+}
+
+// MibigGeneAnnotation is a gene of an entry. Domains are the profiles antiSMASH
+// detected in drafted genes, for the curator to turn into modules.
+type MibigGeneAnnotation struct {
+	Id      string   `json:"id"`
+	Name    string   `json:"name,omitempty"`
+	Product string   `json:"product,omitempty"`
+	Domains []string `json:"domains,omitempty"`
+}
+
+type MibigGenes struct {
+	Annotations []MibigGeneAnnotation `json:"annotations"`
+}
+
+// MibigDraft is the skeleton of a new entry
+type MibigDraft struct {
+	MibigEntry
+	Loci         []MibigLocus      `json:"loci"`
+	Biosynthesis MibigBiosynthesis `json:"biosynthesis"`
+	Compounds    []MibigCompound   `json:"compounds"`
+	Genes        MibigGenes        `json:"genes"`
 }
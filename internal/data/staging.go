@@ -0,0 +1,11 @@
+package data
+
+type StagingCheck struct {
+	Staging  *StatCounts `json:"staging"`
+	Live     *StatCounts `json:"live"`
+	Problems []string    `json:"problems"`
+}
+
+func (c *StagingCheck) Ok() bool {
+	return len(c.Problems) == 0
+}
@@ -2,9 +2,11 @@ package data
 
 import (
 	"bufio"
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/spf13/viper"
 )
@@ -12,11 +14,16 @@ import (
 type TaxonCache struct {
 	DeprecatedIds map[int64]int64        `json:"deprecated_ids"`
 	Mappings      map[int64]NcbiTaxEntry `json:"mappings"`
+	// taxids NCBI deleted without a replacement
+	DeletedIds map[int64]bool `json:"deleted_ids,omitempty"`
 }
 
 func (t *TaxonCache) EntryForTaxId(taxId int64) (*NcbiTaxEntry, error) {
 	entry, found := t.Mappings[taxId]
 	if !found {
+		if t.DeletedIds[taxId] {
+			return nil, fmt.Errorf("%w: %d", ErrTaxonDeleted, taxId)
+		}
 		newTaxId, found := t.DeprecatedIds[taxId]
 		if !found {
 			return nil, ErrRecordNotFound
@@ -42,105 +49,222 @@ type NcbiTaxEntry struct {
 	Superkingdom string `json:"superkingdom"`
 }
 
-func EntryForTaxId(taxId int64) (*NcbiTaxEntry, error) {
-	dumpFileName := viper.GetString("taxa.lineage")
-	if dumpFileName == "" {
-		return nil, ErrRecordNotFound
-	}
+// TaxDumpFiles are the paths of the NCBI taxdump files used to build a taxon cache
+type TaxDumpFiles struct {
+	Lineage  string
+	Merged   string
+	Delnodes string
+}
 
-	dumpFile, err := os.Open(dumpFileName)
-	if err != nil {
-		return nil, err
+// TaxDumpFilesFromConfig gets the taxdump paths from the taxa section of the config
+func TaxDumpFilesFromConfig() TaxDumpFiles {
+	return TaxDumpFiles{
+		Lineage:  viper.GetString("taxa.lineage"),
+		Merged:   viper.GetString("taxa.merged"),
+		Delnodes: viper.GetString("taxa.delnodes"),
 	}
+}
 
-	scanner := bufio.NewScanner(dumpFile)
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		parts := strings.SplitN(line, "|", 11)
+// BuildTaxonCache parses the taxdump files once and indexes them.
+// If wanted is not nil, only the lineages of the wanted taxids and the taxids
+// they were merged into are kept. The merged and delnodes files are optional.
+func BuildTaxonCache(files TaxDumpFiles, wanted map[int64]bool) (*TaxonCache, error) {
+	cache := TaxonCache{
+		DeprecatedIds: map[int64]int64{},
+		Mappings:      map[int64]NcbiTaxEntry{},
+		DeletedIds:    map[int64]bool{},
+	}
 
-		for i, part := range parts {
-			trimmed := strings.TrimSpace(part)
-			if trimmed == "" {
-				trimmed = "Unknown"
+	if files.Merged != "" {
+		err := scanDumpFile(files.Merged, func(parts []string) error {
+			oldId, newId, err := parseMergedLine(parts)
+			if err != nil {
+				return err
 			}
-			parts[i] = trimmed
+			if wanted == nil || wanted[oldId] {
+				cache.DeprecatedIds[oldId] = newId
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		currTaxId, err := strconv.ParseInt(parts[0], 10, 64)
+	if files.Delnodes != "" {
+		err := scanDumpFile(files.Delnodes, func(parts []string) error {
+			taxId, err := strconv.ParseInt(parts[0], 10, 64)
+			if err != nil {
+				return err
+			}
+			if wanted == nil || wanted[taxId] {
+				cache.DeletedIds[taxId] = true
+			}
+			return nil
+		})
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		if currTaxId != taxId {
-			continue
+	keep := wanted
+	if wanted != nil {
+		keep = make(map[int64]bool, len(wanted)+len(cache.DeprecatedIds))
+		for taxId := range wanted {
+			keep[taxId] = true
+		}
+		for _, newId := range cache.DeprecatedIds {
+			keep[newId] = true
 		}
-
-		species_parts := strings.SplitN(parts[2], " ", 2)
-
-		return &NcbiTaxEntry{
-			TaxId:        taxId,
-			Name:         parts[1],
-			Species:      species_parts[len(species_parts)-1],
-			Genus:        parts[3],
-			Family:       parts[4],
-			Order:        parts[5],
-			Class:        parts[6],
-			Phylum:       parts[7],
-			Kingdom:      parts[8],
-			Superkingdom: parts[9],
-		}, nil
 	}
 
-	err = scanner.Err()
+	err := scanDumpFile(files.Lineage, func(parts []string) error {
+		taxId, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return err
+		}
+		if keep != nil && !keep[taxId] {
+			return nil
+		}
+		cache.Mappings[taxId] = parseLineageLine(taxId, parts)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Maybe the NCBI taxid isn't up-to-date anymore, let's try to find the new ID
-	newId, err := FindMergedId(taxId)
+	return &cache, nil
+}
+
+// scanDumpFile calls fn with the trimmed fields of every line of a taxdump file
+func scanDumpFile(fileName string, fn func(parts []string) error) error {
+	dumpFile, err := os.Open(fileName)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer dumpFile.Close()
 
-	return EntryForTaxId(newId)
+	scanner := bufio.NewScanner(dumpFile)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		parts := strings.Split(strings.TrimSuffix(line, "|"), "|")
+		for i, part := range parts {
+			parts[i] = strings.TrimSpace(part)
+		}
+		if err = fn(parts); err != nil {
+			return fmt.Errorf("%s line %d: %w", fileName, lineNumber, err)
+		}
+	}
+	return scanner.Err()
 }
 
-func FindMergedId(taxId int64) (int64, error) {
-	mergedFileName := viper.GetString("taxa.merged")
-	if mergedFileName == "" {
-		return -1, ErrRecordNotFound
+func parseLineageLine(taxId int64, parts []string) NcbiTaxEntry {
+	field := func(i int) string {
+		if i >= len(parts) || parts[i] == "" {
+			return "Unknown"
+		}
+		return parts[i]
 	}
 
-	mergedFile, err := os.Open(mergedFileName)
-	if err != nil {
-		return -1, err
-	}
+	species_parts := strings.SplitN(field(2), " ", 2)
 
-	scanner := bufio.NewScanner(mergedFile)
+	return NcbiTaxEntry{
+		TaxId:        taxId,
+		Name:         field(1),
+		Species:      species_parts[len(species_parts)-1],
+		Genus:        field(3),
+		Family:       field(4),
+		Order:        field(5),
+		Class:        field(6),
+		Phylum:       field(7),
+		Kingdom:      field(8),
+		Superkingdom: field(9),
+	}
+}
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		parts := strings.SplitN(line, "|", 3)
+func parseMergedLine(parts []string) (int64, int64, error) {
+	if len(parts) < 2 {
+		return -1, -1, fmt.Errorf("expected 2 fields, got %d", len(parts))
+	}
+	oldId, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return -1, -1, err
+	}
+	newId, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return -1, -1, err
+	}
+	return oldId, newId, nil
+}
 
-		oldId, err := strconv.ParseInt(strings.TrimSpace(parts[0]), 10, 64)
-		if err != nil {
-			return -1, err
-		}
+var (
+	dumpIndex     *TaxonCache
+	dumpIndexErr  error
+	dumpIndexOnce sync.Once
+)
 
-		newId, err := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
-		if err != nil {
-			return -1, err
+// taxDumpIndex loads the configured taxdump files into memory on first use
+func taxDumpIndex() (*TaxonCache, error) {
+	dumpIndexOnce.Do(func() {
+		files := TaxDumpFilesFromConfig()
+		if files.Lineage == "" {
+			dumpIndexErr = ErrRecordNotFound
+			return
 		}
+		dumpIndex, dumpIndexErr = BuildTaxonCache(files, nil)
+	})
+	return dumpIndex, dumpIndexErr
+}
 
-		if oldId == taxId {
-			return newId, nil
-		}
+// EntryForTaxId looks up a taxid in the configured taxdump, following merged taxids.
+// The dump is indexed on the first lookup.
+func EntryForTaxId(taxId int64) (*NcbiTaxEntry, error) {
+	index, err := taxDumpIndex()
+	if err != nil {
+		return nil, err
 	}
-	err = scanner.Err()
+	return index.EntryForTaxId(taxId)
+}
+
+func FindMergedId(taxId int64) (int64, error) {
+	index, err := taxDumpIndex()
 	if err != nil {
 		return -1, err
 	}
+	newId, found := index.DeprecatedIds[taxId]
+	if !found {
+		return -1, ErrRecordNotFound
+	}
+	return newId, nil
+}
+
+// RankChange is a change of one rank in the lineage of a taxon
+type RankChange struct {
+	Rank string `json:"rank"`
+	Old  string `json:"old"`
+	New  string `json:"new"`
+}
+
+// TaxonChange describes how a taxon in the database differs from the taxon cache
+type TaxonChange struct {
+	Name         string       `json:"name"`
+	OldNcbiTaxId int64        `json:"old_ncbi_taxid"`
+	NcbiTaxId    int64        `json:"ncbi_taxid"`
+	Ranks        []RankChange `json:"ranks,omitempty"`
+	Entries      []string     `json:"entries"`
+}
 
-	return -1, ErrRecordNotFound
+// TaxaRefresh reports the result of re-resolving the taxa in the database.
+// Deleted and Missing taxa are left alone and need manual attention.
+type TaxaRefresh struct {
+	DryRun    bool          `json:"dry_run"`
+	Changed   []TaxonChange `json:"changed"`
+	Deleted   []TaxonChange `json:"deleted"`
+	Missing   []TaxonChange `json:"missing"`
+	Unchanged int           `json:"unchanged"`
 }
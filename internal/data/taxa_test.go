@@ -0,0 +1,79 @@
+package data
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var testDump = TaxDumpFiles{
+	Lineage:  "testdata/taxdump/rankedlineage.dmp",
+	Merged:   "testdata/taxdump/merged.dmp",
+	Delnodes: "testdata/taxdump/delnodes.dmp",
+}
+
+func TestBuildTaxonCache(t *testing.T) {
+	cache, err := BuildTaxonCache(testDump, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cache.Mappings) != 3 {
+		t.Errorf("Expected 3 taxa, got %d", len(cache.Mappings))
+	}
+
+	expected := &NcbiTaxEntry{
+		TaxId:        1902,
+		Name:         "Streptomyces coelicolor",
+		Species:      "coelicolor",
+		Genus:        "Streptomyces",
+		Family:       "Streptomycetaceae",
+		Order:        "Kitasatosporales",
+		Class:        "Actinomycetes",
+		Phylum:       "Actinomycetota",
+		Kingdom:      "Bacillati",
+		Superkingdom: "Bacteria",
+	}
+
+	// merged taxids resolve to the entry of their replacement
+	entry, err := cache.EntryForTaxId(100226)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(expected, entry) {
+		t.Errorf("Unexpected entry:\n%s", cmp.Diff(expected, entry))
+	}
+
+	entry, err = cache.EntryForTaxId(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Genus != "Unknown" {
+		t.Errorf("Expected empty ranks to be Unknown, got %s", entry.Genus)
+	}
+
+	if _, err = cache.EntryForTaxId(12345); !errors.Is(err, ErrTaxonDeleted) {
+		t.Errorf("Expected %s for a deleted taxid, got %v", ErrTaxonDeleted, err)
+	}
+	if _, err = cache.EntryForTaxId(4); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("Expected %s for an unknown taxid, got %v", ErrRecordNotFound, err)
+	}
+}
+
+func TestBuildTaxonCacheRestricted(t *testing.T) {
+	cache, err := BuildTaxonCache(testDump, map[int64]bool{100226: true, 12345: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := cache.Mappings[1902]; !ok || len(cache.Mappings) != 1 {
+		t.Errorf("Expected only the merge target 1902, got %v", cache.Mappings)
+	}
+	if !cmp.Equal(map[int64]int64{100226: 1902}, cache.DeprecatedIds) {
+		t.Errorf("Unexpected deprecated ids %v", cache.DeprecatedIds)
+	}
+	if !cmp.Equal(map[int64]bool{12345: true}, cache.DeletedIds) {
+		t.Errorf("Unexpected deleted ids %v", cache.DeletedIds)
+	}
+}
@@ -1,80 +1,135 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/lib/pq"
 	"secondarymetabolites.org/mibig-api/internal/data"
 	"secondarymetabolites.org/mibig-api/internal/queries"
-	"secondarymetabolites.org/mibig-api/internal/utils"
 )
 
 type EntryModel interface {
 	Counts() (*data.StatCounts, error)
 	ClusterStats() ([]data.StatCluster, error)
 	PhylumStats() ([]data.TaxonStats, error)
+	Taxonomy(ids []string) ([]data.TaxonomyEntry, error)
 	Repository() ([]data.RepositoryEntry, error)
-	Get(ids []string) ([]data.RepositoryEntry, error)
+	Get(ids []string, page data.Pagination) ([]data.RepositoryEntry, error)
 	Search(t queries.QueryTerm) ([]string, error)
+	SearchCds(t queries.QueryTerm) ([]data.CdsHit, error)
+	SearchDomains(t queries.QueryTerm) ([]data.DomainHit, error)
+	Explain(query *queries.Query) (*data.SearchPlan, error)
+	TextMatches(ids []string, terms []string, page data.Pagination) ([]data.TextMatch, error)
+	Sequences(ids []string, protein bool) ([]data.FastaRecord, error)
+	ActiveProteins() ([]data.FastaRecord, error)
+	Profiles() ([]data.EntryProfile, error)
 	Available(category string, term string) ([]data.AvailableTerm, error)
 	ResultStats(ids []string) (*data.ResultStats, error)
 	GuessCategories(query *queries.Query) error
 	LookupContributors(ids []string) ([]data.Contributor, error)
 	Latest(accession string) (*data.RepositoryEntry, error)
+	Record(entry_id string) (*data.EntryRecord, error)
+	History(accession string) ([]data.EntryVersion, error)
+	IsEmbargoed(id string) (bool, error)
+	WithEmbargoed() EntryModel
 
 	Add(entry data.MibigEntry, raw []byte, taxCache *data.TaxonCache) error
 	Update(entry data.MibigEntry, raw []byte, taxCache *data.TaxonCache) error
+	AddBatch(entries []data.RawEntry, taxCache *data.TaxonCache, opts BatchOptions) ([]error, error)
 	Refresh() error
 	List() ([]data.MibigEntry, error)
 	LoadTaxonEntry(name string, ncbi_taxid int64, taxCache *data.TaxonCache) (int64, error)
 	Dump() error
+	PublishEmbargoed() ([]string, error)
+	Retire(accession string, reasons []string, seeAlso []string, retiredBy string) (*data.Retirement, error)
+	AddAntismash(accession string, records data.AsRecordReader) (string, error)
+	Antismash(accession string) (*data.AntismashSummary, error)
 }
 
 type LiveEntryModel struct {
 	DB *sql.DB
+	// Schema the import and maintenance methods write to, defaults to LiveSchema.
+	// The read methods always serve the live schema.
+	Schema string
+	// Embargoed makes the read methods include embargoed entries, for reviewers.
+	Embargoed bool
 }
 
 func NewEntryModel(db *sql.DB) *LiveEntryModel {
-	return &LiveEntryModel{DB: db}
+	return &LiveEntryModel{DB: db, Schema: LiveSchema}
+}
+
+func (m *LiveEntryModel) schema() string {
+	if m.Schema == "" {
+		return LiveSchema
+	}
+	return m.Schema
+}
+
+// WithEmbargoed returns a copy of the model that also reads embargoed entries
+func (m *LiveEntryModel) WithEmbargoed() EntryModel {
+	reviewer := *m
+	reviewer.Embargoed = true
+	return &reviewer
+}
+
+// visible returns the condition on live.entries that hides embargoed entries from the public
+func (m *LiveEntryModel) visible() string {
+	return visibleCondition(m.Embargoed)
+}
+
+func visibleCondition(includeEmbargoed bool) string {
+	if includeEmbargoed {
+		return "TRUE"
+	}
+	return "status <> 'embargoed'"
 }
 
 func (m *LiveEntryModel) Counts() (*data.StatCounts, error) {
-	stmt_total := `SELECT COUNT(entry_id) FROM live.entries`
-	stmt_complete := `SELECT COUNT(entry_id) FROM live.entries WHERE completeness = 'complete'`
-	stmt_partial := `SELECT COUNT(entry_id) FROM live.entries WHERE completeness = 'partial'`
-	stmt_pending := `SELECT COUNT(entry_id) FROM live.entries WHERE status = 'pending'`
-	stmt_active := `SELECT COUNT(entry_id) FROM live.entries WHERE status = 'active'`
-	stmt_retired := `SELECT COUNT(entry_id) FROM live.entries WHERE status = 'retired'`
+	return countsInSchema(m.DB, LiveSchema, m.Embargoed)
+}
+
+func countsInSchema(db *sql.DB, schema string, includeEmbargoed bool) (*data.StatCounts, error) {
+	visible := visibleCondition(includeEmbargoed)
+	stmt_total := fmt.Sprintf(`SELECT COUNT(entry_id) FROM %s.entries WHERE %s`, schema, visible)
+	stmt_complete := fmt.Sprintf(`SELECT COUNT(entry_id) FROM %s.entries WHERE completeness = 'complete' AND %s`, schema, visible)
+	stmt_partial := fmt.Sprintf(`SELECT COUNT(entry_id) FROM %s.entries WHERE completeness = 'partial' AND %s`, schema, visible)
+	stmt_pending := fmt.Sprintf(`SELECT COUNT(entry_id) FROM %s.entries WHERE status = 'pending'`, schema)
+	stmt_active := fmt.Sprintf(`SELECT COUNT(entry_id) FROM %s.entries WHERE status = 'active'`, schema)
+	stmt_retired := fmt.Sprintf(`SELECT COUNT(entry_id) FROM %s.entries WHERE status = 'retired'`, schema)
 	var counts data.StatCounts
 
-	err := m.DB.QueryRow(stmt_total).Scan(&counts.Total)
+	err := db.QueryRow(stmt_total).Scan(&counts.Total)
 	if err != nil {
 		return nil, err
 	}
 
-	err = m.DB.QueryRow(stmt_complete).Scan(&counts.Complete)
+	err = db.QueryRow(stmt_complete).Scan(&counts.Complete)
 	if err != nil {
 		return nil, err
 	}
 
-	err = m.DB.QueryRow(stmt_partial).Scan(&counts.Partial)
+	err = db.QueryRow(stmt_partial).Scan(&counts.Partial)
 	if err != nil {
 		return nil, err
 	}
 
-	err = m.DB.QueryRow(stmt_pending).Scan(&counts.Pending)
+	err = db.QueryRow(stmt_pending).Scan(&counts.Pending)
 	if err != nil {
 		return nil, err
 	}
 
-	err = m.DB.QueryRow(stmt_active).Scan(&counts.Active)
+	err = db.QueryRow(stmt_active).Scan(&counts.Active)
 	if err != nil {
 		return nil, err
 	}
 
-	err = m.DB.QueryRow(stmt_retired).Scan(&counts.Retired)
+	err = db.QueryRow(stmt_retired).Scan(&counts.Retired)
 	if err != nil {
 		return nil, err
 	}
@@ -82,9 +137,10 @@ func (m *LiveEntryModel) Counts() (*data.StatCounts, error) {
 }
 
 func (m *LiveEntryModel) ClusterStats() ([]data.StatCluster, error) {
-	statement := `SELECT
+	statement := fmt.Sprintf(`SELECT
 	unnest(names) AS name, unnest(descriptions) AS description, unnest(css_classes) AS css_class, COUNT(1) AS entry_count
-FROM live.entry_bgc_info GROUP BY name, description, css_class ORDER BY entry_count DESC, name`
+FROM live.entry_bgc_info JOIN live.entries USING (entry_id) WHERE %s
+GROUP BY name, description, css_class ORDER BY entry_count DESC, name`, m.visible())
 
 	var clusters []data.StatCluster
 
@@ -106,7 +162,7 @@ FROM live.entry_bgc_info GROUP BY name, description, css_class ORDER BY entry_co
 }
 
 func (m *LiveEntryModel) PhylumStats() ([]data.TaxonStats, error) {
-	statement := `SELECT phylum, COUNT(phylum) AS ct FROM live.entries LEFT JOIN data.taxa USING (tax_id) GROUP BY phylum ORDER BY ct DESC, phylum`
+	statement := fmt.Sprintf(`SELECT phylum, COUNT(phylum) AS ct FROM live.entries LEFT JOIN data.taxa USING (tax_id) WHERE %s GROUP BY phylum ORDER BY ct DESC, phylum`, m.visible())
 	var stats []data.TaxonStats
 
 	rows, err := m.DB.Query(statement)
@@ -126,13 +182,54 @@ func (m *LiveEntryModel) PhylumStats() ([]data.TaxonStats, error) {
 	return stats, nil
 }
 
+// Taxonomy returns the lineages of the latest versions of all active entries.
+// If ids is not nil, only the entries with these ids are included.
+func (m *LiveEntryModel) Taxonomy(ids []string) ([]data.TaxonomyEntry, error) {
+	statement := `SELECT
+	COALESCE(superkingdom, 'Unknown'), COALESCE(kingdom, 'Unknown'), COALESCE(phylum, 'Unknown'), COALESCE(class, 'Unknown'),
+	COALESCE(taxonomic_order, 'Unknown'), COALESCE(family, 'Unknown'), COALESCE(genus, 'Unknown'), COALESCE(species, 'Unknown'),
+	COALESCE(names, '{}')
+	FROM (SELECT DISTINCT ON (accession) entry_id, tax_id, status FROM live.entries ORDER BY accession, version DESC) AS latest
+	JOIN data.taxa USING (tax_id)
+	LEFT JOIN live.entry_bgc_info USING (entry_id)
+	WHERE status = 'active' AND ($1::text[] IS NULL OR entry_id = ANY($1))`
+
+	var filter any
+	if ids != nil {
+		filter = pq.Array(ids)
+	}
+
+	rows, err := m.DB.Query(statement, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []data.TaxonomyEntry{}
+	for rows.Next() {
+		var classes []sql.NullString
+		entry := data.TaxonomyEntry{Lineage: make([]string, 8)}
+		if err = rows.Scan(&entry.Lineage[0], &entry.Lineage[1], &entry.Lineage[2], &entry.Lineage[3],
+			&entry.Lineage[4], &entry.Lineage[5], &entry.Lineage[6], &entry.Lineage[7], pq.Array(&classes)); err != nil {
+			return nil, err
+		}
+		entry.Classes = validStrings(classes)
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
 func (m *LiveEntryModel) Repository() ([]data.RepositoryEntry, error) {
-	statement := `SELECT DISTINCT ON (accession)
-	entry_id, quality, completeness, status, compounds, synonyms, descriptions, css_classes, organism_name
+	statement := fmt.Sprintf(`SELECT DISTINCT ON (accession)
+	entry_id, quality, completeness, status, compounds, synonyms, descriptions, css_classes, organism_name, retirement_reason, see_also
 	FROM live.entries
 	LEFT JOIN live.entry_compounds USING (entry_id)
 	LEFT JOIN live.entry_bgc_info USING (entry_id)
-	ORDER BY accession, version DESC`
+	WHERE %s
+	ORDER BY accession, version DESC`, m.visible())
 
 	rows, err := m.DB.Query(statement)
 	if err != nil {
@@ -147,20 +244,26 @@ func parseRepositoryEntriesFromDB(rows *sql.Rows) ([]data.RepositoryEntry, error
 
 	for rows.Next() {
 		var (
-			descriptions     []string
-			css_classes      []string
-			product_names    []string
-			product_synonyms []sql.NullString
-			products         []data.Product
+			descriptions       []string
+			css_classes        []string
+			product_names      []string
+			product_synonyms   []sql.NullString
+			products           []data.Product
+			retirement_reasons []string
+			see_also           []string
 		)
 
 		entry := data.RepositoryEntry{}
 		if err := rows.Scan(&entry.Accession, &entry.Quality, &entry.Completeness, &entry.Status,
 			pq.Array(&product_names), pq.Array(&product_synonyms),
 			pq.Array(&descriptions), pq.Array(&css_classes),
-			&entry.OrganismName); err != nil {
+			&entry.OrganismName, pq.Array(&retirement_reasons), pq.Array(&see_also)); err != nil {
 			return nil, err
 		}
+		if entry.Status == "retired" {
+			entry.RetirementReasons = retirement_reasons
+			entry.SeeAlso = see_also
+		}
 
 		products = make([]data.Product, len(product_names))
 		for i := range product_names {
@@ -178,26 +281,42 @@ func parseRepositoryEntriesFromDB(rows *sql.Rows) ([]data.RepositoryEntry, error
 	return entries, nil
 }
 
-func (m *LiveEntryModel) Get(ids []string) ([]data.RepositoryEntry, error) {
-	statement := `SELECT
-		a.entry_id,
-		a.minimal,
-		completeness AS complete,
-		a.status,
-		array_agg(DISTINCT c.name) AS compounds,
-		array_agg(array_to_json(synonyms)) AS synonyms,
-		array_agg(DISTINCT safe_class || ':' || b.name ORDER BY safe_class || ':' || b.name) AS biosyn_class,
-		t.name
+var sortColumns = map[string]string{
+	"accession":    "accession",
+	"organism":     "organism_name",
+	"completeness": "completeness",
+	"quality":      "quality",
+}
+
+func (m *LiveEntryModel) Get(ids []string, page data.Pagination) ([]data.RepositoryEntry, error) {
+	if page.Sort == "" {
+		page.Sort = "accession"
+	}
+	column, ok := sortColumns[page.Sort]
+	if !ok {
+		return nil, data.ErrInvalidSortOrder
+	}
+	if page.Descending {
+		column += " DESC"
+	}
+
+	var limit sql.NullInt64
+	if page.Paginate > 0 {
+		limit = sql.NullInt64{Int64: int64(page.Paginate), Valid: true}
+	}
+
+	// entry_id as the final sort key keeps the order stable between pages
+	statement := fmt.Sprintf(`SELECT
+	entry_id, quality, completeness, status, compounds, synonyms, descriptions, css_classes, organism_name, retirement_reason, see_also
 	FROM ( SELECT * FROM unnest($1::text[]) AS entry_id) vals
-	JOIN live.entries a USING (entry_id)
-	JOIN mibig.rel_entries_types USING (entry_id)
-	JOIN mibig.bgc_types b USING (bgc_type_id)
-	JOIN mibig.chem_compounds c USING (entry_id)
-	JOIN data.taxa t USING (tax_id)
-	GROUP BY a.entry_id, minimal, complete, t.name
-	ORDER BY a.entry_id`
+	JOIN live.entries USING (entry_id)
+	LEFT JOIN live.entry_compounds USING (entry_id)
+	LEFT JOIN live.entry_bgc_info USING (entry_id)
+	WHERE %s
+	ORDER BY %s, entry_id
+	LIMIT $2 OFFSET $3`, m.visible(), column)
 
-	rows, err := m.DB.Query(statement, pq.Array(ids))
+	rows, err := m.DB.Query(statement, pq.Array(ids), limit, page.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -205,12 +324,121 @@ func (m *LiveEntryModel) Get(ids []string) ([]data.RepositoryEntry, error) {
 	return parseRepositoryEntriesFromDB(rows)
 }
 
+// Protein sequences are the translations of annotated and added genes,
+// nucleotide sequences are the sequences of the entry's loci.
+const proteinSequenceStatement = `SELECT
+	entry_id, gene.id, COALESCE(gene.product, ''), gene.translation
+	FROM ( SELECT * FROM unnest($1::text[]) AS entry_id) vals
+	JOIN live.entries USING (entry_id),
+	jsonb_to_recordset(COALESCE(data -> 'genes' -> 'annotations', '[]') || COALESCE(data -> 'genes' -> 'to_add', '[]'))
+		AS gene(id text, product text, translation text)
+	WHERE gene.translation IS NOT NULL AND %s
+	ORDER BY entry_id, gene.id`
+
+const nucleotideSequenceStatement = `SELECT
+	entry_id, locus.accession, concat(locus.location ->> 'from', '-', locus.location ->> 'to'), locus.sequence
+	FROM ( SELECT * FROM unnest($1::text[]) AS entry_id) vals
+	JOIN live.entries USING (entry_id),
+	jsonb_to_recordset(data -> 'loci') AS locus(accession text, location jsonb, sequence text)
+	WHERE locus.sequence IS NOT NULL AND %s
+	ORDER BY entry_id, locus.accession`
+
+func (m *LiveEntryModel) Sequences(ids []string, protein bool) ([]data.FastaRecord, error) {
+	statement := nucleotideSequenceStatement
+	if protein {
+		statement = proteinSequenceStatement
+	}
+
+	rows, err := m.DB.Query(fmt.Sprintf(statement, m.visible()), pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []data.FastaRecord
+	for rows.Next() {
+		var entry_id, name, description string
+		var record data.FastaRecord
+		if err = rows.Scan(&entry_id, &name, &description, &record.Sequence); err != nil {
+			return nil, err
+		}
+		record.Id = fmt.Sprintf("%s|%s", entry_id, name)
+		record.Description = description
+		records = append(records, record)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// ActiveProteins returns the gene translations of the latest versions of all active entries
+func (m *LiveEntryModel) ActiveProteins() ([]data.FastaRecord, error) {
+	rows, err := m.DB.Query(`SELECT entry_id FROM (
+		SELECT DISTINCT ON (accession) entry_id, status FROM live.entries ORDER BY accession, version DESC
+	) AS latest WHERE status = 'active'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var entry_id string
+		if err = rows.Scan(&entry_id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, entry_id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return m.Sequences(ids, true)
+}
+
+// Profiles returns the comparison profiles of the latest versions of all active entries
+func (m *LiveEntryModel) Profiles() ([]data.EntryProfile, error) {
+	statement := `SELECT entry_id,
+	ARRAY(SELECT DISTINCT c ->> 'class' FROM jsonb_array_elements(COALESCE(data -> 'biosynthesis' -> 'classes', '[]')) AS c WHERE c ->> 'class' IS NOT NULL),
+	ARRAY(SELECT DISTINCT domain FROM live.entry_domains d WHERE d.entry_id = latest.entry_id),
+	COALESCE(superkingdom, ''), COALESCE(kingdom, ''), COALESCE(phylum, ''), COALESCE(class, ''),
+	COALESCE(taxonomic_order, ''), COALESCE(family, ''), COALESCE(genus, ''), COALESCE(species, ''),
+	COALESCE(compounds, '{}')
+	FROM (SELECT DISTINCT ON (accession) entry_id, tax_id, status, data FROM live.entries ORDER BY accession, version DESC) AS latest
+	LEFT JOIN data.taxa USING (tax_id)
+	LEFT JOIN live.entry_compounds USING (entry_id)
+	WHERE status = 'active'
+	ORDER BY entry_id`
+
+	rows, err := m.DB.Query(statement)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := []data.EntryProfile{}
+	for rows.Next() {
+		profile := data.EntryProfile{Lineage: make([]string, 8)}
+		var compounds []sql.NullString
+		if err = rows.Scan(&profile.Accession, pq.Array(&profile.Classes), pq.Array(&profile.Domains),
+			&profile.Lineage[0], &profile.Lineage[1], &profile.Lineage[2], &profile.Lineage[3],
+			&profile.Lineage[4], &profile.Lineage[5], &profile.Lineage[6], &profile.Lineage[7],
+			pq.Array(&compounds)); err != nil {
+			return nil, err
+		}
+		profile.Compounds = validStrings(compounds)
+		profiles = append(profiles, profile)
+	}
+	return profiles, rows.Err()
+}
+
 var categoryDetector = map[string]string{
-	"type":     `SELECT COUNT(bgc_type_id) FROM mibig.bgc_types WHERE term ILIKE $1`,
+	"type":     `SELECT COUNT(bgc_type_id) FROM data.bgc_types WHERE term ILIKE $1`,
 	"acc":      `SELECT COUNT(entry_id) FROM live.entries WHERE entry_id ILIKE $1`,
-	"compound": `SELECT COUNT(entry_id) FROM mibig.chem_compounds WHERE name ILIKE $1`,
-	"genus":    `SELECT COUNT(tax_id) FROM mibig.taxa WHERE genus ILIKE $1`,
-	"species":  `SELECT COUNT(tax_id) FROM mibig.taxa WHERE species ILIKE $1`,
+	"compound": `SELECT COUNT(entry_id) FROM live.compounds WHERE name ILIKE $1`,
+	"genus":    `SELECT COUNT(tax_id) FROM data.taxa WHERE genus ILIKE $1`,
+	"species":  `SELECT COUNT(tax_id) FROM data.taxa WHERE species ILIKE $1`,
 }
 
 func (m *LiveEntryModel) guessCategory(term string) (string, error) {
@@ -218,24 +446,26 @@ func (m *LiveEntryModel) guessCategory(term string) (string, error) {
 	for _, category := range []string{"type", "acc", "compound", "genus", "species"} {
 		statement := categoryDetector[category]
 		var count int
-		if err := m.DB.QueryRow(statement, term).Scan(&count); err != nil {
+		if err := m.DB.QueryRow(statement, likePattern(term)).Scan(&count); err != nil {
 			return "", err
 		}
 		if count > 0 {
 			return category, nil
 		}
 	}
-	return "", data.ErrInvalidCategory
+	// anything else might still be mentioned somewhere in the entry text
+	return "text", nil
 }
 
 var statementByCategory = map[string]string{
-	"type": `SELECT entry_id FROM live.entries e LEFT JOIN mibig.rel_entries_types ret USING (entry_id) WHERE bgc_type_id IN (
+	"type": `SELECT entry_id FROM live.rel_entries_types WHERE bgc_type_id IN (
 	WITH RECURSIVE all_subtypes AS (
-		SELECT bgc_type_id, parent_id FROM mibig.bgc_types WHERE term = $1
+		SELECT bgc_type_id, parent_id FROM data.bgc_types WHERE term ILIKE $1
 	UNION
-		SELECT r.bgc_type_id, r.parent_id FROM mibig.bgc_types r INNER JOIN all_subtypes s ON s.bgc_type_id = r.parent_id)
+		SELECT r.bgc_type_id, r.parent_id FROM data.bgc_types r INNER JOIN all_subtypes s ON s.bgc_type_id = r.parent_id)
 	SELECT bgc_type_id FROM all_subtypes)`,
-	"compound":     `SELECT entry_id FROM mibig.chem_compounds WHERE name ILIKE $1`,
+	"compound": `SELECT entry_id FROM live.compounds WHERE name ILIKE $1
+	UNION SELECT entry_id FROM live.compound_synonyms WHERE synonym ILIKE $1`,
 	"acc":          `SELECT entry_id FROM live.entries WHERE entry_id ILIKE $1`,
 	"superkingdom": `SELECT entry_id FROM live.entries LEFT JOIN data.taxa USING (tax_id) WHERE superkingdom ILIKE $1`,
 	"kingdom":      `SELECT entry_id FROM live.entries LEFT JOIN data.taxa USING (tax_id) WHERE kingdom ILIKE $1`,
@@ -245,84 +475,148 @@ var statementByCategory = map[string]string{
 	"family":       `SELECT entry_id FROM live.entries LEFT JOIN data.taxa USING (tax_id) WHERE family ILIKE $1`,
 	"genus":        `SELECT entry_id FROM live.entries LEFT JOIN data.taxa USING (tax_id) WHERE genus ILIKE $1`,
 	"species":      `SELECT entry_id FROM live.entries LEFT JOIN data.taxa USING (tax_id) WHERE species ILIKE $1`,
-	"minimal":      `SELECT entry_id FROM live.entries WHERE minimal = $1`,
-	"completeness": `SELECT entry_id FROM live.entries LEFT JOIN mibig.loci USING (entry_id) WHERE completeness = $1`,
-	"ncbi":         `SELECT entry_id FROM live.entries LEFT JOIN mibig.loci USING (entry_id) WHERE accession ILIKE $1`,
+	"completeness": `SELECT entry_id FROM live.entries WHERE completeness::text ILIKE $1`,
+	"quality":      `SELECT entry_id FROM live.entries WHERE quality::text ILIKE $1`,
+	"status":       `SELECT entry_id FROM live.entries WHERE status::text ILIKE $1`,
+	"ncbi":         `SELECT entry_id FROM live.loci WHERE accession ILIKE $1`,
+	"text":         `SELECT entry_id FROM live.entries WHERE search_text @@ ` + textQuery("$1"),
+	"hmm":          `SELECT entry_id FROM data.antismash_protoclusters JOIN data.antismash_domain_hits USING (protocluster_id) WHERE lower(name) LIKE lower($1)`,
+	// only antiSMASH profiles taken from Pfam, by their Pfam name or accession
+	"pfam": `SELECT entry_id FROM data.antismash_protoclusters JOIN data.antismash_domain_hits h USING (protocluster_id)
+	JOIN data.pfam_profiles p ON lower(h.name) = lower(p.name) WHERE lower(p.name) LIKE lower($1) OR p.accession ILIKE $1`,
 }
 
-func (m *LiveEntryModel) Search(t queries.QueryTerm) ([]string, error) {
-	var entry_ids []string
-	switch v := t.(type) {
-	case *queries.Expression:
-		if v.Category == "unknown" {
-			cat, err := m.guessCategory(v.Term)
-			if err != nil {
-				return nil, err
-			}
-			v.Category = cat
-		}
-		statement, ok := statementByCategory[v.Category]
-		if !ok {
-			return []string{}, nil
-		}
+// comparisonByCategory holds the statements for numeric comparisons,
+// with the comparison operator left as a format verb
+var comparisonByCategory = map[string]string{
+	"length": `SELECT entry_id FROM live.loci GROUP BY entry_id HAVING SUM(end_coord - start_coord + 1) %s $1`,
+}
 
-		rows, err := m.DB.Query(statement, v.Term)
-		if err != nil {
-			return nil, err
-		}
-		defer rows.Close()
+// textQuery is the tsquery of a full-text term. Searching and ranking both use it,
+// so the ranked and highlighted matches are the ones the search found.
+func textQuery(placeholder string) string {
+	return fmt.Sprintf(`plainto_tsquery('english', %s)`, placeholder)
+}
+
+// likePattern turns a search term with * and ? wildcards into an ILIKE pattern
+func likePattern(term string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term)
+	return strings.NewReplacer("*", "%", "?", "_").Replace(escaped)
+}
 
-		for rows.Next() {
-			var entry_id string
-			rows.Scan(&entry_id)
-			entry_ids = append(entry_ids, entry_id)
+// expressionStatement returns the statement and argument for a single search expression
+func expressionStatement(statements map[string]string, expr *queries.Expression) (string, string, error) {
+	if expr.Comparison != "" {
+		statement, ok := comparisonByCategory[expr.Category]
+		if !ok || !slices.Contains(queries.COMPARISONS, expr.Comparison) {
+			return "", "", data.ErrInvalidCategory
 		}
+		return fmt.Sprintf(statement, expr.Comparison), expr.Term, nil
+	}
 
-		return entry_ids, nil
+	statement, ok := statements[expr.Category]
+	if !ok {
+		return "", "", nil
+	}
+	// full-text terms are parsed by the database, not matched as patterns
+	if expr.Category == "text" {
+		return statement, expr.Term, nil
+	}
+	return statement, likePattern(expr.Term), nil
+}
 
-	case *queries.Operation:
-		var (
-			err   error
-			left  []string
-			right []string
-		)
-		left, err = m.Search(v.Left)
-		if err != nil {
+func (m *LiveEntryModel) Search(t queries.QueryTerm) ([]string, error) {
+	statement, args, err := m.compileSearch(&entrySearch, t)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := m.DB.Query(statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entry_ids := []string{}
+	for rows.Next() {
+		var entry_id string
+		if err = rows.Scan(&entry_id); err != nil {
 			return nil, err
 		}
-		right, err = m.Search(v.Right)
-		if err != nil {
+		entry_ids = append(entry_ids, entry_id)
+	}
+	return entry_ids, rows.Err()
+}
+
+// TextMatches ranks the given entries by how well their text matches any of the terms,
+// best matches first, and returns a page of them with highlighted snippets.
+// Entries without a matching text are ranked 0 and have no snippet.
+func (m *LiveEntryModel) TextMatches(ids []string, terms []string, page data.Pagination) ([]data.TextMatch, error) {
+	var limit sql.NullInt64
+	if page.Paginate > 0 {
+		limit = sql.NullInt64{Int64: int64(page.Paginate), Valid: true}
+	}
+
+	// match any of the terms, each parsed like the search did
+	args := []any{pq.Array(ids), limit, page.Offset}
+	tsqueries := make([]string, 0, len(terms))
+	for _, term := range terms {
+		args = append(args, term)
+		tsqueries = append(tsqueries, textQuery(fmt.Sprintf("$%d", len(args))))
+	}
+	if len(tsqueries) == 0 {
+		tsqueries = append(tsqueries, "NULL::tsquery")
+	}
+
+	statement := fmt.Sprintf(`WITH query AS (SELECT %s AS q),
+	ranked AS (
+		SELECT entry_id, data, search_text, COALESCE(ts_rank_cd(search_text, q), 0) AS rank
+		FROM ( SELECT * FROM unnest($1::text[]) AS entry_id) vals
+		JOIN live.entries USING (entry_id), query
+		ORDER BY rank DESC, entry_id
+		LIMIT $2 OFFSET $3)
+	SELECT entry_id, rank,
+		CASE WHEN search_text @@ q THEN ts_headline('english', data.entry_search_document(data), q, 'MaxFragments=2, MinWords=5, MaxWords=20') ELSE '' END
+	FROM ranked, query
+	ORDER BY rank DESC, entry_id`, strings.Join(tsqueries, " || "))
+
+	rows, err := m.DB.Query(statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := []data.TextMatch{}
+	for rows.Next() {
+		var match data.TextMatch
+		if err = rows.Scan(&match.EntryId, &match.Rank, &match.Snippet); err != nil {
 			return nil, err
 		}
-		switch v.Operation {
-		case queries.AND:
-			return utils.Intersect(left, right), nil
-		case queries.OR:
-			return utils.Union(left, right), nil
-		case queries.EXCEPT:
-			return utils.Difference(left, right), nil
-		default:
-			return nil, fmt.Errorf("invalid operation: %s", v.Op())
-		}
+		matches = append(matches, match)
 	}
-	// Should never get here
-	return entry_ids, nil
+	return matches, rows.Err()
 }
 
 var availableByCategory = map[string]string{
-	"type":         `SELECT DISTINCT(term), description FROM mibig.bgc_types WHERE term ILIKE concat($1::text, '%') OR description ILIKE concat($1::text, '%') ORDER BY term`,
-	"compound":     `SELECT DISTINCT(name), name FROM mibig.chem_compounds WHERE name ILIKE concat($1::text, '%')`,
-	"acc":          `SELECT DISTINCT(entry_id), entry_id FROM live.entries WHERE entry_id ILIKE concat('%', $1::text, '%')`,
-	"superkingdom": `SELECT DISTINCT(superkingdom), superkingdom FROM mibig.taxa WHERE superkingdom ILIKE concat('%', $1::text, '%')`,
-	"kingdom":      `SELECT DISTINCT(kingdom), kingdom FROM mibig.taxa WHERE kingdom ILIKE concat('%', $1::text, '%')`,
-	"phylum":       `SELECT DISTINCT(phylum), phylum FROM mibig.taxa WHERE phylum ILIKE concat('%', $1::text, '%')`,
-	"class":        `SELECT DISTINCT(class), class FROM mibig.taxa WHERE class ILIKE concat('%', $1::text, '%')`,
-	"order":        `SELECT DISTINCT(taxonomic_order), taxonomic_order FROM mibig.taxa WHERE taxonomic_order ILIKE concat('%', $1::text, '%')`,
-	"family":       `SELECT DISTINCT(family), family FROM mibig.taxa WHERE family ILIKE concat('%', $1::text, '%')`,
-	"genus":        `SELECT DISTINCT(genus), genus FROM mibig.taxa WHERE genus ILIKE concat('%', $1::text, '%')`,
-	"species":      `SELECT DISTINCT(species), species FROM mibig.taxa WHERE species ILIKE concat('%', $1::text, '%')`,
-	"completeness": `WITH completeness AS (SELECT unnest(enum_range(NULL::mibig.locus_completeness))::text AS value) SELECT value FROM completeness WHERE value ILIKE concat($1::text, '%')`,
-	"ncbi":         `SELECT DISTINCT(accession), accession FROM mibig.loci WHERE accession ILIKE concat($1::text, '%')`,
+	"type":         `SELECT DISTINCT(term), description FROM data.bgc_types WHERE term ILIKE concat($1::text, '%') OR description ILIKE concat($1::text, '%') ORDER BY term`,
+	"compound":     `SELECT DISTINCT(name), name FROM live.compounds JOIN live.entries USING (entry_id) WHERE name ILIKE concat($1::text, '%') AND status <> 'embargoed'`,
+	"acc":          `SELECT DISTINCT(entry_id), entry_id FROM live.entries WHERE entry_id ILIKE concat('%', $1::text, '%') AND status <> 'embargoed'`,
+	"superkingdom": `SELECT DISTINCT(superkingdom), superkingdom FROM data.taxa WHERE superkingdom ILIKE concat('%', $1::text, '%')`,
+	"kingdom":      `SELECT DISTINCT(kingdom), kingdom FROM data.taxa WHERE kingdom ILIKE concat('%', $1::text, '%')`,
+	"phylum":       `SELECT DISTINCT(phylum), phylum FROM data.taxa WHERE phylum ILIKE concat('%', $1::text, '%')`,
+	"class":        `SELECT DISTINCT(class), class FROM data.taxa WHERE class ILIKE concat('%', $1::text, '%')`,
+	"order":        `SELECT DISTINCT(taxonomic_order), taxonomic_order FROM data.taxa WHERE taxonomic_order ILIKE concat('%', $1::text, '%')`,
+	"family":       `SELECT DISTINCT(family), family FROM data.taxa WHERE family ILIKE concat('%', $1::text, '%')`,
+	"genus":        `SELECT DISTINCT(genus), genus FROM data.taxa WHERE genus ILIKE concat('%', $1::text, '%')`,
+	"species":      `SELECT DISTINCT(species), species FROM data.taxa WHERE species ILIKE concat('%', $1::text, '%')`,
+	"completeness": `WITH completeness AS (SELECT unnest(enum_range(NULL::live.entry_completeness))::text AS value) SELECT value, value FROM completeness WHERE value ILIKE concat($1::text, '%')`,
+	"quality":      `WITH quality AS (SELECT unnest(enum_range(NULL::live.entry_quality))::text AS value) SELECT value, value FROM quality WHERE value ILIKE concat($1::text, '%')`,
+	"status":       `WITH status AS (SELECT unnest(enum_range(NULL::live.entry_status))::text AS value) SELECT value, value FROM status WHERE value ILIKE concat($1::text, '%')`,
+	"ncbi":         `SELECT DISTINCT(loci.accession), loci.accession FROM live.loci JOIN live.entries USING (entry_id) WHERE loci.accession ILIKE concat($1::text, '%') AND status <> 'embargoed'`,
+	"hmm":          `SELECT DISTINCT(name), name FROM data.antismash_domain_hits JOIN data.antismash_protoclusters USING (protocluster_id) JOIN live.entries USING (entry_id) WHERE lower(name) LIKE lower(concat($1::text, '%')) AND status <> 'embargoed'`,
+	"pfam": `SELECT DISTINCT(p.name), concat(p.description, ' (', p.accession, ')') FROM data.pfam_profiles p
+	JOIN data.antismash_domain_hits h ON lower(h.name) = lower(p.name) JOIN data.antismash_protoclusters USING (protocluster_id) JOIN live.entries USING (entry_id)
+	WHERE (lower(p.name) LIKE lower(concat($1::text, '%')) OR p.accession ILIKE concat($1::text, '%')) AND status <> 'embargoed'`,
 }
 
 func (m *LiveEntryModel) Available(category string, term string) ([]data.AvailableTerm, error) {
@@ -332,11 +626,6 @@ func (m *LiveEntryModel) Available(category string, term string) ([]data.Availab
 		ok        bool
 	)
 
-	if category == "minimal" {
-		description := "Minimal MIBiG entry"
-		return fakeBooleanOptions(term, description)
-	}
-
 	if statement, ok = availableByCategory[category]; !ok {
 		return nil, data.ErrInvalidCategory
 	}
@@ -357,37 +646,21 @@ func (m *LiveEntryModel) Available(category string, term string) ([]data.Availab
 	return available, nil
 }
 
-func fakeBooleanOptions(term string, description string) ([]data.AvailableTerm, error) {
-	if strings.HasPrefix("true", term) {
-		return []data.AvailableTerm{{Val: "true", Desc: description}}, nil
-	}
-	if strings.HasPrefix("yes", term) {
-		return []data.AvailableTerm{{Val: "true", Desc: description}}, nil
-	}
-	if strings.HasPrefix("false", term) {
-		return []data.AvailableTerm{{Val: "false", Desc: description}}, nil
-	}
-	if strings.HasPrefix("no", term) {
-		return []data.AvailableTerm{{Val: "false", Desc: description}}, nil
-	}
-	return []data.AvailableTerm{}, nil
-}
-
 func (m *LiveEntryModel) ResultStats(ids []string) (*data.ResultStats, error) {
 	var stats data.ResultStats
 	var err error
 
 	cluster_by_type_search := `SELECT
-	unnest(biosyn_class) as biosyn_class, COUNT(1) AS class_count
+	COALESCE(name, 'unknown'), COUNT(1) AS class_count
 	FROM ( SELECT * FROM unnest($1::text[]) AS entry_id) vals
-	JOIN live.entries a USING (entry_id)
-	GROUP BY biosyn_class`
+	JOIN live.entry_bgc_info USING (entry_id), unnest(names) AS name
+	GROUP BY name`
 
 	cluster_by_phylum_search := `SELECT
-	phylum, COUNT(phylum)
+	COALESCE(phylum, 'unknown'), COUNT(1)
 	FROM ( SELECT * FROM unnest($1::text[]) AS entry_id) vals
 	JOIN live.entries USING (entry_id)
-	JOIN mibig.taxa USING (tax_id)
+	LEFT JOIN data.taxa USING (tax_id)
 	GROUP BY phylum`
 
 	stats.ClustersByType, err = m.labelsAndCounts(cluster_by_type_search, ids)
@@ -426,24 +699,28 @@ func (m *LiveEntryModel) labelsAndCounts(statement string, ids []string) (*data.
 }
 
 func (m *LiveEntryModel) GuessCategories(query *queries.Query) error {
-	return m.recursiveGuessCategories(query.Terms)
+	return m.recursiveGuessCategories(query.Terms, searchModeFor(query.QueryType))
 }
 
-func (m *LiveEntryModel) recursiveGuessCategories(term queries.QueryTerm) error {
+func (m *LiveEntryModel) recursiveGuessCategories(term queries.QueryTerm, mode *searchMode) error {
 	switch v := term.(type) {
 	case *queries.Expression:
 		if v.Category == "unknown" {
-			cat, err := m.guessCategory(v.Term)
+			cat, err := m.guessCategoryInMode(mode, v.Term)
 			if err != nil {
 				return err
 			}
 			v.Category = cat
 		}
 	case *queries.Operation:
-		if err := m.recursiveGuessCategories(v.Left); err != nil {
+		if err := m.recursiveGuessCategories(v.Left, mode); err != nil {
+			return err
+		}
+		if err := m.recursiveGuessCategories(v.Right, mode); err != nil {
 			return err
 		}
-		if err := m.recursiveGuessCategories(v.Right); err != nil {
+	case *queries.Negation:
+		if err := m.recursiveGuessCategories(v.Term, mode); err != nil {
 			return err
 		}
 	}
@@ -488,13 +765,13 @@ func (m *LiveEntryModel) LookupContributors(ids []string) ([]data.Contributor, e
 }
 
 func (m *LiveEntryModel) Latest(accession string) (*data.RepositoryEntry, error) {
-	statement := `SELECT
-	entry_id, quality, completeness, status, compounds, synonyms, descriptions, css_classes, organism_name
+	statement := fmt.Sprintf(`SELECT
+	entry_id, quality, completeness, status, compounds, synonyms, descriptions, css_classes, organism_name, retirement_reason, see_also
 	FROM live.entries
 	LEFT JOIN live.entry_compounds USING (entry_id)
 	LEFT JOIN live.entry_bgc_info USING (entry_id)
-	WHERE accession=$1
-	ORDER BY version DESC`
+	WHERE accession=$1 AND %s
+	ORDER BY version DESC`, m.visible())
 
 	rows, err := m.DB.Query(statement, accession)
 	if err != nil {
@@ -511,12 +788,112 @@ func (m *LiveEntryModel) Latest(accession string) (*data.RepositoryEntry, error)
 	return &entries[0], nil
 }
 
+func (m *LiveEntryModel) Record(entry_id string) (*data.EntryRecord, error) {
+	statement := fmt.Sprintf(`SELECT entry_id, accession, version, updated_at, data FROM live.entries WHERE entry_id = $1 AND %s`, m.visible())
+
+	var record data.EntryRecord
+	err := m.DB.QueryRow(statement, entry_id).Scan(&record.EntryId, &record.Accession, &record.Version, &record.UpdatedAt, &record.Data)
+	if err == sql.ErrNoRows {
+		return nil, data.ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (m *LiveEntryModel) History(accession string) ([]data.EntryVersion, error) {
+	statement := fmt.Sprintf(`SELECT entry_id, version, status, quality, completeness, retirement_reason, see_also, updated_at
+	FROM live.entries WHERE accession = $1 AND %s ORDER BY version`, m.visible())
+
+	rows, err := m.DB.Query(statement, accession)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []data.EntryVersion
+	for rows.Next() {
+		var version data.EntryVersion
+		if err = rows.Scan(&version.EntryId, &version.Version, &version.Status, &version.Quality, &version.Completeness,
+			pq.Array(&version.RetirementReason), pq.Array(&version.SeeAlso), &version.UpdatedAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, data.ErrRecordNotFound
+	}
+	return versions, nil
+}
+
+// IsEmbargoed checks if an entry id, or the latest version of an accession, is embargoed
+func (m *LiveEntryModel) IsEmbargoed(id string) (bool, error) {
+	statement := `SELECT status = 'embargoed' FROM live.entries WHERE entry_id = $1 OR accession = $1 ORDER BY version DESC LIMIT 1`
+
+	var embargoed bool
+	err := m.DB.QueryRow(statement, id).Scan(&embargoed)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return embargoed, nil
+}
+
+// PublishEmbargoed makes all embargoed entries whose embargo has lifted active
+// and returns their entry ids
+func (m *LiveEntryModel) PublishEmbargoed() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := fmt.Sprintf(`UPDATE %s.entries SET
+		status = 'active',
+		embargo_until = NULL,
+		data = jsonb_set(data, '{status}', '"active"') - 'embargo_until',
+		updated_at = NOW()
+	WHERE status = 'embargoed' AND embargo_until <= NOW()
+	RETURNING entry_id`, m.schema())
+
+	rows, err := tx.QueryContext(ctx, statement)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	published := []string{}
+	for rows.Next() {
+		var entry_id string
+		if err = rows.Scan(&entry_id); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return nil, err
+		}
+		published = append(published, entry_id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return published, tx.Commit()
+}
+
 /* type EntryModel interface {
 	Counts() (*data.StatCounts, error)
 	ClusterStats() ([]data.StatCluster, error)
 	GenusStats() ([]data.TaxonStats, error)
 	Repository() ([]data.RepositoryEntry, error)
-	Get(ids []string) ([]data.RepositoryEntry, error)
+	Get(ids []string, page data.Pagination) ([]data.RepositoryEntry, error)
 	Search(t queries.QueryTerm) ([]string, error)
 	Available(category string, term string) ([]data.AvailableTerm, error)
 	ResultStats(ids []string) (*data.ResultStats, error)
@@ -525,6 +902,14 @@ func (m *LiveEntryModel) Latest(accession string) (*data.RepositoryEntry, error)
 } */
 
 type MockEntryModel struct {
+	// entry ids and accessions reported as embargoed
+	Embargoed []string
+	// latest entry versions by accession
+	Latests map[string]data.RepositoryEntry
+	// comparison profiles returned by Profiles
+	EntryProfiles []data.EntryProfile
+	// antiSMASH summaries by accession
+	AntismashSummaries map[string]data.AntismashSummary
 }
 
 func NewMockEntryModel() *MockEntryModel {
@@ -543,11 +928,15 @@ func (m *MockEntryModel) PhylumStats() ([]data.TaxonStats, error) {
 	return nil, data.ErrNotImplemented
 }
 
+func (m *MockEntryModel) Taxonomy(ids []string) ([]data.TaxonomyEntry, error) {
+	return nil, data.ErrNotImplemented
+}
+
 func (m *MockEntryModel) Repository() ([]data.RepositoryEntry, error) {
 	return nil, data.ErrNotImplemented
 }
 
-func (m *MockEntryModel) Get(ids []string) ([]data.RepositoryEntry, error) {
+func (m *MockEntryModel) Get(ids []string, page data.Pagination) ([]data.RepositoryEntry, error) {
 	return nil, data.ErrNotImplemented
 }
 
@@ -555,6 +944,34 @@ func (m *MockEntryModel) Search(t queries.QueryTerm) ([]string, error) {
 	return nil, data.ErrNotImplemented
 }
 
+func (m *MockEntryModel) SearchCds(t queries.QueryTerm) ([]data.CdsHit, error) {
+	return nil, data.ErrNotImplemented
+}
+
+func (m *MockEntryModel) SearchDomains(t queries.QueryTerm) ([]data.DomainHit, error) {
+	return nil, data.ErrNotImplemented
+}
+
+func (m *MockEntryModel) Explain(query *queries.Query) (*data.SearchPlan, error) {
+	return nil, data.ErrNotImplemented
+}
+
+func (m *MockEntryModel) TextMatches(ids []string, terms []string, page data.Pagination) ([]data.TextMatch, error) {
+	return nil, data.ErrNotImplemented
+}
+
+func (m *MockEntryModel) Sequences(ids []string, protein bool) ([]data.FastaRecord, error) {
+	return nil, data.ErrNotImplemented
+}
+
+func (m *MockEntryModel) ActiveProteins() ([]data.FastaRecord, error) {
+	return nil, data.ErrNotImplemented
+}
+
+func (m *MockEntryModel) Profiles() ([]data.EntryProfile, error) {
+	return m.EntryProfiles, nil
+}
+
 func (m *MockEntryModel) Available(category string, term string) ([]data.AvailableTerm, error) {
 	return nil, data.ErrNotImplemented
 }
@@ -579,6 +996,10 @@ func (m *MockEntryModel) Update(entry data.MibigEntry, raw []byte, taxCache *dat
 	return data.ErrNotImplemented
 }
 
+func (m *MockEntryModel) AddBatch(entries []data.RawEntry, taxCache *data.TaxonCache, opts BatchOptions) ([]error, error) {
+	return nil, data.ErrNotImplemented
+}
+
 func (m *MockEntryModel) Refresh() error {
 	return data.ErrNotImplemented
 }
@@ -596,5 +1017,51 @@ func (m *MockEntryModel) LoadTaxonEntry(name string, ncbi_taxid int64, taxCache
 }
 
 func (m *MockEntryModel) Latest(accession string) (*data.RepositoryEntry, error) {
+	if m.Latests == nil {
+		return nil, data.ErrNotImplemented
+	}
+	entry, ok := m.Latests[accession]
+	if !ok {
+		return nil, data.ErrRecordNotFound
+	}
+	return &entry, nil
+}
+
+func (m *MockEntryModel) Record(entry_id string) (*data.EntryRecord, error) {
+	return nil, data.ErrNotImplemented
+}
+
+func (m *MockEntryModel) History(accession string) ([]data.EntryVersion, error) {
 	return nil, data.ErrNotImplemented
 }
+
+func (m *MockEntryModel) IsEmbargoed(id string) (bool, error) {
+	return slices.Contains(m.Embargoed, id), nil
+}
+
+func (m *MockEntryModel) WithEmbargoed() EntryModel {
+	return m
+}
+
+func (m *MockEntryModel) PublishEmbargoed() ([]string, error) {
+	return nil, data.ErrNotImplemented
+}
+
+func (m *MockEntryModel) Retire(accession string, reasons []string, seeAlso []string, retiredBy string) (*data.Retirement, error) {
+	return nil, data.ErrNotImplemented
+}
+
+func (m *MockEntryModel) AddAntismash(accession string, records data.AsRecordReader) (string, error) {
+	return "", data.ErrNotImplemented
+}
+
+func (m *MockEntryModel) Antismash(accession string) (*data.AntismashSummary, error) {
+	if m.AntismashSummaries == nil {
+		return nil, data.ErrNotImplemented
+	}
+	summary, ok := m.AntismashSummaries[accession]
+	if !ok {
+		return nil, data.ErrRecordNotFound
+	}
+	return &summary, nil
+}
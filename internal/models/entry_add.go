@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/lib/pq"
@@ -18,7 +20,7 @@ func (m *LiveEntryModel) Add(entry data.MibigEntry, raw []byte, taxCache *data.T
 		return err
 	}
 
-	err = insertEntry(entry, taxCache, raw, ctx, tx)
+	err = insertEntry(m.schema(), entry, taxCache, raw, ctx, tx)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -34,7 +36,7 @@ func (m *LiveEntryModel) Update(entry data.MibigEntry, raw []byte, taxCache *dat
 		return err
 	}
 
-	err = updateEntry(entry, taxCache, raw, ctx, tx)
+	err = updateEntry(m.schema(), entry, taxCache, raw, ctx, tx)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -43,18 +45,102 @@ func (m *LiveEntryModel) Update(entry data.MibigEntry, raw []byte, taxCache *dat
 	return tx.Commit()
 }
 
-func (m *LiveEntryModel) Refresh() error {
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
-	_, err := m.DB.ExecContext(ctx, `REFRESH MATERIALIZED VIEW live.entry_bgc_info`)
+type BatchOptions struct {
+	FailFast bool
+	DryRun   bool
+}
+
+var ErrBatchAborted = errors.New("batch aborted")
+
+// AddBatch inserts all entries in a single transaction. Each entry gets its own
+// savepoint, so a failing entry doesn't take the rest of the batch with it unless
+// FailFast is set. In DryRun mode the transaction is always rolled back.
+// The returned slice holds the per-entry error, in the same order as the input.
+func (m *LiveEntryModel) AddBatch(entries []data.RawEntry, taxCache *data.TaxonCache, opts BatchOptions) ([]error, error) {
+	ctx := context.Background()
+	tx, err := m.DB.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	_, err = m.DB.ExecContext(ctx, `REFRESH MATERIALIZED VIEW live.entry_compounds`)
-	return err
+
+	if err = createBatchTaxa(entries, taxCache, ctx, tx); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	errs := make([]error, len(entries))
+
+	for i, entry := range entries {
+		_, err = tx.ExecContext(ctx, `SAVEPOINT batch_entry`)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		errs[i] = insertEntry(m.schema(), entry.Entry, taxCache, entry.Raw, ctx, tx)
+		if errs[i] != nil {
+			if opts.FailFast {
+				tx.Rollback()
+				return errs, fmt.Errorf("%w: %s", ErrBatchAborted, errs[i])
+			}
+			_, err = tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT batch_entry`)
+		} else {
+			_, err = tx.ExecContext(ctx, `RELEASE SAVEPOINT batch_entry`)
+		}
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if opts.DryRun {
+		return errs, tx.Rollback()
+	}
+
+	return errs, tx.Commit()
+}
+
+// createBatchTaxa creates the missing taxa of a batch up front, in the order of their names.
+// Concurrent batches sharing organisms then wait for each other instead of deadlocking.
+// Taxa that fail are left to their entries, so the errors are reported per entry.
+func createBatchTaxa(entries []data.RawEntry, taxCache *data.TaxonCache, ctx context.Context, tx *sql.Tx) error {
+	taxa := make(map[string]int64)
+	for _, entry := range entries {
+		if _, found := taxa[entry.Entry.Taxonomy.Name]; !found {
+			taxa[entry.Entry.Taxonomy.Name] = entry.Entry.Taxonomy.NcbiTaxId
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(taxa)) {
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT batch_taxon`); err != nil {
+			return err
+		}
+		release := `RELEASE SAVEPOINT batch_taxon`
+		if _, _, err := getOrCreateCurrentTaxId(name, taxa[name], taxCache, ctx, tx); err != nil {
+			release = `ROLLBACK TO SAVEPOINT batch_taxon`
+		}
+		if _, err := tx.ExecContext(ctx, release); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *LiveEntryModel) Refresh() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, view := range []string{"entry_bgc_info", "entry_compounds", "entry_genes", "entry_domains"} {
+		_, err := m.DB.ExecContext(ctx, fmt.Sprintf(`REFRESH MATERIALIZED VIEW %s.%s`, m.schema(), view))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (m *LiveEntryModel) List() ([]data.MibigEntry, error) {
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 	entries := []data.MibigEntry{}
 	statement := `SELECT accession, version, status, quality, completeness, tax_id, organism_name, retirement_reason, see_also FROM live.entries`
 	rows, err := m.DB.QueryContext(ctx, statement)
@@ -78,8 +164,9 @@ func (m *LiveEntryModel) List() ([]data.MibigEntry, error) {
 }
 
 func (m *LiveEntryModel) Dump() error {
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
-	_, err := m.DB.ExecContext(ctx, `TRUNCATE live.entries CASCADE`)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := m.DB.ExecContext(ctx, fmt.Sprintf(`TRUNCATE %s.entries CASCADE`, m.schema()))
 	return err
 }
 
@@ -90,29 +177,17 @@ func (m LiveEntryModel) LoadTaxonEntry(name string, ncbi_taxid int64, taxCache *
 		return -1, err
 	}
 
-	tax_id, err := getOrCreateTaxId(name, ncbi_taxid, taxCache, ctx, tx)
+	ncbi_taxid, _, err = getOrCreateCurrentTaxId(name, ncbi_taxid, taxCache, ctx, tx)
 	if err != nil {
-		switch {
-		case errors.Is(err, errTaxidOutdated):
-			for errors.Is(err, errTaxidOutdated) {
-				ncbi_taxid = tax_id
-				tax_id, err = getOrCreateTaxId(name, ncbi_taxid, taxCache, ctx, tx)
-			}
-			if err != nil {
-				tx.Rollback()
-				return -1, err
-			}
-		default:
-			tx.Rollback()
-			return -1, err
-		}
+		tx.Rollback()
+		return -1, err
 	}
 
 	return ncbi_taxid, tx.Commit()
 }
 
-func updateEntry(entry data.MibigEntry, taxCache *data.TaxonCache, raw []byte, ctx context.Context, tx *sql.Tx) error {
-	statement := `UPDATE live.entries SET
+func updateEntry(schema string, entry data.MibigEntry, taxCache *data.TaxonCache, raw []byte, ctx context.Context, tx *sql.Tx) error {
+	statement := fmt.Sprintf(`UPDATE %s.entries SET
 		accession = $2,
 		version = $3,
 		status = $4,
@@ -122,27 +197,71 @@ func updateEntry(entry data.MibigEntry, taxCache *data.TaxonCache, raw []byte, c
 		organism_name = $8,
 		retirement_reason = $9,
 		see_also = $10,
-		data = $11
-	WHERE entry_id = $1`
-
-	return updateOrInsertEntry(statement, entry, taxCache, raw, ctx, tx)
+		data = $11,
+		embargo_until = $12::timestamptz,
+		updated_at = CASE WHEN data IS DISTINCT FROM $11 THEN NOW() ELSE updated_at END
+	WHERE entry_id = $1`, schema)
 
+	err := updateOrInsertEntry(statement, entry, taxCache, raw, ctx, tx)
+	if err != nil {
+		return err
+	}
+	return fillSearchTables(schema, entry, ctx, tx)
 }
 
-func insertEntry(entry data.MibigEntry, taxCache *data.TaxonCache, raw []byte, ctx context.Context, tx *sql.Tx) error {
+func insertEntry(schema string, entry data.MibigEntry, taxCache *data.TaxonCache, raw []byte, ctx context.Context, tx *sql.Tx) error {
 
-	statement := `INSERT INTO live.entries (
-		entry_id, accession, version, status, quality, completeness, tax_id, organism_name, retirement_reason, see_also, data
-	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	statement := fmt.Sprintf(`INSERT INTO %s.entries (
+		entry_id, accession, version, status, quality, completeness, tax_id, organism_name, retirement_reason, see_also, data, embargo_until
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12::timestamptz)`, schema)
 
-	return updateOrInsertEntry(statement, entry, taxCache, raw, ctx, tx)
+	err := updateOrInsertEntry(statement, entry, taxCache, raw, ctx, tx)
+	if err != nil {
+		return err
+	}
+	return fillSearchTables(schema, entry, ctx, tx)
+}
 
+// searchTableStatements derive the normalized search tables of an entry from its stored data.
+// Keep this in sync with the backfill in the migration creating the tables.
+var searchTableStatements = []string{
+	`DELETE FROM %[1]s.compounds WHERE entry_id = $1`,
+	`DELETE FROM %[1]s.loci WHERE entry_id = $1`,
+	`DELETE FROM %[1]s.rel_entries_types WHERE entry_id = $1`,
+	`INSERT INTO %[1]s.compounds (entry_id, position, name)
+	SELECT entry_id, c.position, c.compound ->> 'name'
+	FROM %[1]s.entries, jsonb_array_elements(COALESCE(data -> 'compounds', '[]'::jsonb)) WITH ORDINALITY AS c(compound, position)
+	WHERE entry_id = $1 AND c.compound ->> 'name' IS NOT NULL`,
+	`INSERT INTO %[1]s.compound_synonyms (entry_id, position, synonym)
+	SELECT entry_id, c.position, s.synonym
+	FROM %[1]s.entries, jsonb_array_elements(COALESCE(data -> 'compounds', '[]'::jsonb)) WITH ORDINALITY AS c(compound, position),
+		jsonb_array_elements_text(COALESCE(c.compound -> 'synonyms', '[]'::jsonb)) AS s(synonym)
+	WHERE entry_id = $1 AND c.compound ->> 'name' IS NOT NULL`,
+	`INSERT INTO %[1]s.loci (entry_id, position, accession, start_coord, end_coord)
+	SELECT entry_id, l.position, l.locus ->> 'accession', (l.locus -> 'location' ->> 'from')::bigint, (l.locus -> 'location' ->> 'to')::bigint
+	FROM %[1]s.entries, jsonb_array_elements(COALESCE(data -> 'loci', '[]'::jsonb)) WITH ORDINALITY AS l(locus, position)
+	WHERE entry_id = $1 AND l.locus ->> 'accession' IS NOT NULL`,
+	`INSERT INTO %[1]s.rel_entries_types (entry_id, bgc_type_id)
+	SELECT DISTINCT entry_id, bgc_type_id
+	FROM %[1]s.entries, jsonb_to_recordset(COALESCE(data -> 'biosynthesis' -> 'classes', '[]'::jsonb)) AS specs(class text)
+	JOIN data.bgc_types ON LOWER(class) = term
+	WHERE entry_id = $1`,
+	`UPDATE %[1]s.entries SET search_text = data.entry_search_text(data) WHERE entry_id = $1`,
+}
+
+func fillSearchTables(schema string, entry data.MibigEntry, ctx context.Context, tx *sql.Tx) error {
+	entry_id := fmt.Sprintf("%s.%d", entry.Accession, entry.Version)
+	for _, statement := range searchTableStatements {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(statement, schema), entry_id); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func updateOrInsertEntry(statement string, entry data.MibigEntry, taxCache *data.TaxonCache, raw []byte, ctx context.Context, tx *sql.Tx) error {
-	tax_id, err := getOrCreateTaxId(entry.Taxonomy.Name, entry.Taxonomy.NcbiTaxId, taxCache, ctx, tx)
+	_, tax_id, err := getOrCreateCurrentTaxId(entry.Taxonomy.Name, entry.Taxonomy.NcbiTaxId, taxCache, ctx, tx)
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
@@ -158,18 +277,29 @@ func updateOrInsertEntry(statement string, entry data.MibigEntry, taxCache *data
 		pq.Array(entry.RetirementReasons),
 		pq.Array(entry.SeeAlso),
 		raw,
+		sql.NullString{String: entry.EmbargoUntil, Valid: entry.EmbargoUntil != ""},
 	}
 
 	_, err = tx.ExecContext(ctx, statement, args...)
-	if err != nil {
-		tx.Rollback()
-		return err
-	}
-	return nil
+	return err
 }
 
 var errTaxidOutdated = errors.New("taxId outdated, please retry")
 
+// getOrCreateCurrentTaxId follows outdated NCBI taxids to the current one before getting or creating the taxon.
+// Returns the current NCBI taxid and the tax_id of the taxon.
+func getOrCreateCurrentTaxId(name string, ncbi_taxid int64, taxCache *data.TaxonCache, ctx context.Context, tx *sql.Tx) (int64, int64, error) {
+	tax_id, err := getOrCreateTaxId(name, ncbi_taxid, taxCache, ctx, tx)
+	for errors.Is(err, errTaxidOutdated) {
+		ncbi_taxid = tax_id
+		tax_id, err = getOrCreateTaxId(name, ncbi_taxid, taxCache, ctx, tx)
+	}
+	if err != nil {
+		return -1, -1, err
+	}
+	return ncbi_taxid, tax_id, nil
+}
+
 func getOrCreateTaxId(name string, ncbi_taxid int64, taxCache *data.TaxonCache, ctx context.Context, tx *sql.Tx) (int64, error) {
 	var tax_id int64
 
@@ -178,12 +308,11 @@ func getOrCreateTaxId(name string, ncbi_taxid int64, taxCache *data.TaxonCache,
 		name,
 	}
 
-	err := tx.QueryRow(`SELECT tax_id FROM data.taxa WHERE ncbi_taxid = $1 AND name = $2`, args...).Scan(&tax_id)
+	err := tx.QueryRowContext(ctx, `SELECT tax_id FROM data.taxa WHERE ncbi_taxid = $1 AND name = $2`, args...).Scan(&tax_id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			ncbiTaxEntry, err := taxCache.EntryForTaxId(ncbi_taxid)
 			if err != nil {
-				tx.Rollback()
 				return -1, err
 			}
 
@@ -193,7 +322,8 @@ func getOrCreateTaxId(name string, ncbi_taxid int64, taxCache *data.TaxonCache,
 
 			query := `INSERT INTO data.taxa
 				(ncbi_taxid, superkingdom, kingdom, phylum, class, taxonomic_order, family, genus, species, name)
-				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING tax_id`
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+				ON CONFLICT (name) DO NOTHING RETURNING tax_id`
 
 			args := []interface{}{
 				ncbi_taxid,
@@ -208,14 +338,19 @@ func getOrCreateTaxId(name string, ncbi_taxid int64, taxCache *data.TaxonCache,
 				name,
 			}
 
-			err = tx.QueryRow(query, args...).Scan(&tax_id)
+			// another batch may have created the taxon since, which is used instead
+			err = tx.QueryRowContext(ctx, query, args...).Scan(&tax_id)
+			if errors.Is(err, sql.ErrNoRows) {
+				err = tx.QueryRowContext(ctx, `SELECT tax_id FROM data.taxa WHERE ncbi_taxid = $1 AND name = $2`, ncbi_taxid, name).Scan(&tax_id)
+				if errors.Is(err, sql.ErrNoRows) {
+					return -1, fmt.Errorf("taxon %s exists with another NCBI taxid than %d", name, ncbi_taxid)
+				}
+			}
 			if err != nil {
-				tx.Rollback()
 				return -1, err
 			}
 
 		} else {
-			tx.Rollback()
 			return -1, err
 		}
 	}
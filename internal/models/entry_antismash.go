@@ -0,0 +1,205 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/lib/pq"
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+// AddAntismash stores the protoclusters, their CDSes and domain hits of an antiSMASH result
+// for the latest version of an entry, or the given version, replacing earlier results.
+// The records are stored as they are read, so only one is in memory at a time.
+// The annotations are kept in the data schema, as staged imports replace the live schema.
+func (m *LiveEntryModel) AddAntismash(accession string, records data.AsRecordReader) (string, error) {
+	// parsing happens within the transaction, which takes a while for whole genomes
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+
+	entryId, err := addAntismash(m.schema(), accession, records, ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	return entryId, tx.Commit()
+}
+
+func addAntismash(schema, accession string, records data.AsRecordReader, ctx context.Context, tx *sql.Tx) (string, error) {
+	var entryId string
+	statement := fmt.Sprintf(`SELECT entry_id FROM %s.entries WHERE entry_id = $1 OR accession = $1 ORDER BY version DESC LIMIT 1`, schema)
+	err := tx.QueryRowContext(ctx, statement, accession).Scan(&entryId)
+	if err == sql.ErrNoRows {
+		return "", data.ErrRecordNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM data.antismash_results WHERE entry_id = $1`, entryId); err != nil {
+		return "", err
+	}
+	// the fields preceding the records are read with the first one
+	record, readErr := records.Next()
+	if readErr != nil && readErr != io.EOF {
+		return "", readErr
+	}
+	result := records.Entry()
+	if _, err = tx.ExecContext(ctx, `INSERT INTO data.antismash_results (entry_id, antismash_version, input_file) VALUES ($1, $2, $3)`,
+		entryId, result.Version, result.InputFile); err != nil {
+		return "", err
+	}
+
+	cdsStatement, err := tx.PrepareContext(ctx, `INSERT INTO data.antismash_cdses (protocluster_id, cds_name, position, definition_domains) VALUES ($1, $2, $3, $4)`)
+	if err != nil {
+		return "", err
+	}
+	defer cdsStatement.Close()
+	hitStatement, err := tx.PrepareContext(ctx, `INSERT INTO data.antismash_domain_hits
+		(protocluster_id, cds_name, position, name, evalue, bitscore, seeds, tool) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`)
+	if err != nil {
+		return "", err
+	}
+	defer hitStatement.Close()
+
+	for ; readErr == nil; record, readErr = records.Next() {
+		for i, cbp := range record.Modules.HmmDetection.RuleResults.CdsByProtocluster {
+			pc := cbp.Protocluster
+			qualifiers, err := json.Marshal(pc.Qualifiers)
+			if err != nil {
+				return "", err
+			}
+			product := firstQualifier(pc.Qualifiers, "product")
+			if product == "" {
+				product = pc.Type
+			}
+
+			var protoclusterId int64
+			err = tx.QueryRowContext(ctx, `INSERT INTO data.antismash_protoclusters
+				(entry_id, record_id, position, location, product, category, detection_rule, qualifiers)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING protocluster_id`,
+				entryId, record.Id, i+1, pc.Location, product, firstQualifier(pc.Qualifiers, "category"),
+				firstQualifier(pc.Qualifiers, "detection_rule"), qualifiers).Scan(&protoclusterId)
+			if err != nil {
+				return "", err
+			}
+
+			for j, cds := range cbp.Cdses {
+				definitionDomains, err := json.Marshal(cds.DefinitionDomains)
+				if err != nil {
+					return "", err
+				}
+				if _, err = cdsStatement.ExecContext(ctx, protoclusterId, cds.Name, j+1, definitionDomains); err != nil {
+					return "", err
+				}
+				for k, hit := range cds.Domains {
+					if _, err = hitStatement.ExecContext(ctx, protoclusterId, cds.Name, k+1, hit.Name,
+						hit.EValue, hit.Bitscore, int64(hit.NumSeeds), hit.Tool); err != nil {
+						return "", err
+					}
+				}
+			}
+		}
+	}
+	if readErr != io.EOF {
+		return "", readErr
+	}
+
+	// the version may follow the records
+	if final := records.Entry(); final.Version != result.Version || final.InputFile != result.InputFile {
+		if _, err = tx.ExecContext(ctx, `UPDATE data.antismash_results SET antismash_version = $2, input_file = $3 WHERE entry_id = $1`,
+			entryId, final.Version, final.InputFile); err != nil {
+			return "", err
+		}
+	}
+
+	return entryId, nil
+}
+
+func firstQualifier(qualifiers map[string][]string, name string) string {
+	if values := qualifiers[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Antismash summarises the stored antiSMASH results of an entry version, or of the
+// latest version of an entry that has results
+func (m *LiveEntryModel) Antismash(accession string) (*data.AntismashSummary, error) {
+	statement := fmt.Sprintf(`SELECT entry_id, antismash_version, imported_at
+	FROM data.antismash_results JOIN live.entries USING (entry_id)
+	WHERE (entry_id = $1 OR accession = $1) AND %s
+	ORDER BY version DESC LIMIT 1`, m.visible())
+
+	summary := data.AntismashSummary{Protoclusters: []data.AntismashProtocluster{}}
+	err := m.DB.QueryRow(statement, accession).Scan(&summary.EntryId, &summary.Version, &summary.ImportedAt)
+	if err == sql.ErrNoRows {
+		return nil, data.ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := m.DB.Query(`SELECT protocluster_id, record_id, position, location, product, category, detection_rule
+	FROM data.antismash_protoclusters WHERE entry_id = $1 ORDER BY protocluster_id`, summary.EntryId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	positions := map[int64]int{}
+	for rows.Next() {
+		var (
+			protoclusterId int64
+			pc             data.AntismashProtocluster
+		)
+		if err = rows.Scan(&protoclusterId, &pc.Record, &pc.Number, &pc.Location, &pc.Product, &pc.Category, &pc.DetectionRule); err != nil {
+			return nil, err
+		}
+		pc.Cdses = []data.AntismashCds{}
+		positions[protoclusterId] = len(summary.Protoclusters)
+		summary.Protoclusters = append(summary.Protoclusters, pc)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	cdsRows, err := m.DB.Query(`SELECT c.protocluster_id, c.cds_name, c.definition_domains,
+	ARRAY(SELECT h.name FROM data.antismash_domain_hits h WHERE h.protocluster_id = c.protocluster_id AND h.cds_name = c.cds_name ORDER BY h.position)
+	FROM data.antismash_cdses c JOIN data.antismash_protoclusters p USING (protocluster_id)
+	WHERE p.entry_id = $1 ORDER BY c.protocluster_id, c.position`, summary.EntryId)
+	if err != nil {
+		return nil, err
+	}
+	defer cdsRows.Close()
+
+	for cdsRows.Next() {
+		var (
+			protoclusterId    int64
+			cds               data.AntismashCds
+			definitionDomains []byte
+		)
+		if err = cdsRows.Scan(&protoclusterId, &cds.Name, &definitionDomains, pq.Array(&cds.Domains)); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(definitionDomains, &cds.DefinitionDomains); err != nil {
+			return nil, err
+		}
+		if cds.Domains == nil {
+			cds.Domains = []string{}
+		}
+		pc := &summary.Protoclusters[positions[protoclusterId]]
+		pc.Cdses = append(pc.Cdses, cds)
+	}
+	return &summary, cdsRows.Err()
+}
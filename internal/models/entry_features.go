@@ -0,0 +1,120 @@
+package models
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+	"secondarymetabolites.org/mibig-api/internal/data"
+	"secondarymetabolites.org/mibig-api/internal/queries"
+)
+
+func (m *LiveEntryModel) SearchCds(t queries.QueryTerm) ([]data.CdsHit, error) {
+	gene_ids, err := m.searchFeatures(&cdsSearch, t)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := `SELECT entry_id, locus_tag, name, product, functions
+	FROM live.entry_genes WHERE gene_id = ANY($1)
+	ORDER BY entry_id, locus_tag`
+
+	rows, err := m.DB.Query(statement, pq.Array(gene_ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := []data.CdsHit{}
+	for rows.Next() {
+		var (
+			hit           data.CdsHit
+			name, product sql.NullString
+			functions     []sql.NullString
+		)
+		if err = rows.Scan(&hit.Accession, &hit.LocusTag, &name, &product, pq.Array(&functions)); err != nil {
+			return nil, err
+		}
+		hit.Name = name.String
+		hit.Product = product.String
+		hit.Functions = validStrings(functions)
+		hits = append(hits, hit)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+func (m *LiveEntryModel) SearchDomains(t queries.QueryTerm) ([]data.DomainHit, error) {
+	domain_ids, err := m.searchFeatures(&domainSearch, t)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := `SELECT entry_id, locus_tag, module, domain, substrates
+	FROM live.entry_domains WHERE domain_id = ANY($1)
+	ORDER BY entry_id, locus_tag, module, domain`
+
+	rows, err := m.DB.Query(statement, pq.Array(domain_ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := []data.DomainHit{}
+	for rows.Next() {
+		var (
+			hit               data.DomainHit
+			locus_tag, module sql.NullString
+			substrates        []sql.NullString
+		)
+		if err = rows.Scan(&hit.Accession, &locus_tag, &module, &hit.Domain, pq.Array(&substrates)); err != nil {
+			return nil, err
+		}
+		hit.LocusTag = locus_tag.String
+		hit.Module = module.String
+		hit.Substrates = validStrings(substrates)
+		hits = append(hits, hit)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+func validStrings(values []sql.NullString) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value.Valid {
+			result = append(result, value.String)
+		}
+	}
+	return result
+}
+
+// searchFeatures works like Search, but returns feature ids
+func (m *LiveEntryModel) searchFeatures(mode *searchMode, t queries.QueryTerm) ([]int64, error) {
+	statement, args, err := m.compileSearch(mode, t)
+	if err != nil {
+		return nil, err
+	}
+	return m.featureIds(statement, args...)
+}
+
+func (m *LiveEntryModel) featureIds(statement string, args ...any) ([]int64, error) {
+	rows, err := m.DB.Query(statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feature_ids []int64
+	for rows.Next() {
+		var feature_id int64
+		if err = rows.Scan(&feature_id); err != nil {
+			return nil, err
+		}
+		feature_ids = append(feature_ids, feature_id)
+	}
+	return feature_ids, rows.Err()
+}
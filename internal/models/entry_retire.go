@@ -0,0 +1,106 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+// Retire adds a new, retired version of the latest version of an entry and records
+// who retired it and why. Successors given in seeAlso need to exist.
+// Embargoed entries can't be retired, as the retired version would publish their data.
+func (m *LiveEntryModel) Retire(accession string, reasons []string, seeAlso []string, retiredBy string) (*data.Retirement, error) {
+	if seeAlso == nil {
+		seeAlso = []string{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	retirement, err := retireEntry(m.schema(), accession, reasons, seeAlso, retiredBy, ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return retirement, tx.Commit()
+}
+
+func retireEntry(schema, accession string, reasons, seeAlso []string, retiredBy string, ctx context.Context, tx *sql.Tx) (*data.Retirement, error) {
+	var (
+		current data.MibigEntry
+		status  string
+	)
+	current.Accession = accession
+
+	statement := fmt.Sprintf(`SELECT version, status FROM %s.entries WHERE accession = $1 ORDER BY version DESC LIMIT 1 FOR UPDATE`, schema)
+	err := tx.QueryRowContext(ctx, statement, accession).Scan(&current.Version, &status)
+	if err == sql.ErrNoRows {
+		return nil, data.ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status == "retired" {
+		return nil, data.ErrEntryRetired
+	}
+	if status == "embargoed" {
+		return nil, data.ErrEntryEmbargoed
+	}
+
+	for _, successor := range seeAlso {
+		var found bool
+		statement = fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s.entries WHERE accession = $1 AND accession <> $2)`, schema)
+		if err = tx.QueryRowContext(ctx, statement, successor, accession).Scan(&found); err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %s", data.ErrUnknownSuccessor, successor)
+		}
+	}
+
+	retired := current
+	retired.Version = current.Version + 1
+	retirement := data.Retirement{
+		Accession:      accession,
+		RetiredEntryId: fmt.Sprintf("%s.%d", accession, current.Version),
+		EntryId:        fmt.Sprintf("%s.%d", accession, retired.Version),
+		Reasons:        reasons,
+		SeeAlso:        seeAlso,
+		RetiredBy:      retiredBy,
+	}
+
+	// the new version keeps everything but the status and retirement info of the old one
+	statement = fmt.Sprintf(`INSERT INTO %[1]s.entries (
+		entry_id, accession, version, status, quality, completeness, tax_id, organism_name, retirement_reason, see_also, data
+	) SELECT $2, accession, $3, 'retired', quality, completeness, tax_id, organism_name, $4, $5,
+		(data - 'embargo_until') || jsonb_build_object('version', $3::int, 'status', 'retired', 'retirement_reasons', to_jsonb($4::text[]), 'see_also', to_jsonb($5::text[]))
+	FROM %[1]s.entries WHERE entry_id = $1`, schema)
+	_, err = tx.ExecContext(ctx, statement, retirement.RetiredEntryId, retirement.EntryId, retired.Version, pq.Array(reasons), pq.Array(seeAlso))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = fillSearchTables(schema, retired, ctx, tx); err != nil {
+		return nil, err
+	}
+
+	statement = `INSERT INTO data.retirements (accession, retired_entry_id, entry_id, reasons, see_also, retired_by)
+	VALUES ($1, $2, $3, $4, $5, $6) RETURNING retired_at`
+	err = tx.QueryRowContext(ctx, statement, accession, retirement.RetiredEntryId, retirement.EntryId,
+		pq.Array(reasons), pq.Array(seeAlso), retiredBy).Scan(&retirement.RetiredAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &retirement, nil
+}
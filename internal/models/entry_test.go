@@ -1,16 +1,22 @@
 package models
 
 import (
+	"bytes"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"sync"
 	"testing"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/google/go-cmp/cmp"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
+	"secondarymetabolites.org/mibig-api/internal/antismash"
 	"secondarymetabolites.org/mibig-api/internal/data"
 	"secondarymetabolites.org/mibig-api/internal/queries"
 )
@@ -33,7 +39,7 @@ func newEntryTestDB(t *testing.T) *EntryModelTest {
 		t.Fatal(err)
 	}
 	migration, err := migrate.NewWithDatabaseInstance(
-		"file://../../../migrations",
+		"file://../../migrations",
 		"postgres", driver)
 	if err != nil {
 		t.Fatal(err)
@@ -78,7 +84,11 @@ func TestEntryModel(t *testing.T) {
 	t.Run("Repository", mt.EntryModelRepository)
 	t.Run("Get", mt.EntryModelGet)
 	t.Run("Search", mt.EntryModelSearch)
+	t.Run("TextMatches", mt.EntryModelTextMatches)
 	t.Run("Available", mt.EntryModelAvailable)
+	t.Run("Antismash", mt.EntryModelAntismash)
+	t.Run("AddBatchSharedTaxon", mt.EntryModelAddBatchSharedTaxon)
+	t.Run("RetireEmbargoed", mt.EntryModelRetireEmbargoed)
 
 }
 
@@ -95,9 +105,9 @@ func (mt *EntryModelTest) EntryModelCounts(t *testing.T) {
 
 func (mt *EntryModelTest) EntryModelClusterStats(t *testing.T) {
 	expected := []data.StatCluster{
-		{Type: "ripp", Description: "Ribosomally synthesized and post-translationally modified peptide", Count: 1, Class: "ripp"},
-		{Type: "pks", Description: "Polyketide", Count: 1, Class: "pks"},
-		{Type: "nrps", Description: "Nonribosomal peptide", Count: 1, Class: "nrps"},
+		{Type: "Lanthipeptide", Description: "Lanthipeptide", Count: 1, Class: "ripp"},
+		{Type: "NRP", Description: "Nonribosomal peptide", Count: 1, Class: "nrps"},
+		{Type: "Polyketide", Description: "Polyketide", Count: 1, Class: "pks"},
 	}
 
 	stats, err := mt.m.ClusterStats()
@@ -112,9 +122,9 @@ func (mt *EntryModelTest) EntryModelClusterStats(t *testing.T) {
 
 func (mt *EntryModelTest) EntryModelRepository(t *testing.T) {
 	expected := []data.RepositoryEntry{
-		{Accession: "BGC0000535", Complete: "complete", Minimal: false, Products: []data.Product{{Name: "nisin A", Synonyms: []string{"nisin"}}}, ProductTags: []data.ProductTag{{Name: "Lanthipeptide", Class: "ripp"}}, OrganismName: "Lactococcus lactis subsp. lactis"},
-		{Accession: "BGC0001070", Complete: "complete", Minimal: false, Products: []data.Product{{Name: "kirromycin", Synonyms: []string{"mocimycin", "delvomycin"}}}, ProductTags: []data.ProductTag{
-			{Name: "NRP", Class: "nrps"}, {Name: "Modular type I polyketide", Class: "pks"}, {Name: "Trans-AT type I polyketide", Class: "pks"},
+		{Accession: "BGC0000535.1", Quality: "high", Completeness: "complete", Status: "active", Products: []data.Product{{Name: "nisin A"}}, ProductTags: []data.ProductTag{{Name: "Lanthipeptide", Class: "ripp"}}, OrganismName: "Lactococcus lactis subsp. lactis"},
+		{Accession: "BGC0001070.1", Quality: "medium", Completeness: "complete", Status: "active", Products: []data.Product{{Name: "kirromycin"}}, ProductTags: []data.ProductTag{
+			{Name: "Nonribosomal peptide", Class: "nrps"}, {Name: "Polyketide", Class: "pks"},
 		}, OrganismName: "Streptomyces collinus Tu 365"},
 	}
 
@@ -135,13 +145,13 @@ func (mt *EntryModelTest) EntryModelGet(t *testing.T) {
 		ExpectedResult []data.RepositoryEntry
 		ExpectedError  error
 	}{
-		{Name: "One", Ids: []string{"BGC0000535"}, ExpectedResult: []data.RepositoryEntry{
-			{Accession: "BGC0000535", Complete: "complete", Minimal: false, Products: []data.Product{{Name: "nisin A", Synonyms: []string{"nisin"}}}, ProductTags: []data.ProductTag{{Name: "Lanthipeptide", Class: "ripp"}}, OrganismName: "Lactococcus lactis subsp. lactis"},
+		{Name: "One", Ids: []string{"BGC0000535.1"}, ExpectedResult: []data.RepositoryEntry{
+			{Accession: "BGC0000535.1", Quality: "high", Completeness: "complete", Status: "active", Products: []data.Product{{Name: "nisin A"}}, ProductTags: []data.ProductTag{{Name: "Lanthipeptide", Class: "ripp"}}, OrganismName: "Lactococcus lactis subsp. lactis"},
 		}, ExpectedError: nil},
-		{Name: "Two", Ids: []string{"BGC0000535", "BGC0001070"}, ExpectedResult: []data.RepositoryEntry{
-			{Accession: "BGC0000535", Complete: "complete", Minimal: false, Products: []data.Product{{Name: "nisin A", Synonyms: []string{"nisin"}}}, ProductTags: []data.ProductTag{{Name: "Lanthipeptide", Class: "ripp"}}, OrganismName: "Lactococcus lactis subsp. lactis"},
-			{Accession: "BGC0001070", Complete: "complete", Minimal: false, Products: []data.Product{{Name: "kirromycin", Synonyms: []string{"mocimycin", "delvomycin"}}}, ProductTags: []data.ProductTag{
-				{Name: "NRP", Class: "nrps"}, {Name: "Modular type I polyketide", Class: "pks"}, {Name: "Trans-AT type I polyketide", Class: "pks"},
+		{Name: "Two", Ids: []string{"BGC0000535.1", "BGC0001070.1"}, ExpectedResult: []data.RepositoryEntry{
+			{Accession: "BGC0000535.1", Quality: "high", Completeness: "complete", Status: "active", Products: []data.Product{{Name: "nisin A"}}, ProductTags: []data.ProductTag{{Name: "Lanthipeptide", Class: "ripp"}}, OrganismName: "Lactococcus lactis subsp. lactis"},
+			{Accession: "BGC0001070.1", Quality: "medium", Completeness: "complete", Status: "active", Products: []data.Product{{Name: "kirromycin"}}, ProductTags: []data.ProductTag{
+				{Name: "Nonribosomal peptide", Class: "nrps"}, {Name: "Polyketide", Class: "pks"},
 			}, OrganismName: "Streptomyces collinus Tu 365"},
 		}, ExpectedError: nil},
 	}
@@ -149,7 +159,7 @@ func (mt *EntryModelTest) EntryModelGet(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.Name, func(t *testing.T) {
 
-			repo, err := mt.m.Get(tt.Ids)
+			repo, err := mt.m.Get(tt.Ids, data.Pagination{})
 			if err != tt.ExpectedError {
 				t.Fatalf("Get(%v) unexpected error: want %s, got %s", tt.Ids, tt.ExpectedError, err)
 			}
@@ -168,14 +178,20 @@ func (mt *EntryModelTest) EntryModelSearch(t *testing.T) {
 		ExpectedResult []string
 		ExpectedError  error
 	}{
-		{Name: "RiPP", Query: &queries.Expression{Category: "type", Term: "ripp"}, ExpectedResult: []string{"BGC0000535"}, ExpectedError: nil},
+		{Name: "RiPP", Query: &queries.Expression{Category: "type", Term: "ribosomal"}, ExpectedResult: []string{"BGC0000535.1"}, ExpectedError: nil},
 		{Name: "Operation/OR", Query: &queries.Operation{
 			Operation: queries.OR,
-			Left:      &queries.Expression{Category: "type", Term: "ripp"},
+			Left:      &queries.Expression{Category: "type", Term: "lanthipeptide"},
 			Right:     &queries.Expression{Category: "type", Term: "nrps"},
-		}, ExpectedResult: []string{"BGC0000535", "BGC0001070"}, ExpectedError: nil},
-		{Name: "Guess Category", Query: &queries.Expression{Category: "unknown", Term: "ripp"}, ExpectedResult: []string{"BGC0000535"}, ExpectedError: nil},
-		{Name: "Guess Invalid Category", Query: &queries.Expression{Category: "unknown", Term: "foobarbaz"}, ExpectedResult: nil, ExpectedError: data.ErrInvalidCategory},
+		}, ExpectedResult: []string{"BGC0000535.1", "BGC0001070.1"}, ExpectedError: nil},
+		{Name: "Negation", Query: &queries.Negation{Term: &queries.Expression{Category: "genus", Term: "Streptomyces"}}, ExpectedResult: []string{"BGC0000535.1"}, ExpectedError: nil},
+		{Name: "Compound synonym", Query: &queries.Expression{Category: "compound", Term: "mocimycin"}, ExpectedResult: []string{"BGC0001070.1"}, ExpectedError: nil},
+		{Name: "NCBI", Query: &queries.Expression{Category: "ncbi", Term: "HM219853*"}, ExpectedResult: []string{"BGC0000535.1"}, ExpectedError: nil},
+		{Name: "Completeness", Query: &queries.Expression{Category: "completeness", Term: "complete"}, ExpectedResult: []string{"BGC0000535.1", "BGC0001070.1"}, ExpectedError: nil},
+		{Name: "Length", Query: &queries.Expression{Category: "length", Term: "50000", Comparison: ">"}, ExpectedResult: []string{"BGC0001070.1"}, ExpectedError: nil},
+		{Name: "Guess Category", Query: &queries.Expression{Category: "unknown", Term: "lanthipeptide"}, ExpectedResult: []string{"BGC0000535.1"}, ExpectedError: nil},
+		{Name: "Text", Query: &queries.Expression{Category: "text", Term: "mocimycin"}, ExpectedResult: []string{"BGC0001070.1"}, ExpectedError: nil},
+		{Name: "Guess Text Category", Query: &queries.Expression{Category: "unknown", Term: "foobarbaz"}, ExpectedResult: []string{}, ExpectedError: nil},
 	}
 
 	for _, tt := range tests {
@@ -192,6 +208,39 @@ func (mt *EntryModelTest) EntryModelSearch(t *testing.T) {
 	}
 }
 
+func (mt *EntryModelTest) EntryModelTextMatches(t *testing.T) {
+	ids := []string{"BGC0000535.1", "BGC0001070.1"}
+
+	matches, err := mt.m.TextMatches(ids, []string{"mocimycin", "nisin"}, data.Pagination{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, match := range matches {
+		if match.Rank <= 0 || match.Snippet == "" {
+			t.Errorf("%s: expected a ranked match with a snippet, got %+v", match.EntryId, match)
+		}
+	}
+
+	// "-nisin" is not a negation for the search, so ranking must not treat it as one either
+	term := "kirromycin -nisin"
+	hits, err := mt.m.Search(&queries.Expression{Category: "text", Term: term})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 0 {
+		t.Errorf("expected no hits for %q, got %v", term, hits)
+	}
+	matches, err = mt.m.TextMatches(ids, []string{term}, data.Pagination{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, match := range matches {
+		if match.Rank != 0 || match.Snippet != "" {
+			t.Errorf("%s: expected no match for %q, got %+v", match.EntryId, term, match)
+		}
+	}
+}
+
 func (mt *EntryModelTest) EntryModelAvailable(t *testing.T) {
 	tests := []struct {
 		Name           string
@@ -201,7 +250,10 @@ func (mt *EntryModelTest) EntryModelAvailable(t *testing.T) {
 		ExpectedError  error
 	}{
 		{Name: "type", Category: "type", Term: "r", ExpectedResult: []data.AvailableTerm{
-			{Val: "ripp", Desc: "Ribosomally synthesized and post-translationally modified peptide"},
+			{Val: "ribosomal", Desc: "Ribosomally synthesized peptide"},
+		}, ExpectedError: nil},
+		{Name: "completeness", Category: "completeness", Term: "p", ExpectedResult: []data.AvailableTerm{
+			{Val: "partial", Desc: "partial"},
 		}, ExpectedError: nil},
 		{Name: "invalid", Category: "foo", Term: "bar", ExpectedResult: nil, ExpectedError: data.ErrInvalidCategory},
 	}
@@ -219,3 +271,190 @@ func (mt *EntryModelTest) EntryModelAvailable(t *testing.T) {
 		})
 	}
 }
+
+func (mt *EntryModelTest) EntryModelAntismash(t *testing.T) {
+	raw, err := ioutil.ReadFile("../antismash/testdata/minimal.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a hit of a profile with an underscore, which are common among antiSMASH profiles
+	terpeneHit := []byte(`["Terpene_synth_C", 1e-30, 90.0, 30, "rule-based-clusters"]`)
+	raw = bytes.Replace(raw, terpeneHit, append(terpeneHit, `, ["PKS_KS", 1e-20, 60.0, 20, "rule-based-clusters"]`...), 1)
+	records := func() data.AsRecordReader {
+		return antismash.NewReader(bytes.NewReader(raw))
+	}
+
+	if _, err = mt.m.AddAntismash("BGC0009999", records()); err != data.ErrRecordNotFound {
+		t.Errorf("AddAntismash(BGC0009999) unexpected error: want %v, got %v", data.ErrRecordNotFound, err)
+	}
+
+	// storing twice replaces the earlier results
+	for range 2 {
+		entryId, err := mt.m.AddAntismash("BGC0001070", records())
+		if err != nil {
+			t.Fatalf("AddAntismash(BGC0001070) unexpected error: %s", err)
+		}
+		if entryId != "BGC0001070.1" {
+			t.Errorf("AddAntismash(BGC0001070) stored for %s", entryId)
+		}
+	}
+
+	summary, err := mt.m.Antismash("BGC0001070")
+	if err != nil {
+		t.Fatalf("Antismash(BGC0001070) unexpected error: %s", err)
+	}
+	if summary.EntryId != "BGC0001070.1" || summary.Version != "7.1.0" || len(summary.Protoclusters) != 2 {
+		t.Fatalf("Antismash(BGC0001070) unexpected summary %+v", summary)
+	}
+	expected := []data.AntismashCds{
+		{Name: "SCO3230", Domains: []string{"AMP-binding", "Condensation"}, DefinitionDomains: map[string][]string{"NRPS": {"Condensation", "AMP-binding"}}},
+		{Name: "SCO3231", Domains: []string{"AMP-binding"}, DefinitionDomains: map[string][]string{}},
+	}
+	if diff := cmp.Diff(expected, summary.Protoclusters[0].Cdses); diff != "" {
+		t.Errorf("Antismash(BGC0001070) unexpected CDSes (-want +got):\n%s", diff)
+	}
+
+	if _, err = mt.m.Antismash("BGC0000535"); err != data.ErrRecordNotFound {
+		t.Errorf("Antismash(BGC0000535) unexpected error: want %v, got %v", data.ErrRecordNotFound, err)
+	}
+
+	for _, term := range []string{"amp-binding", "PKS_KS", "terpene_synth_*"} {
+		ids, err := mt.m.Search(&queries.Expression{Category: "hmm", Term: term})
+		if err != nil {
+			t.Fatalf("Search([hmm]%s) unexpected error: %s", term, err)
+		}
+		if !cmp.Equal([]string{"BGC0001070.1"}, ids) {
+			t.Errorf("Search([hmm]%s) unexpected results %v", term, ids)
+		}
+	}
+	// underscores match literally
+	ids, err := mt.m.Search(&queries.Expression{Category: "hmm", Term: "PKSxKS"})
+	if err != nil {
+		t.Fatalf("Search([hmm]PKSxKS) unexpected error: %s", err)
+	}
+	if len(ids) != 0 {
+		t.Errorf("Search([hmm]PKSxKS) unexpected results %v", ids)
+	}
+
+	// [pfam] leaves out profiles not taken from Pfam, like PKS_KS
+	for _, tt := range []struct {
+		Term     string
+		Expected []string
+	}{
+		{Term: "amp-binding", Expected: []string{"BGC0001070.1"}},
+		{Term: "PF00501", Expected: []string{"BGC0001070.1"}},
+		{Term: "Terpene_synth_C", Expected: []string{"BGC0001070.1"}},
+		{Term: "PKS_KS", Expected: []string{}},
+	} {
+		ids, err := mt.m.Search(&queries.Expression{Category: "pfam", Term: tt.Term})
+		if err != nil {
+			t.Fatalf("Search([pfam]%s) unexpected error: %s", tt.Term, err)
+		}
+		if !cmp.Equal(tt.Expected, ids) {
+			t.Errorf("Search([pfam]%s) unexpected results %v", tt.Term, ids)
+		}
+	}
+
+	for _, tt := range []struct {
+		Term     string
+		Expected []data.AvailableTerm
+	}{
+		{Term: "amp", Expected: []data.AvailableTerm{{Val: "AMP-binding", Desc: "AMP-binding enzyme (PF00501)"}}},
+		{Term: "PF0050", Expected: []data.AvailableTerm{{Val: "AMP-binding", Desc: "AMP-binding enzyme (PF00501)"}}},
+		{Term: "PKS", Expected: nil},
+	} {
+		available, err := mt.m.Available("pfam", tt.Term)
+		if err != nil {
+			t.Fatalf("Available(pfam, %s) unexpected error: %s", tt.Term, err)
+		}
+		if !cmp.Equal(tt.Expected, available) {
+			t.Errorf("Available(pfam, %s) unexpected results %v", tt.Term, available)
+		}
+	}
+
+	available, err := mt.m.Available("hmm", "amp")
+	if err != nil {
+		t.Fatalf("Available(hmm, amp) unexpected error: %s", err)
+	}
+	if !cmp.Equal([]data.AvailableTerm{{Val: "AMP-binding", Desc: "AMP-binding"}}, available) {
+		t.Errorf("Available(hmm, amp) unexpected results %v", available)
+	}
+}
+
+func (mt *EntryModelTest) EntryModelAddBatchSharedTaxon(t *testing.T) {
+	cache := &data.TaxonCache{
+		Mappings: map[int64]data.NcbiTaxEntry{
+			1883: {TaxId: 1883, Superkingdom: "Bacteria", Genus: "Streptomyces"},
+			1902: {TaxId: 1902, Superkingdom: "Bacteria", Genus: "Streptomyces", Species: "coelicolor"},
+		},
+	}
+
+	batch := func(accession string, organisms ...data.MibigTaxonomy) []data.RawEntry {
+		entries := []data.RawEntry{}
+		for i, organism := range organisms {
+			entry := data.MibigEntry{Accession: fmt.Sprintf("%s%d", accession, i), Version: 1, Status: "active",
+				Quality: "medium", Completeness: "complete", Taxonomy: organism}
+			raw, err := json.Marshal(entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			entries = append(entries, data.RawEntry{Entry: entry, Raw: raw})
+		}
+		return entries
+	}
+	first := data.MibigTaxonomy{Name: "Streptomyces sp. batch", NcbiTaxId: 1883}
+	second := data.MibigTaxonomy{Name: "Streptomyces coelicolor batch", NcbiTaxId: 1902}
+
+	// both batches create the same new taxa, in a different order of their entries
+	batches := [][]data.RawEntry{batch("BGC000910", first, second), batch("BGC000920", second, first)}
+	results := make([]error, len(batches))
+	var wg sync.WaitGroup
+	for i, entries := range batches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs, err := mt.m.AddBatch(entries, cache, BatchOptions{FailFast: true})
+			if err == nil {
+				err = errors.Join(errs...)
+			}
+			results[i] = err
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range results {
+		if err != nil {
+			t.Errorf("AddBatch(batch %d) unexpected error: %s", i, err)
+		}
+	}
+
+	var taxa int
+	err := mt.m.DB.QueryRow(`SELECT COUNT(*) FROM data.taxa WHERE name = ANY($1)`, pq.Array([]string{first.Name, second.Name})).Scan(&taxa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if taxa != 2 {
+		t.Errorf("expected the taxa to be created once, got %d rows", taxa)
+	}
+}
+
+func (mt *EntryModelTest) EntryModelRetireEmbargoed(t *testing.T) {
+	_, err := mt.m.DB.Exec(`INSERT INTO live.entries (entry_id, accession, version, status, quality, completeness, tax_id, organism_name, data, embargo_until)
+	VALUES ('BGC0009990.1', 'BGC0009990', 1, 'embargoed', 'medium', 'complete', 1, 'Lactococcus lactis subsp. lactis',
+		'{"accession": "BGC0009990", "version": 1, "status": "embargoed", "embargo_until": "2099-01-01"}', '2099-01-01')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = mt.m.Retire("BGC0009990", []string{"duplicate"}, nil, "test"); !errors.Is(err, data.ErrEntryEmbargoed) {
+		t.Errorf("Retire(BGC0009990) unexpected error: want %v, got %v", data.ErrEntryEmbargoed, err)
+	}
+
+	var versions int
+	if err = mt.m.DB.QueryRow(`SELECT COUNT(*) FROM live.entries WHERE accession = 'BGC0009990'`).Scan(&versions); err != nil {
+		t.Fatal(err)
+	}
+	if versions != 1 {
+		t.Errorf("Retire(BGC0009990) added a version to the embargoed entry")
+	}
+}
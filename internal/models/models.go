@@ -3,25 +3,39 @@ package models
 import "database/sql"
 
 type Models struct {
-	Entries EntryModel
-	Roles   RoleModel
-	Users   UserModel
-	Tokens  TokenModel
+	Entries      EntryModel
+	Roles        RoleModel
+	Users        UserModel
+	Tokens       TokenModel
+	Staging      StagingModel
+	Requests     RequestModel
+	Reservations ReservationModel
+	Releases     ReleaseModel
+	Taxa         TaxonModel
 }
 
 func NewModels(db *sql.DB) Models {
 	return Models{
-		Entries: NewEntryModel(db),
-		Roles:   NewRoleModel(db),
-		Users:   NewUserModel(db),
-		Tokens:  NewTokenModel(db),
+		Entries:      NewEntryModel(db),
+		Roles:        NewRoleModel(db),
+		Users:        NewUserModel(db),
+		Tokens:       NewTokenModel(db),
+		Staging:      NewStagingModel(db),
+		Requests:     NewRequestModel(db),
+		Reservations: NewReservationModel(db),
+		Releases:     NewReleaseModel(db),
+		Taxa:         NewTaxonModel(db),
 	}
 }
 
 func NewMockModes(tokenScopes []string) Models {
 	return Models{
-		Entries: NewMockEntryModel(),
-		Roles:   NewMockRoleModel(),
-		Tokens:  NewMockTokenModel(tokenScopes),
+		Entries:      NewMockEntryModel(),
+		Roles:        NewMockRoleModel(),
+		Tokens:       NewMockTokenModel(tokenScopes),
+		Requests:     NewMockRequestModel(),
+		Reservations: NewMockReservationModel(),
+		Releases:     NewMockReleaseModel(),
+		Taxa:         NewMockTaxonModel(),
 	}
 }
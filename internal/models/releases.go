@@ -0,0 +1,298 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+type ReleaseModel interface {
+	Create(name, notes string) (*data.Release, error)
+	List() ([]data.Release, error)
+	Latest() (*data.Release, error)
+	Changelog(from, to string) (*data.Changelog, error)
+}
+
+type LiveReleaseModel struct {
+	DB *sql.DB
+}
+
+func NewReleaseModel(db *sql.DB) *LiveReleaseModel {
+	return &LiveReleaseModel{DB: db}
+}
+
+const releaseColumns = `name, notes, created_at,
+	COUNT(entry_id),
+	COUNT(entry_id) FILTER (WHERE completeness = 'complete'),
+	COUNT(entry_id) FILTER (WHERE completeness = 'partial'),
+	COUNT(entry_id) FILTER (WHERE status = 'pending'),
+	COUNT(entry_id) FILTER (WHERE status = 'active'),
+	COUNT(entry_id) FILTER (WHERE status = 'retired')
+	FROM data.releases LEFT JOIN data.release_entries USING (release_id)`
+
+func scanRelease(row interface{ Scan(...any) error }) (*data.Release, error) {
+	var release data.Release
+	err := row.Scan(&release.Name, &release.Notes, &release.Created, &release.Counts.Total,
+		&release.Counts.Complete, &release.Counts.Partial, &release.Counts.Pending,
+		&release.Counts.Active, &release.Counts.Retired)
+	if err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// Create records a new release, with a snapshot of all public entries currently live
+func (m *LiveReleaseModel) Create(name, notes string) (*data.Release, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var release_id int64
+	err = tx.QueryRowContext(ctx, `INSERT INTO data.releases (name, notes) VALUES ($1, $2) RETURNING release_id`, name, notes).Scan(&release_id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, data.ErrDuplicateRelease
+		}
+		return nil, err
+	}
+
+	statement := `INSERT INTO data.release_entries (release_id, entry_id, accession, version, status, quality, completeness)
+	SELECT $1, entry_id, accession, version, status, quality, completeness FROM live.entries WHERE status <> 'embargoed'`
+	if _, err = tx.ExecContext(ctx, statement, release_id); err != nil {
+		return nil, err
+	}
+
+	release, err := scanRelease(tx.QueryRowContext(ctx, `SELECT `+releaseColumns+` WHERE release_id = $1 GROUP BY release_id`, release_id))
+	if err != nil {
+		return nil, err
+	}
+
+	return release, tx.Commit()
+}
+
+func (m *LiveReleaseModel) List() ([]data.Release, error) {
+	rows, err := m.DB.Query(`SELECT ` + releaseColumns + ` GROUP BY release_id ORDER BY created_at, release_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	releases := []data.Release{}
+	for rows.Next() {
+		release, err := scanRelease(rows)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, *release)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return releases, nil
+}
+
+func (m *LiveReleaseModel) Latest() (*data.Release, error) {
+	release, err := scanRelease(m.DB.QueryRow(`SELECT ` + releaseColumns + ` GROUP BY release_id ORDER BY created_at DESC, release_id DESC LIMIT 1`))
+	if err == sql.ErrNoRows {
+		return nil, data.ErrRecordNotFound
+	}
+	return release, err
+}
+
+func (m *LiveReleaseModel) Changelog(from, to string) (*data.Changelog, error) {
+	old, err := m.snapshot(from)
+	if err != nil {
+		return nil, err
+	}
+	current, err := m.snapshot(to)
+	if err != nil {
+		return nil, err
+	}
+	return changelogBetween(from, to, old, current), nil
+}
+
+func (m *LiveReleaseModel) snapshot(name string) ([]data.ReleaseEntry, error) {
+	var exists bool
+	if err := m.DB.QueryRow(`SELECT EXISTS (SELECT 1 FROM data.releases WHERE name = $1)`, name).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, data.ErrRecordNotFound
+	}
+
+	statement := `SELECT entry_id, accession, version, status, quality, completeness
+	FROM data.release_entries JOIN data.releases USING (release_id) WHERE name = $1`
+	rows, err := m.DB.Query(statement, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []data.ReleaseEntry{}
+	for rows.Next() {
+		var entry data.ReleaseEntry
+		if err = rows.Scan(&entry.EntryId, &entry.Accession, &entry.Version, &entry.Status, &entry.Quality, &entry.Completeness); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// changelogBetween compares the latest versions of all accessions in two releases.
+// Accessions missing from the newer release are reported as retired.
+func changelogBetween(from, to string, old, current []data.ReleaseEntry) *data.Changelog {
+	changelog := data.Changelog{
+		From:                from,
+		To:                  to,
+		Added:               []data.ChangelogEntry{},
+		Updated:             []data.ChangelogEntry{},
+		Retired:             []data.ChangelogEntry{},
+		QualityChanged:      []data.ChangelogEntry{},
+		CompletenessChanged: []data.ChangelogEntry{},
+	}
+
+	before := latestByAccession(old)
+	after := latestByAccession(current)
+
+	accessions := make([]string, 0, len(before)+len(after))
+	for accession := range after {
+		accessions = append(accessions, accession)
+	}
+	for accession := range before {
+		if _, ok := after[accession]; !ok {
+			accessions = append(accessions, accession)
+		}
+	}
+	sort.Strings(accessions)
+
+	for _, accession := range accessions {
+		o, existed := before[accession]
+		n, exists := after[accession]
+		switch {
+		case !existed:
+			changelog.Added = append(changelog.Added, data.ChangelogEntry{Accession: accession, To: n.EntryId})
+			continue
+		case !exists:
+			changelog.Retired = append(changelog.Retired, data.ChangelogEntry{Accession: accession, From: o.EntryId})
+			continue
+		case n.Status == "retired" && o.Status != "retired":
+			changelog.Retired = append(changelog.Retired, data.ChangelogEntry{Accession: accession, From: o.EntryId, To: n.EntryId})
+		case n.Version != o.Version:
+			changelog.Updated = append(changelog.Updated, data.ChangelogEntry{Accession: accession, From: o.EntryId, To: n.EntryId})
+		}
+
+		if n.Quality != o.Quality {
+			changelog.QualityChanged = append(changelog.QualityChanged, data.ChangelogEntry{
+				Accession: accession, From: o.EntryId, To: n.EntryId, OldValue: o.Quality, NewValue: n.Quality})
+		}
+		if n.Completeness != o.Completeness {
+			changelog.CompletenessChanged = append(changelog.CompletenessChanged, data.ChangelogEntry{
+				Accession: accession, From: o.EntryId, To: n.EntryId, OldValue: o.Completeness, NewValue: n.Completeness})
+		}
+	}
+
+	return &changelog
+}
+
+func latestByAccession(entries []data.ReleaseEntry) map[string]data.ReleaseEntry {
+	latest := make(map[string]data.ReleaseEntry, len(entries))
+	for _, entry := range entries {
+		if existing, ok := latest[entry.Accession]; !ok || entry.Version > existing.Version {
+			latest[entry.Accession] = entry
+		}
+	}
+	return latest
+}
+
+type mockRelease struct {
+	data.Release
+	Entries []data.ReleaseEntry
+}
+
+type MockReleaseModel struct {
+	releases []mockRelease
+}
+
+func NewMockReleaseModel() *MockReleaseModel {
+	return &MockReleaseModel{}
+}
+
+// AddSnapshot adds a release with the given entries, for tests
+func (m *MockReleaseModel) AddSnapshot(name string, entries []data.ReleaseEntry) {
+	release := data.Release{Name: name, Created: time.Now().Truncate(time.Second)}
+	for _, entry := range entries {
+		release.Counts.Total++
+		switch entry.Status {
+		case "pending":
+			release.Counts.Pending++
+		case "active":
+			release.Counts.Active++
+		case "retired":
+			release.Counts.Retired++
+		}
+		switch entry.Completeness {
+		case "complete":
+			release.Counts.Complete++
+		case "partial":
+			release.Counts.Partial++
+		}
+	}
+	m.releases = append(m.releases, mockRelease{Release: release, Entries: entries})
+}
+
+func (m *MockReleaseModel) Create(name, notes string) (*data.Release, error) {
+	for _, release := range m.releases {
+		if release.Name == name {
+			return nil, data.ErrDuplicateRelease
+		}
+	}
+	m.AddSnapshot(name, nil)
+	m.releases[len(m.releases)-1].Notes = notes
+	release := m.releases[len(m.releases)-1].Release
+	return &release, nil
+}
+
+func (m *MockReleaseModel) List() ([]data.Release, error) {
+	releases := make([]data.Release, 0, len(m.releases))
+	for _, release := range m.releases {
+		releases = append(releases, release.Release)
+	}
+	return releases, nil
+}
+
+func (m *MockReleaseModel) Latest() (*data.Release, error) {
+	if len(m.releases) == 0 {
+		return nil, data.ErrRecordNotFound
+	}
+	release := m.releases[len(m.releases)-1].Release
+	return &release, nil
+}
+
+func (m *MockReleaseModel) Changelog(from, to string) (*data.Changelog, error) {
+	var old, current *mockRelease
+	for i := range m.releases {
+		if m.releases[i].Name == from {
+			old = &m.releases[i]
+		}
+		if m.releases[i].Name == to {
+			current = &m.releases[i]
+		}
+	}
+	if old == nil || current == nil {
+		return nil, data.ErrRecordNotFound
+	}
+	return changelogBetween(from, to, old.Entries, current.Entries), nil
+}
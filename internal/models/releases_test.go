@@ -0,0 +1,61 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+func TestChangelogBetween(t *testing.T) {
+	old := []data.ReleaseEntry{
+		{EntryId: "BGC0000001.1", Accession: "BGC0000001", Version: 1, Status: "active", Quality: "medium", Completeness: "complete"},
+		{EntryId: "BGC0000002.1", Accession: "BGC0000002", Version: 1, Status: "active", Quality: "high", Completeness: "partial"},
+		{EntryId: "BGC0000003.1", Accession: "BGC0000003", Version: 1, Status: "active", Quality: "low", Completeness: "partial"},
+		{EntryId: "BGC0000004.1", Accession: "BGC0000004", Version: 1, Status: "active", Quality: "low", Completeness: "unknown"},
+	}
+	current := []data.ReleaseEntry{
+		{EntryId: "BGC0000001.1", Accession: "BGC0000001", Version: 1, Status: "active", Quality: "medium", Completeness: "complete"},
+		{EntryId: "BGC0000002.1", Accession: "BGC0000002", Version: 1, Status: "active", Quality: "high", Completeness: "partial"},
+		{EntryId: "BGC0000002.2", Accession: "BGC0000002", Version: 2, Status: "active", Quality: "high", Completeness: "complete"},
+		{EntryId: "BGC0000003.1", Accession: "BGC0000003", Version: 1, Status: "active", Quality: "low", Completeness: "partial"},
+		{EntryId: "BGC0000003.2", Accession: "BGC0000003", Version: 2, Status: "retired", Quality: "low", Completeness: "partial"},
+		{EntryId: "BGC0000005.1", Accession: "BGC0000005", Version: 1, Status: "active", Quality: "high", Completeness: "complete"},
+	}
+
+	expected := &data.Changelog{
+		From:    "3.1",
+		To:      "4.0",
+		Added:   []data.ChangelogEntry{{Accession: "BGC0000005", To: "BGC0000005.1"}},
+		Updated: []data.ChangelogEntry{{Accession: "BGC0000002", From: "BGC0000002.1", To: "BGC0000002.2"}},
+		Retired: []data.ChangelogEntry{
+			{Accession: "BGC0000003", From: "BGC0000003.1", To: "BGC0000003.2"},
+			{Accession: "BGC0000004", From: "BGC0000004.1"},
+		},
+		QualityChanged: []data.ChangelogEntry{},
+		CompletenessChanged: []data.ChangelogEntry{
+			{Accession: "BGC0000002", From: "BGC0000002.1", To: "BGC0000002.2", OldValue: "partial", NewValue: "complete"},
+		},
+	}
+
+	changelog := changelogBetween("3.1", "4.0", old, current)
+	if !cmp.Equal(expected, changelog) {
+		t.Errorf("Unexpected changelog:\n%s", cmp.Diff(expected, changelog))
+	}
+
+	markdown := changelog.Markdown()
+	for _, line := range []string{
+		"# Changes from MIBiG 3.1 to 4.0",
+		"## Added (1)",
+		"- BGC0000002.1 → BGC0000002.2",
+		"- BGC0000004.1 (removed)",
+		"## Quality changed (0)\n\nNone.",
+		"- BGC0000002: partial → complete",
+	} {
+		if !strings.Contains(markdown, line) {
+			t.Errorf("Markdown is missing %q:\n%s", line, markdown)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+	"secondarymetabolites.org/mibig-api/internal/utils"
+)
+
+type RequestModel interface {
+	Insert(request *data.StoredAccessionRequest) error
+	Get(ticket string) (*data.StoredAccessionRequest, error)
+	List(status string) ([]data.StoredAccessionRequest, error)
+	Assign(ticket string, reviewer *data.User) error
+	Resolve(ticket, status, accession, comment string) error
+}
+
+type LiveRequestModel struct {
+	DB *sql.DB
+}
+
+func NewRequestModel(db *sql.DB) *LiveRequestModel {
+	return &LiveRequestModel{DB: db}
+}
+
+func newTicket() (string, error) {
+	uid, err := utils.GenerateUid(5)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("REQ-%s", uid), nil
+}
+
+// Insert stores a new request, filling in ticket, status and submission time
+func (m *LiveRequestModel) Insert(request *data.StoredAccessionRequest) error {
+	ticket, err := newTicket()
+	if err != nil {
+		return err
+	}
+
+	loci, err := json.Marshal(request.Loci)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	statement := `INSERT INTO submissions.accession_requests (ticket, name, email, compounds, loci)
+	VALUES ($1, $2, $3, $4, $5) RETURNING status, submitted_at, updated_at`
+	err = m.DB.QueryRowContext(ctx, statement, ticket, request.Name, request.Email, pq.Array(request.Compounds), loci).Scan(
+		&request.Status, &request.Submitted, &request.Updated)
+	if err != nil {
+		return err
+	}
+	request.Ticket = ticket
+	return nil
+}
+
+const requestColumns = `ticket, name, r.email, compounds, loci, status, COALESCE(u.email, ''), COALESCE(accession, ''), COALESCE(comment, ''), submitted_at, updated_at
+	FROM submissions.accession_requests AS r
+	LEFT JOIN auth.users AS u ON reviewer_id = user_id`
+
+func scanRequest(row interface{ Scan(...any) error }) (*data.StoredAccessionRequest, error) {
+	var (
+		request data.StoredAccessionRequest
+		loci    []byte
+	)
+	err := row.Scan(&request.Ticket, &request.Name, &request.Email, pq.Array(&request.Compounds), &loci, &request.Status,
+		&request.Reviewer, &request.Accession, &request.Comment, &request.Submitted, &request.Updated)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(loci, &request.Loci); err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (m *LiveRequestModel) Get(ticket string) (*data.StoredAccessionRequest, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	row := m.DB.QueryRowContext(ctx, `SELECT `+requestColumns+` WHERE ticket = $1`, ticket)
+	request, err := scanRequest(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, data.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return request, nil
+}
+
+// List returns all requests with the given status, or all requests if status is empty
+func (m *LiveRequestModel) List(status string) ([]data.StoredAccessionRequest, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	statement := `SELECT ` + requestColumns + ` WHERE ($1 = '' OR status::text = $1) ORDER BY submitted_at, request_id`
+	rows, err := m.DB.QueryContext(ctx, statement, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []data.StoredAccessionRequest{}
+	for rows.Next() {
+		request, err := scanRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, *request)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// Assign puts an open request under review by the given reviewer
+func (m *LiveRequestModel) Assign(ticket string, reviewer *data.User) error {
+	statement := `UPDATE submissions.accession_requests
+	SET status = 'under_review', reviewer_id = $2, updated_at = NOW()
+	WHERE ticket = $1 AND status IN ('received', 'under_review')`
+	return m.updateOpen(ticket, statement, reviewer.Id)
+}
+
+// Resolve closes an open request, either assigning an accession or rejecting it
+func (m *LiveRequestModel) Resolve(ticket, status, accession, comment string) error {
+	statement := `UPDATE submissions.accession_requests
+	SET status = $2, accession = NULLIF($3, ''), comment = NULLIF($4, ''), updated_at = NOW()
+	WHERE ticket = $1 AND status IN ('received', 'under_review')`
+	return m.updateOpen(ticket, statement, status, accession, comment)
+}
+
+func (m *LiveRequestModel) updateOpen(ticket, statement string, args ...any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, statement, append([]any{ticket}, args...)...)
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated > 0 {
+		return nil
+	}
+
+	// Nothing changed, so either the request doesn't exist or it's closed already
+	if _, err = m.Get(ticket); err != nil {
+		return err
+	}
+	return data.ErrRequestClosed
+}
+
+type MockRequestModel struct {
+	Requests []*data.StoredAccessionRequest
+}
+
+func NewMockRequestModel() *MockRequestModel {
+	return &MockRequestModel{}
+}
+
+func (m *MockRequestModel) Insert(request *data.StoredAccessionRequest) error {
+	ticket, err := newTicket()
+	if err != nil {
+		return err
+	}
+	request.Ticket = ticket
+	request.Status = data.RequestReceived
+	request.Submitted = time.Now().Truncate(time.Second)
+	request.Updated = request.Submitted
+
+	stored := *request
+	m.Requests = append(m.Requests, &stored)
+	return nil
+}
+
+func (m *MockRequestModel) Get(ticket string) (*data.StoredAccessionRequest, error) {
+	for _, request := range m.Requests {
+		if request.Ticket == ticket {
+			found := *request
+			return &found, nil
+		}
+	}
+	return nil, data.ErrRecordNotFound
+}
+
+func (m *MockRequestModel) List(status string) ([]data.StoredAccessionRequest, error) {
+	requests := []data.StoredAccessionRequest{}
+	for _, request := range m.Requests {
+		if status == "" || request.Status == status {
+			requests = append(requests, *request)
+		}
+	}
+	return requests, nil
+}
+
+func (m *MockRequestModel) Assign(ticket string, reviewer *data.User) error {
+	return m.updateOpen(ticket, func(request *data.StoredAccessionRequest) {
+		request.Status = data.RequestUnderReview
+		request.Reviewer = reviewer.Email
+	})
+}
+
+func (m *MockRequestModel) Resolve(ticket, status, accession, comment string) error {
+	return m.updateOpen(ticket, func(request *data.StoredAccessionRequest) {
+		request.Status = status
+		request.Accession = accession
+		request.Comment = comment
+	})
+}
+
+func (m *MockRequestModel) updateOpen(ticket string, update func(*data.StoredAccessionRequest)) error {
+	for _, request := range m.Requests {
+		if request.Ticket != ticket {
+			continue
+		}
+		if !request.IsOpen() {
+			return data.ErrRequestClosed
+		}
+		update(request)
+		request.Updated = time.Now().Truncate(time.Second)
+		return nil
+	}
+	return data.ErrRecordNotFound
+}
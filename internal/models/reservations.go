@@ -0,0 +1,251 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+// DefaultReservationTTL is how long reserved accessions stay valid unless specified otherwise
+const DefaultReservationTTL = 90 * 24 * time.Hour
+
+type ReservationModel interface {
+	Reserve(ticket string, reservedBy *data.User, ttl time.Duration) (*data.Reservation, error)
+	Release(accession string) error
+	List(status string) ([]data.Reservation, error)
+}
+
+type LiveReservationModel struct {
+	DB *sql.DB
+}
+
+func NewReservationModel(db *sql.DB) *LiveReservationModel {
+	return &LiveReservationModel{DB: db}
+}
+
+const reservationColumns = `accession, status, COALESCE(ticket, '') AS ticket, COALESCE(email, '') AS email, reserved_at, expires_at, released_at
+	FROM (SELECT r.*, ar.ticket, u.email,
+		CASE
+			WHEN released_at IS NOT NULL THEN 'released'
+			WHEN EXISTS (SELECT 1 FROM live.entries AS e WHERE e.accession = r.accession) THEN 'used'
+			WHEN expires_at < NOW() THEN 'expired'
+			ELSE 'active'
+		END AS status
+		FROM submissions.reservations AS r
+		LEFT JOIN submissions.accession_requests AS ar USING (request_id)
+		LEFT JOIN auth.users AS u ON reserved_by = u.user_id) AS reservations`
+
+// Reserve allocates the next free accession. Accessions are never handed out twice,
+// even if the earlier reservation expired or was released, so stale references can't
+// end up pointing at a different cluster.
+// If ticket is set, the accession request is resolved with the new accession.
+// A ttl of zero means the reservation does not expire.
+//
+// Reservations are only tracked here, no placeholder with the 'reserved' entry status is
+// written to live.entries: an entry row needs data, taxonomy and quality a reservation
+// doesn't have, every public query on live.entries would have to skip the placeholders,
+// and promoting a staged release replaces live.entries wholesale. A reservation counts
+// as used as soon as an entry with its accession is imported.
+func (m *LiveReservationModel) Reserve(ticket string, reservedBy *data.User, ttl time.Duration) (*data.Reservation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Serialise allocations, reads of existing reservations are still fine
+	if _, err = tx.ExecContext(ctx, `LOCK TABLE submissions.reservations IN EXCLUSIVE MODE`); err != nil {
+		return nil, err
+	}
+
+	var request_id sql.NullInt64
+	if ticket != "" {
+		var status string
+		err = tx.QueryRowContext(ctx, `SELECT request_id, status FROM submissions.accession_requests WHERE ticket = $1 FOR UPDATE`,
+			ticket).Scan(&request_id, &status)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, data.ErrRecordNotFound
+			}
+			return nil, err
+		}
+		if status != data.RequestReceived && status != data.RequestUnderReview {
+			return nil, data.ErrRequestClosed
+		}
+	}
+
+	var highest int64
+	statement := `SELECT COALESCE(GREATEST(
+		(SELECT MAX(substring(accession FROM '^BGC(\d{7})$')::bigint) FROM live.entries),
+		(SELECT MAX(substring(accession FROM '^BGC(\d{7})$')::bigint) FROM submissions.reservations)
+	), 0)`
+	if err = tx.QueryRowContext(ctx, statement).Scan(&highest); err != nil {
+		return nil, err
+	}
+
+	reservation := data.Reservation{
+		Accession: fmt.Sprintf("BGC%07d", highest+1),
+		Status:    data.ReservationActive,
+		Ticket:    ticket,
+	}
+
+	var reserved_by sql.NullInt64
+	if reservedBy != nil {
+		reserved_by = sql.NullInt64{Int64: reservedBy.Id, Valid: true}
+		reservation.ReservedBy = reservedBy.Email
+	}
+	var expires_at sql.NullTime
+	if ttl > 0 {
+		expires_at = sql.NullTime{Time: time.Now().Add(ttl), Valid: true}
+	}
+
+	statement = `INSERT INTO submissions.reservations (accession, request_id, reserved_by, expires_at)
+	VALUES ($1, $2, $3, $4) RETURNING reserved_at, expires_at`
+	err = tx.QueryRowContext(ctx, statement, reservation.Accession, request_id, reserved_by, expires_at).Scan(
+		&reservation.Reserved, &reservation.Expires)
+	if err != nil {
+		return nil, err
+	}
+
+	if request_id.Valid {
+		statement = `UPDATE submissions.accession_requests
+		SET status = 'accession_assigned', accession = $2, updated_at = NOW()
+		WHERE request_id = $1`
+		if _, err = tx.ExecContext(ctx, statement, request_id, reservation.Accession); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// Release gives up an active reservation. A request resolved with the accession goes
+// back to being under review.
+func (m *LiveReservationModel) Release(accession string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRowContext(ctx, `SELECT status FROM (SELECT `+reservationColumns+`) AS r WHERE accession = $1`, accession).Scan(&status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return data.ErrRecordNotFound
+		}
+		return err
+	}
+	if status != data.ReservationActive {
+		return data.ErrReservationClosed
+	}
+
+	statements := []string{
+		`UPDATE submissions.reservations SET released_at = NOW() WHERE accession = $1`,
+		`UPDATE submissions.accession_requests
+		SET status = 'under_review', accession = NULL, updated_at = NOW()
+		WHERE accession = $1 AND status = 'accession_assigned'
+		AND request_id = (SELECT request_id FROM submissions.reservations WHERE accession = $1)`,
+	}
+	for _, statement := range statements {
+		if _, err = tx.ExecContext(ctx, statement, accession); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// List returns all reservations with the given status, or all reservations if status is empty
+func (m *LiveReservationModel) List(status string) ([]data.Reservation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	statement := `SELECT ` + reservationColumns + ` WHERE ($1 = '' OR status = $1) ORDER BY accession`
+	rows, err := m.DB.QueryContext(ctx, statement, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reservations := []data.Reservation{}
+	for rows.Next() {
+		var reservation data.Reservation
+		err = rows.Scan(&reservation.Accession, &reservation.Status, &reservation.Ticket, &reservation.ReservedBy,
+			&reservation.Reserved, &reservation.Expires, &reservation.Released)
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, reservation)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+type MockReservationModel struct {
+	Reservations []*data.Reservation
+}
+
+func NewMockReservationModel() *MockReservationModel {
+	return &MockReservationModel{}
+}
+
+func (m *MockReservationModel) Reserve(ticket string, reservedBy *data.User, ttl time.Duration) (*data.Reservation, error) {
+	reservation := data.Reservation{
+		Accession: fmt.Sprintf("BGC%07d", len(m.Reservations)+1),
+		Status:    data.ReservationActive,
+		Ticket:    ticket,
+		Reserved:  time.Now().Truncate(time.Second),
+	}
+	if reservedBy != nil {
+		reservation.ReservedBy = reservedBy.Email
+	}
+	if ttl > 0 {
+		expires := reservation.Reserved.Add(ttl)
+		reservation.Expires = &expires
+	}
+	stored := reservation
+	m.Reservations = append(m.Reservations, &stored)
+	return &reservation, nil
+}
+
+func (m *MockReservationModel) Release(accession string) error {
+	for _, reservation := range m.Reservations {
+		if reservation.Accession != accession {
+			continue
+		}
+		if reservation.Status != data.ReservationActive {
+			return data.ErrReservationClosed
+		}
+		released := time.Now().Truncate(time.Second)
+		reservation.Status = data.ReservationReleased
+		reservation.Released = &released
+		return nil
+	}
+	return data.ErrRecordNotFound
+}
+
+func (m *MockReservationModel) List(status string) ([]data.Reservation, error) {
+	reservations := []data.Reservation{}
+	for _, reservation := range m.Reservations {
+		if status == "" || reservation.Status == status {
+			reservations = append(reservations, *reservation)
+		}
+	}
+	return reservations, nil
+}
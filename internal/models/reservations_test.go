@@ -0,0 +1,113 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+func TestReservationModel(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test")
+	}
+
+	mt := newEntryTestDB(t)
+	defer mt.Teardown()
+
+	m := NewReservationModel(mt.m.DB)
+	requests := NewRequestModel(mt.m.DB)
+
+	// the highest accession in the test data is BGC0001070
+	first, err := m.Reserve("", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Accession != "BGC0001071" || first.Status != data.ReservationActive || first.Expires != nil {
+		t.Errorf("unexpected first reservation %+v", first)
+	}
+
+	second, err := m.Reserve("", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Accession != "BGC0001072" || second.Expires == nil {
+		t.Errorf("unexpected second reservation %+v", second)
+	}
+
+	if err = m.Release(first.Accession); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Release(first.Accession); !errors.Is(err, data.ErrReservationClosed) {
+		t.Errorf("releasing twice: want %v, got %v", data.ErrReservationClosed, err)
+	}
+	_, err = mt.m.DB.Exec(`UPDATE submissions.reservations SET expires_at = NOW() - interval '1 day' WHERE accession = $1`, second.Accession)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := data.StoredAccessionRequest{AccessionRequest: data.AccessionRequest{
+		Name: "Alice User", Email: "alice@example.org", Compounds: []string{"nisin Z"},
+		Loci: []data.AccessionRequestLocus{{GenBankAccession: "HM219853.1", Start: 1, End: 12000}},
+	}}
+	if err = requests.Insert(&request); err != nil {
+		t.Fatal(err)
+	}
+
+	// released and expired accessions are never handed out again
+	third, err := m.Reserve(request.Ticket, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.Accession != "BGC0001073" || third.Ticket != request.Ticket {
+		t.Errorf("unexpected reservation for ticket %+v", third)
+	}
+
+	resolved, err := requests.Get(request.Ticket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Status != data.RequestAccessionAssigned || resolved.Accession != third.Accession {
+		t.Errorf("request not resolved with the reservation: %+v", resolved)
+	}
+
+	if _, err = m.Reserve(request.Ticket, nil, 0); !errors.Is(err, data.ErrRequestClosed) {
+		t.Errorf("reserving for a resolved ticket: want %v, got %v", data.ErrRequestClosed, err)
+	}
+	if _, err = m.Reserve("REQ-MISSING", nil, 0); !errors.Is(err, data.ErrRecordNotFound) {
+		t.Errorf("reserving for an unknown ticket: want %v, got %v", data.ErrRecordNotFound, err)
+	}
+
+	for status, expected := range map[string][]string{
+		data.ReservationReleased: {first.Accession},
+		data.ReservationExpired:  {second.Accession},
+		data.ReservationActive:   {third.Accession},
+	} {
+		reservations, err := m.List(status)
+		if err != nil {
+			t.Fatal(err)
+		}
+		accessions := []string{}
+		for _, reservation := range reservations {
+			accessions = append(accessions, reservation.Accession)
+		}
+		if !cmp.Equal(expected, accessions) {
+			t.Errorf("%s reservations:\n%s", status, cmp.Diff(expected, accessions))
+		}
+	}
+
+	// releasing puts the request back up for review
+	if err = m.Release(third.Accession); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := requests.Get(request.Ticket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Status != data.RequestUnderReview || reopened.Accession != "" {
+		t.Errorf("request not reopened after release: %+v", reopened)
+	}
+}
@@ -0,0 +1,200 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+	"secondarymetabolites.org/mibig-api/internal/queries"
+)
+
+// searchMode describes what a search of a given query type returns.
+// Entry searches return entry ids, CDS and domain searches return rows of the
+// per-feature materialized views.
+type searchMode struct {
+	// statements selecting ids by category
+	statements map[string]string
+	// mode-specific categories tried in order for terms without a category,
+	// before falling back to the entry-level categories
+	detectOrder []string
+	// selects the features of all entries returned by an entry-level statement,
+	// empty for entry searches
+	byEntry string
+	// selects all ids, to negate searches
+	all string
+	// selects the ids belonging to embargoed entries, to hide them from the public
+	embargoed string
+}
+
+var entrySearch = searchMode{
+	statements: statementByCategory,
+	all:        `SELECT entry_id FROM live.entries`,
+	embargoed:  `SELECT entry_id FROM live.entries WHERE status = 'embargoed'`,
+}
+
+var cdsSearch = searchMode{
+	statements: map[string]string{
+		"locus":    `SELECT gene_id FROM live.entry_genes WHERE locus_tag ILIKE $1`,
+		"gene":     `SELECT gene_id FROM live.entry_genes WHERE name ILIKE $1`,
+		"product":  `SELECT gene_id FROM live.entry_genes WHERE product ILIKE $1`,
+		"function": `SELECT gene_id FROM live.entry_genes WHERE EXISTS (SELECT 1 FROM unnest(functions) AS f WHERE f ILIKE $1)`,
+	},
+	detectOrder: []string{"locus", "gene", "product"},
+	byEntry:     `SELECT gene_id FROM live.entry_genes WHERE entry_id IN (%s)`,
+	all:         `SELECT gene_id FROM live.entry_genes`,
+	embargoed:   `SELECT gene_id FROM live.entry_genes JOIN live.entries USING (entry_id) WHERE status = 'embargoed'`,
+}
+
+var domainSearch = searchMode{
+	statements: map[string]string{
+		"domain":    `SELECT domain_id FROM live.entry_domains WHERE domain ILIKE $1`,
+		"substrate": `SELECT domain_id FROM live.entry_domains WHERE EXISTS (SELECT 1 FROM unnest(substrates) AS s WHERE s ILIKE $1)`,
+		"module":    `SELECT domain_id FROM live.entry_domains WHERE module ILIKE $1`,
+		"locus":     `SELECT domain_id FROM live.entry_domains WHERE locus_tag ILIKE $1`,
+	},
+	detectOrder: []string{"domain", "substrate"},
+	byEntry:     `SELECT domain_id FROM live.entry_domains WHERE entry_id IN (%s)`,
+	all:         `SELECT domain_id FROM live.entry_domains`,
+	embargoed:   `SELECT domain_id FROM live.entry_domains JOIN live.entries USING (entry_id) WHERE status = 'embargoed'`,
+}
+
+func searchModeFor(queryType queries.QueryType) *searchMode {
+	switch queryType {
+	case queries.Cds:
+		return &cdsSearch
+	case queries.Domain:
+		return &domainSearch
+	}
+	return &entrySearch
+}
+
+// leaf returns the statement and argument for a single expression.
+// In feature searches, entry-level categories select all features of the matching entries.
+func (mode *searchMode) leaf(expr *queries.Expression) (string, string, error) {
+	statement, arg, err := expressionStatement(mode.statements, expr)
+	if err != nil || mode.byEntry == "" {
+		return statement, arg, err
+	}
+	if statement != "" && expr.Comparison == "" {
+		return statement, arg, nil
+	}
+
+	statement, arg, err = expressionStatement(statementByCategory, expr)
+	if err != nil || statement == "" {
+		return "", "", err
+	}
+	return fmt.Sprintf(mode.byEntry, statement), arg, nil
+}
+
+func (m *LiveEntryModel) guessCategoryInMode(mode *searchMode, term string) (string, error) {
+	for _, category := range mode.detectOrder {
+		var found bool
+		statement := fmt.Sprintf(`SELECT EXISTS (%s)`, mode.statements[category])
+		if err := m.DB.QueryRow(statement, likePattern(term)).Scan(&found); err != nil {
+			return "", err
+		}
+		if found {
+			return category, nil
+		}
+	}
+	return m.guessCategory(term)
+}
+
+var firstPlaceholder = regexp.MustCompile(`\$1\b`)
+
+var setOperations = map[queries.OperationType]string{
+	queries.AND:    "INTERSECT",
+	queries.OR:     "UNION",
+	queries.EXCEPT: "EXCEPT",
+}
+
+// compileSearch turns a query term into a single statement, doing the boolean logic
+// with set operations in the database. Arguments are numbered in order of appearance.
+// Unless the model includes embargoed entries, their hits are removed.
+func (m *LiveEntryModel) compileSearch(mode *searchMode, t queries.QueryTerm) (string, []any, error) {
+	args := []any{}
+	statement, err := m.compileTerm(mode, t, &args)
+	if err != nil {
+		return "", nil, err
+	}
+	if !m.Embargoed {
+		statement = fmt.Sprintf("(%s) EXCEPT (%s)", statement, mode.embargoed)
+	}
+	return fmt.Sprintf(`SELECT DISTINCT * FROM (%s) AS hits ORDER BY 1`, statement), args, nil
+}
+
+func (m *LiveEntryModel) compileTerm(mode *searchMode, t queries.QueryTerm, args *[]any) (string, error) {
+	switch v := t.(type) {
+	case *queries.Expression:
+		if v.Category == "unknown" {
+			cat, err := m.guessCategoryInMode(mode, v.Term)
+			if err != nil {
+				return "", err
+			}
+			v.Category = cat
+		}
+		statement, arg, err := mode.leaf(v)
+		if err != nil {
+			return "", err
+		}
+		if statement == "" {
+			return fmt.Sprintf(`%s WHERE false`, mode.all), nil
+		}
+		*args = append(*args, arg)
+		return firstPlaceholder.ReplaceAllLiteralString(statement, fmt.Sprintf("$%d", len(*args))), nil
+
+	case *queries.Operation:
+		operation, ok := setOperations[v.Operation]
+		if !ok {
+			return "", fmt.Errorf("invalid operation: %s", v.Op())
+		}
+		left, err := m.compileTerm(mode, v.Left, args)
+		if err != nil {
+			return "", err
+		}
+		right, err := m.compileTerm(mode, v.Right, args)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(%s) %s (%s)", left, operation, right), nil
+
+	case *queries.Negation:
+		negated, err := m.compileTerm(mode, v.Term, args)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(%s) EXCEPT (%s)", mode.all, negated), nil
+	}
+	return "", fmt.Errorf("invalid query term %T", t)
+}
+
+// Explain compiles a query like a search would and returns the query plan
+func (m *LiveEntryModel) Explain(query *queries.Query) (*data.SearchPlan, error) {
+	statement, args, err := m.compileSearch(searchModeFor(query.QueryType), query.Terms)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := m.DB.Query(fmt.Sprintf(`EXPLAIN %s`, statement), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plan := data.SearchPlan{Statement: statement, Arguments: make([]string, 0, len(args)), Plan: []string{}}
+	for _, arg := range args {
+		plan.Arguments = append(plan.Arguments, fmt.Sprint(arg))
+	}
+	for rows.Next() {
+		var line string
+		if err = rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		plan.Plan = append(plan.Plan, strings.TrimRight(line, " "))
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
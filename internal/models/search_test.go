@@ -0,0 +1,113 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"secondarymetabolites.org/mibig-api/internal/queries"
+)
+
+func TestCompileSearch(t *testing.T) {
+	m := LiveEntryModel{Embargoed: true}
+
+	tests := []struct {
+		Name              string
+		Mode              *searchMode
+		Term              queries.QueryTerm
+		ExpectedStatement string
+		ExpectedArgs      []any
+	}{
+		{
+			Name:              "single expression",
+			Mode:              &entrySearch,
+			Term:              &queries.Expression{Category: "acc", Term: "BGC0000001*"},
+			ExpectedStatement: `SELECT DISTINCT * FROM (SELECT entry_id FROM live.entries WHERE entry_id ILIKE $1) AS hits ORDER BY 1`,
+			ExpectedArgs:      []any{"BGC0000001%"},
+		},
+		{
+			Name: "operations",
+			Mode: &entrySearch,
+			Term: &queries.Operation{Operation: queries.OR,
+				Left:  &queries.Expression{Category: "acc", Term: "BGC0000001"},
+				Right: &queries.Expression{Category: "genus", Term: "Streptomyces"},
+			},
+			ExpectedStatement: `SELECT DISTINCT * FROM ((SELECT entry_id FROM live.entries WHERE entry_id ILIKE $1) UNION ` +
+				`(SELECT entry_id FROM live.entries LEFT JOIN data.taxa USING (tax_id) WHERE genus ILIKE $2)) AS hits ORDER BY 1`,
+			ExpectedArgs: []any{"BGC0000001", "Streptomyces"},
+		},
+		{
+			Name: "negation",
+			Mode: &entrySearch,
+			Term: &queries.Negation{Term: &queries.Expression{Category: "phylum", Term: "Actinomycetota"}},
+			ExpectedStatement: `SELECT DISTINCT * FROM ((SELECT entry_id FROM live.entries) EXCEPT ` +
+				`(SELECT entry_id FROM live.entries LEFT JOIN data.taxa USING (tax_id) WHERE phylum ILIKE $1)) AS hits ORDER BY 1`,
+			ExpectedArgs: []any{"Actinomycetota"},
+		},
+		{
+			Name:              "unsupported category",
+			Mode:              &entrySearch,
+			Term:              &queries.Expression{Category: "product", Term: "foo"},
+			ExpectedStatement: `SELECT DISTINCT * FROM (SELECT entry_id FROM live.entries WHERE false) AS hits ORDER BY 1`,
+			ExpectedArgs:      []any{},
+		},
+		{
+			Name: "feature search with entry category",
+			Mode: &cdsSearch,
+			Term: &queries.Operation{Operation: queries.AND,
+				Left:  &queries.Expression{Category: "product", Term: "*synthetase"},
+				Right: &queries.Expression{Category: "acc", Term: "BGC0000001"},
+			},
+			ExpectedStatement: `SELECT DISTINCT * FROM ((SELECT gene_id FROM live.entry_genes WHERE product ILIKE $1) INTERSECT ` +
+				`(SELECT gene_id FROM live.entry_genes WHERE entry_id IN (SELECT entry_id FROM live.entries WHERE entry_id ILIKE $2))) AS hits ORDER BY 1`,
+			ExpectedArgs: []any{"%synthetase", "BGC0000001"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			statement, args, err := m.compileSearch(tt.Mode, tt.Term)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if statement != tt.ExpectedStatement {
+				t.Errorf("Unexpected statement.\n%s", cmp.Diff(tt.ExpectedStatement, statement))
+			}
+			if !cmp.Equal(tt.ExpectedArgs, args) {
+				t.Errorf("Unexpected arguments.\n%s", cmp.Diff(tt.ExpectedArgs, args))
+			}
+		})
+	}
+}
+
+func TestCompileSearchHidesEmbargoed(t *testing.T) {
+	m := LiveEntryModel{}
+
+	statement, _, err := m.compileSearch(&cdsSearch, &queries.Expression{Category: "gene", Term: "orf1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `SELECT DISTINCT * FROM ((SELECT gene_id FROM live.entry_genes WHERE name ILIKE $1) EXCEPT ` +
+		`(SELECT gene_id FROM live.entry_genes JOIN live.entries USING (entry_id) WHERE status = 'embargoed')) AS hits ORDER BY 1`
+	if statement != expected {
+		t.Errorf("Unexpected statement.\n%s", cmp.Diff(expected, statement))
+	}
+}
+
+func TestLikePattern(t *testing.T) {
+	tests := []struct {
+		Term     string
+		Expected string
+	}{
+		{"nrps", "nrps"},
+		{"actino*", "actino%"},
+		{"BGC000000?", "BGC000000_"},
+		{`50%_done\`, `50\%\_done\\`},
+	}
+
+	for _, tt := range tests {
+		if actual := likePattern(tt.Term); actual != tt.Expected {
+			t.Errorf("likePattern(%s): expected %s, got %s", tt.Term, tt.Expected, actual)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+const (
+	LiveSchema     = "live"
+	StagingSchema  = "live_staging"
+	PreviousSchema = "live_previous"
+)
+
+type StagingModel interface {
+	Prepare() error
+	Entries() EntryModel
+	Check(minRatio float64) (*data.StagingCheck, error)
+	Promote() error
+	Rollback() error
+}
+
+type LiveStagingModel struct {
+	DB *sql.DB
+}
+
+func NewStagingModel(db *sql.DB) *LiveStagingModel {
+	return &LiveStagingModel{DB: db}
+}
+
+// Prepare drops any leftover staging schema and creates an empty copy of the live schema
+func (m *LiveStagingModel) Prepare() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`DROP SCHEMA IF EXISTS %s CASCADE`, StagingSchema))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = cloneSchema(LiveSchema, StagingSchema, ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// cloneSchema creates an empty copy of a schema, reading its layout from the catalog so the copy
+// follows whatever the migrations did to the original. Enum types, tables with their columns,
+// defaults, constraints and indexes, foreign keys and materialized views are copied.
+func cloneSchema(from, to string, ctx context.Context, tx *sql.Tx) error {
+	// with only pg_catalog on the search path, the catalog functions schema-qualify all names
+	_, err := tx.ExecContext(ctx, `SET LOCAL search_path TO pg_catalog`)
+	if err != nil {
+		return err
+	}
+
+	qualified := regexp.MustCompile(`\b` + regexp.QuoteMeta(from) + `\.`)
+	rename := func(definition string) string {
+		return qualified.ReplaceAllString(definition, to+".")
+	}
+
+	statements := []string{fmt.Sprintf(`CREATE SCHEMA %s`, pq.QuoteIdentifier(to))}
+
+	enums, err := queryPairs(ctx, tx, `SELECT t.typname, string_agg(quote_literal(e.enumlabel), ', ' ORDER BY e.enumsortorder)
+		FROM pg_type t JOIN pg_enum e ON e.enumtypid = t.oid
+		WHERE t.typnamespace = $1::regnamespace GROUP BY t.typname ORDER BY t.typname`, from)
+	if err != nil {
+		return err
+	}
+	for _, enum := range enums {
+		statements = append(statements, fmt.Sprintf(`CREATE TYPE %s.%s AS ENUM (%s)`, pq.QuoteIdentifier(to), pq.QuoteIdentifier(enum[0]), enum[1]))
+	}
+
+	tables, err := queryPairs(ctx, tx, `SELECT relname, '' FROM pg_class
+		WHERE relnamespace = $1::regnamespace AND relkind = 'r' ORDER BY relname`, from)
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		statements = append(statements, fmt.Sprintf(`CREATE TABLE %[1]s.%[3]s (LIKE %[2]s.%[3]s INCLUDING ALL)`,
+			pq.QuoteIdentifier(to), pq.QuoteIdentifier(from), pq.QuoteIdentifier(table[0])))
+	}
+
+	// LIKE keeps the column types, so columns using an enum of the old schema need to switch to the copy
+	enumColumns, err := queryPairs(ctx, tx, `SELECT c.relname, a.attname || '/' || t.typname
+		FROM pg_attribute a JOIN pg_class c ON a.attrelid = c.oid JOIN pg_type t ON a.atttypid = t.oid
+		WHERE c.relnamespace = $1::regnamespace AND c.relkind = 'r' AND t.typnamespace = $1::regnamespace AND t.typtype = 'e'
+			AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY c.relname, a.attnum`, from)
+	if err != nil {
+		return err
+	}
+	for _, column := range enumColumns {
+		name, typeName, _ := strings.Cut(column[1], "/")
+		statements = append(statements, fmt.Sprintf(`ALTER TABLE %[1]s.%[2]s ALTER COLUMN %[3]s TYPE %[1]s.%[4]s USING %[3]s::text::%[1]s.%[4]s`,
+			pq.QuoteIdentifier(to), pq.QuoteIdentifier(column[0]), pq.QuoteIdentifier(name), pq.QuoteIdentifier(typeName)))
+	}
+
+	foreignKeys, err := queryPairs(ctx, tx, `SELECT c.relname, quote_ident(con.conname) || ' ' || pg_get_constraintdef(con.oid)
+		FROM pg_constraint con JOIN pg_class c ON con.conrelid = c.oid
+		WHERE c.relnamespace = $1::regnamespace AND con.contype = 'f' ORDER BY c.relname, con.conname`, from)
+	if err != nil {
+		return err
+	}
+	for _, foreignKey := range foreignKeys {
+		statements = append(statements, fmt.Sprintf(`ALTER TABLE %s.%s ADD CONSTRAINT %s`,
+			pq.QuoteIdentifier(to), pq.QuoteIdentifier(foreignKey[0]), rename(foreignKey[1])))
+	}
+
+	views, err := queryPairs(ctx, tx, `SELECT relname, pg_get_viewdef(oid) FROM pg_class
+		WHERE relnamespace = $1::regnamespace AND relkind = 'm' ORDER BY oid`, from)
+	if err != nil {
+		return err
+	}
+	for _, view := range views {
+		statements = append(statements, fmt.Sprintf(`CREATE MATERIALIZED VIEW %s.%s AS %s WITH NO DATA`,
+			pq.QuoteIdentifier(to), pq.QuoteIdentifier(view[0]), strings.TrimRight(rename(view[1]), "; \n")))
+	}
+
+	viewIndexes, err := queryPairs(ctx, tx, `SELECT i.indexname, i.indexdef FROM pg_indexes i JOIN pg_class c ON c.relname = i.tablename
+		WHERE i.schemaname = $1 AND c.relnamespace = $1::regnamespace AND c.relkind = 'm' ORDER BY i.indexname`, from)
+	if err != nil {
+		return err
+	}
+	for _, index := range viewIndexes {
+		statements = append(statements, rename(index[1]))
+	}
+
+	for _, statement := range statements {
+		if _, err = tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("%w: %s", err, statement)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `SET LOCAL search_path TO DEFAULT`)
+	return err
+}
+
+// queryPairs runs a catalog query returning two text columns
+func queryPairs(ctx context.Context, tx *sql.Tx, query string, args ...any) ([][2]string, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pairs [][2]string
+	for rows.Next() {
+		var pair [2]string
+		if err = rows.Scan(&pair[0], &pair[1]); err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, rows.Err()
+}
+
+// Entries returns an entry model that imports into the staging schema
+func (m *LiveStagingModel) Entries() EntryModel {
+	return &LiveEntryModel{DB: m.DB, Schema: StagingSchema}
+}
+
+// Check runs sanity checks on the staging schema, comparing it to the live schema.
+// A staged release is considered broken if it lost more than (1 - minRatio) of the
+// active entries currently live.
+func (m *LiveStagingModel) Check(minRatio float64) (*data.StagingCheck, error) {
+	exists, err := m.schemaExists(StagingSchema)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, data.ErrNoSchema
+	}
+
+	check := data.StagingCheck{Problems: []string{}}
+
+	check.Staging, err = countsInSchema(m.DB, StagingSchema, true)
+	if err != nil {
+		return nil, err
+	}
+
+	check.Live, err = countsInSchema(m.DB, LiveSchema, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if check.Staging.Total == 0 {
+		check.Problems = append(check.Problems, "staging schema contains no entries")
+	}
+
+	if check.Live.Active > 0 && float64(check.Staging.Active) < minRatio*float64(check.Live.Active) {
+		check.Problems = append(check.Problems, fmt.Sprintf("staging has %d active entries, live has %d", check.Staging.Active, check.Live.Active))
+	}
+
+	var count int
+	err = m.DB.QueryRow(fmt.Sprintf(`SELECT COUNT(entry_id) FROM %s.entries e LEFT JOIN data.taxa t USING (tax_id) WHERE t.tax_id IS NULL`, StagingSchema)).Scan(&count)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		check.Problems = append(check.Problems, fmt.Sprintf("%d entries without taxonomy information", count))
+	}
+
+	rows, err := m.DB.Query(fmt.Sprintf(`SELECT accession FROM %s.entries WHERE status = 'active' GROUP BY accession HAVING COUNT(version) > 1 ORDER BY accession`, StagingSchema))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var accession string
+		if err = rows.Scan(&accession); err != nil {
+			return nil, err
+		}
+		check.Problems = append(check.Problems, fmt.Sprintf("%s has more than one active version", accession))
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, view := range []struct {
+		Name       string
+		MayBeEmpty bool
+	}{{"entry_bgc_info", false}, {"entry_compounds", false}, {"entry_genes", false}, {"entry_domains", true}} {
+		// Querying a materialized view that was never refreshed fails
+		err = m.DB.QueryRow(fmt.Sprintf(`SELECT COUNT(entry_id) FROM %s.%s`, StagingSchema, view.Name)).Scan(&count)
+		if err != nil {
+			check.Problems = append(check.Problems, fmt.Sprintf("materialized view %s not available: %s", view.Name, err))
+			continue
+		}
+		if count == 0 && check.Staging.Total > 0 && !view.MayBeEmpty {
+			check.Problems = append(check.Problems, fmt.Sprintf("materialized view %s is empty", view.Name))
+		}
+	}
+
+	return &check, nil
+}
+
+// Promote makes the staging schema the live schema, keeping the current live schema
+// around for rollback. Any older previous release is dropped.
+func (m *LiveStagingModel) Promote() error {
+	exists, err := m.schemaExists(StagingSchema)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return data.ErrNoSchema
+	}
+
+	return m.renameSchemas(
+		fmt.Sprintf(`DROP SCHEMA IF EXISTS %s CASCADE`, PreviousSchema),
+		fmt.Sprintf(`ALTER SCHEMA %s RENAME TO %s`, LiveSchema, PreviousSchema),
+		fmt.Sprintf(`ALTER SCHEMA %s RENAME TO %s`, StagingSchema, LiveSchema),
+	)
+}
+
+// Rollback swaps the live and previous schemas.
+// Rolling back twice restores the promoted release.
+func (m *LiveStagingModel) Rollback() error {
+	exists, err := m.schemaExists(PreviousSchema)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return data.ErrNoSchema
+	}
+
+	swap := "live_rollback"
+	return m.renameSchemas(
+		fmt.Sprintf(`ALTER SCHEMA %s RENAME TO %s`, LiveSchema, swap),
+		fmt.Sprintf(`ALTER SCHEMA %s RENAME TO %s`, PreviousSchema, LiveSchema),
+		fmt.Sprintf(`ALTER SCHEMA %s RENAME TO %s`, swap, PreviousSchema),
+	)
+}
+
+func (m *LiveStagingModel) renameSchemas(statements ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range statements {
+		_, err = tx.ExecContext(ctx, statement)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (m *LiveStagingModel) schemaExists(schema string) (bool, error) {
+	var exists bool
+	err := m.DB.QueryRow(`SELECT EXISTS (SELECT 1 FROM pg_namespace WHERE nspname = $1)`, schema).Scan(&exists)
+	return exists, err
+}
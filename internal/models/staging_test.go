@@ -0,0 +1,76 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStagingModelPrepare(t *testing.T) {
+	if testing.Short() {
+		t.Skip("postgres: skipping integration test")
+	}
+
+	mt := newEntryTestDB(t)
+	defer mt.Teardown()
+
+	m := NewStagingModel(mt.m.DB)
+	if err := m.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+	defer mt.m.DB.Exec(fmt.Sprintf(`DROP SCHEMA IF EXISTS %s CASCADE`, StagingSchema))
+
+	for _, layout := range []struct {
+		Name  string
+		Query string
+	}{
+		{Name: "columns", Query: `SELECT c.relname || '.' || a.attname || ' ' || format_type(a.atttypid, a.atttypmod) || CASE WHEN a.attnotnull THEN ' NOT NULL' ELSE '' END
+			FROM pg_attribute a JOIN pg_class c ON a.attrelid = c.oid
+			WHERE c.relnamespace = $1::regnamespace AND c.relkind IN ('r', 'm') AND a.attnum > 0 AND NOT a.attisdropped
+			ORDER BY c.relname, a.attnum`},
+		{Name: "constraints", Query: `SELECT c.relname || ' ' || pg_get_constraintdef(con.oid)
+			FROM pg_constraint con JOIN pg_class c ON con.conrelid = c.oid
+			WHERE c.relnamespace = $1::regnamespace ORDER BY 1`},
+		{Name: "indexes", Query: `SELECT tablename || ' ' || regexp_replace(indexdef, '^CREATE (UNIQUE )?INDEX \S+ ', '')
+			FROM pg_indexes WHERE schemaname = $1 ORDER BY 1`},
+	} {
+		t.Run(layout.Name, func(t *testing.T) {
+			live, err := schemaLayout(mt.m.DB, layout.Query, LiveSchema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			staging, err := schemaLayout(mt.m.DB, layout.Query, StagingSchema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(live) == 0 {
+				t.Fatalf("no %s found in %s", layout.Name, LiveSchema)
+			}
+			if diff := cmp.Diff(live, staging); diff != "" {
+				t.Errorf("%s of %s differ from %s (-live +staging):\n%s", layout.Name, StagingSchema, LiveSchema, diff)
+			}
+		})
+	}
+}
+
+// schemaLayout runs a catalog query for a schema, removing the schema name from the results
+func schemaLayout(db *sql.DB, query, schema string) ([]string, error) {
+	rows, err := db.Query(query, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var layout []string
+	for rows.Next() {
+		var line string
+		if err = rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		layout = append(layout, strings.ReplaceAll(line, schema+".", ""))
+	}
+	return layout, rows.Err()
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+type TaxonModel interface {
+	ReferencedTaxIds() ([]int64, error)
+	Refresh(cache *data.TaxonCache, dryRun bool) (*data.TaxaRefresh, error)
+}
+
+type LiveTaxonModel struct {
+	DB *sql.DB
+}
+
+func NewTaxonModel(db *sql.DB) *LiveTaxonModel {
+	return &LiveTaxonModel{DB: db}
+}
+
+// ReferencedTaxIds lists the NCBI taxids known to the database
+func (m *LiveTaxonModel) ReferencedTaxIds() ([]int64, error) {
+	rows, err := m.DB.Query(`SELECT DISTINCT ncbi_taxid FROM data.taxa ORDER BY ncbi_taxid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	taxIds := []int64{}
+	for rows.Next() {
+		var taxId int64
+		if err = rows.Scan(&taxId); err != nil {
+			return nil, err
+		}
+		taxIds = append(taxIds, taxId)
+	}
+	return taxIds, rows.Err()
+}
+
+// Refresh re-resolves all taxa against the taxon cache, updating their lineages and
+// moving merged taxids to their replacement, including in the data of the entries.
+// Deleted and unknown taxids are only reported. In dry-run mode nothing is changed.
+func (m *LiveTaxonModel) Refresh(cache *data.TaxonCache, dryRun bool) (*data.TaxaRefresh, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	statement := `SELECT tax_id, ncbi_taxid, name,
+		COALESCE(superkingdom, ''), COALESCE(kingdom, ''), COALESCE(phylum, ''), COALESCE(class, ''),
+		COALESCE(taxonomic_order, ''), COALESCE(family, ''), COALESCE(genus, ''), COALESCE(species, ''),
+		ARRAY(SELECT entry_id FROM live.entries e WHERE e.tax_id = t.tax_id ORDER BY entry_id)
+	FROM data.taxa t ORDER BY name FOR UPDATE`
+	rows, err := tx.QueryContext(ctx, statement)
+	if err != nil {
+		return nil, err
+	}
+
+	type storedTaxon struct {
+		id      int64
+		taxon   data.NcbiTaxEntry
+		entries []string
+	}
+	var stored []storedTaxon
+	for rows.Next() {
+		var row storedTaxon
+		t := &row.taxon
+		if err = rows.Scan(&row.id, &t.TaxId, &t.Name, &t.Superkingdom, &t.Kingdom, &t.Phylum, &t.Class,
+			&t.Order, &t.Family, &t.Genus, &t.Species, pq.Array(&row.entries)); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stored = append(stored, row)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report := data.TaxaRefresh{DryRun: dryRun, Changed: []data.TaxonChange{}, Deleted: []data.TaxonChange{}, Missing: []data.TaxonChange{}}
+
+	for _, row := range stored {
+		change, current, err := compareTaxon(row.taxon, cache)
+		change.Entries = row.entries
+		switch {
+		case errors.Is(err, data.ErrTaxonDeleted):
+			report.Deleted = append(report.Deleted, change)
+			continue
+		case errors.Is(err, data.ErrRecordNotFound):
+			report.Missing = append(report.Missing, change)
+			continue
+		case err != nil:
+			return nil, err
+		}
+
+		if change.NcbiTaxId == change.OldNcbiTaxId && len(change.Ranks) == 0 {
+			report.Unchanged++
+			continue
+		}
+		report.Changed = append(report.Changed, change)
+
+		statement = `UPDATE data.taxa SET ncbi_taxid = $2, superkingdom = $3, kingdom = $4, phylum = $5, class = $6,
+			taxonomic_order = $7, family = $8, genus = $9, species = $10
+		WHERE tax_id = $1`
+		_, err = tx.ExecContext(ctx, statement, row.id, current.TaxId, current.Superkingdom, current.Kingdom, current.Phylum,
+			current.Class, current.Order, current.Family, current.Genus, current.Species)
+		if err != nil {
+			return nil, err
+		}
+
+		if change.NcbiTaxId != change.OldNcbiTaxId {
+			statement = `UPDATE live.entries SET data = jsonb_set(data, '{taxonomy,ncbiTaxId}', to_jsonb($2::bigint)), updated_at = NOW()
+			WHERE tax_id = $1`
+			if _, err = tx.ExecContext(ctx, statement, row.id, current.TaxId); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if dryRun {
+		return &report, nil
+	}
+	return &report, tx.Commit()
+}
+
+// compareTaxon looks up a stored taxon in the cache and lists what changed.
+// Errors from the cache lookup are returned with the partially filled change.
+func compareTaxon(stored data.NcbiTaxEntry, cache *data.TaxonCache) (data.TaxonChange, *data.NcbiTaxEntry, error) {
+	change := data.TaxonChange{Name: stored.Name, OldNcbiTaxId: stored.TaxId, NcbiTaxId: stored.TaxId}
+
+	current, err := cache.EntryForTaxId(stored.TaxId)
+	if err != nil {
+		return change, nil, err
+	}
+	change.NcbiTaxId = current.TaxId
+
+	ranks := []struct {
+		Rank     string
+		Old, New string
+	}{
+		{"superkingdom", stored.Superkingdom, current.Superkingdom},
+		{"kingdom", stored.Kingdom, current.Kingdom},
+		{"phylum", stored.Phylum, current.Phylum},
+		{"class", stored.Class, current.Class},
+		{"order", stored.Order, current.Order},
+		{"family", stored.Family, current.Family},
+		{"genus", stored.Genus, current.Genus},
+		{"species", stored.Species, current.Species},
+	}
+	for _, rank := range ranks {
+		if rank.Old != rank.New {
+			change.Ranks = append(change.Ranks, data.RankChange{Rank: rank.Rank, Old: rank.Old, New: rank.New})
+		}
+	}
+	return change, current, nil
+}
+
+type MockTaxonModel struct {
+}
+
+func NewMockTaxonModel() *MockTaxonModel {
+	return &MockTaxonModel{}
+}
+
+func (m *MockTaxonModel) ReferencedTaxIds() ([]int64, error) {
+	return nil, data.ErrNotImplemented
+}
+
+func (m *MockTaxonModel) Refresh(cache *data.TaxonCache, dryRun bool) (*data.TaxaRefresh, error) {
+	return nil, data.ErrNotImplemented
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+func TestCompareTaxon(t *testing.T) {
+	cache := &data.TaxonCache{
+		DeprecatedIds: map[int64]int64{100226: 1902},
+		Mappings: map[int64]data.NcbiTaxEntry{
+			1902: {TaxId: 1902, Superkingdom: "Bacteria", Kingdom: "Bacillati", Phylum: "Actinomycetota", Class: "Actinomycetes",
+				Order: "Kitasatosporales", Family: "Streptomycetaceae", Genus: "Streptomyces", Species: "coelicolor"},
+		},
+		DeletedIds: map[int64]bool{12345: true},
+	}
+
+	stored := data.NcbiTaxEntry{TaxId: 100226, Name: "Streptomyces coelicolor A3(2)", Superkingdom: "Bacteria", Kingdom: "Unknown",
+		Phylum: "Actinobacteria", Class: "Actinomycetia", Order: "Streptomycetales", Family: "Streptomycetaceae",
+		Genus: "Streptomyces", Species: "coelicolor"}
+
+	expected := data.TaxonChange{
+		Name:         "Streptomyces coelicolor A3(2)",
+		OldNcbiTaxId: 100226,
+		NcbiTaxId:    1902,
+		Ranks: []data.RankChange{
+			{Rank: "kingdom", Old: "Unknown", New: "Bacillati"},
+			{Rank: "phylum", Old: "Actinobacteria", New: "Actinomycetota"},
+			{Rank: "class", Old: "Actinomycetia", New: "Actinomycetes"},
+			{Rank: "order", Old: "Streptomycetales", New: "Kitasatosporales"},
+		},
+	}
+
+	change, current, err := compareTaxon(stored, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(expected, change) {
+		t.Errorf("Unexpected change:\n%s", cmp.Diff(expected, change))
+	}
+	if current.TaxId != 1902 {
+		t.Errorf("Expected current taxid 1902, got %d", current.TaxId)
+	}
+
+	_, _, err = compareTaxon(data.NcbiTaxEntry{TaxId: 12345}, cache)
+	if !errors.Is(err, data.ErrTaxonDeleted) {
+		t.Errorf("Expected %s, got %v", data.ErrTaxonDeleted, err)
+	}
+}
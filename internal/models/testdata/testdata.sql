@@ -1,61 +1,54 @@
-INSERT INTO mibig.entries (entry_id, minimal, ncbi_taxid, organism_name, biosyn_class ) VALUES
-('BGC0000535', FALSE, 1360, 'Lactococcus lactis subsp. lactis', '{RiPP}'),
-('BGC0001070', FALSE, 1214242, 'Streptomyces collinus Tu 365', '{NRP,Polyketide}');
-
-INSERT INTO mibig.changelogs (comments, version_id, entry_id) VALUES
-('{Submitted}', 1, 'BGC0000535'),
-('{"Migrated from v1.4"}', 6, 'BGC0000535'),
-('{Submitted}', 1, 'BGC0001070'),
-('{"Migrated from v1.4"}', 6, 'BGC0001070');
-
-INSERT INTO mibig.chem_compounds (entry_id, synonyms, name) VALUES
-('BGC0000535', '{nisin}', 'nisin A'),
-('BGC0001070', '{mocimycin,delvomycin}', 'kirromycin');
-
-INSERT INTO mibig.loci (accession, completeness, evidences, start_coord, end_coord, mixs_compliant, entry_id) VALUES
-('HM219853.1', 'complete', '{"Gene expression correlated with compound production","Sequence-based prediction"}', 0, 0, FALSE, 'BGC0000535'),
-('AM746336.1', 'complete', '{"Knock-out studies"}', 0, 0, FALSE, 'BGC0001070');
-
-INSERT INTO mibig.publications (pub_type, pub_reference) VALUES
-('pubmed', '21183019'),
-('pubmed', '4554808'),
-('pubmed', '4373734'),
-('pubmed', '18291322'),
-('pubmed', '21513880'),
-('pubmed', '19609288'),
-('pubmed', '21401713'),
-('pubmed', '23828654');
-
-INSERT INTO mibig.rel_entries_pubs (entry_id, pub_id) VALUES
-('BGC0000535', 1),
-('BGC0001070', 2),
-('BGC0001070', 3),
-('BGC0001070', 4),
-('BGC0001070', 5),
-('BGC0001070', 6),
-('BGC0001070', 7),
-('BGC0001070', 8);
-
-INSERT INTO mibig.rel_entries_types (entry_id, bgc_type_id) VALUES
-('BGC0000535', 32),
-('BGC0001070', 2),
-('BGC0001070', 15),
-('BGC0001070', 13);
-
-INSERT INTO mibig.taxa (ncbi_taxid, superkingdom, phylum, class, taxonomic_order, family, genus, species, name) VALUES
-(1360, 'Bacteria', 'Firmicutes', 'Bacilli', 'Lactobacillales', 'Streptococcaceae', 'Lactococcus', 'lactis', 'Lactococcus lactis subsp. lactis'),
-(1214242, 'Bacteria', 'Actinobacteria', 'Actinobacteria', 'Streptomycetales', 'Streptomycetaceae', 'Streptomyces', 'collinus', 'Streptomyces collinus Tu 365');
-
-INSERT INTO mibig_submitters.submitters (
-    user_id, email, name, call_name, institution, password_hash, is_public, gdpr_consent, active
-) VALUES
-('AAAAAAAAAAAAAAAAAAAAAAAA', 'mibig@example.org', 'MIBiG Submitters', 'MIBiG', 'MIBiG', 'unused', TRUE, TRUE, FALSE),
-('AAAAAAAAAAAAAAAAAAAAAAAB', 'alice@example.org', 'Alice User', 'Alice', 'Testing', 'unused', TRUE, TRUE, TRUE),
-('AAAAAAAAAAAAAAAAAAAAAAAC', 'bob@example.org', 'Bob User', 'Bob', 'Testing', 'unused', TRUE, FALSE, TRUE),
-('AAAAAAAAAAAAAAAAAAAAAAAD', 'chuck@example.org', 'Chuck User', 'Chuck', 'Testing', 'unused', FALSE, FALSE, TRUE);
-
-INSERT INTO mibig_submitters.rel_submitters_roles (user_id, role_id) VALUES
-('AAAAAAAAAAAAAAAAAAAAAAAA', 4),
-('AAAAAAAAAAAAAAAAAAAAAAAB', 1),
-('AAAAAAAAAAAAAAAAAAAAAAAC', 2),
-('AAAAAAAAAAAAAAAAAAAAAAAD', 3);
+INSERT INTO data.bgc_types (term, name, description, safe_class, parent_id) VALUES
+('lanthipeptide', 'Lanthipeptide', 'Lanthipeptide', 'ripp', (SELECT bgc_type_id FROM data.bgc_types WHERE term = 'ribosomal'));
+
+INSERT INTO data.taxa (tax_id, ncbi_taxid, superkingdom, phylum, class, taxonomic_order, family, genus, species, name) VALUES
+(1, 1360, 'Bacteria', 'Firmicutes', 'Bacilli', 'Lactobacillales', 'Streptococcaceae', 'Lactococcus', 'lactis', 'Lactococcus lactis subsp. lactis'),
+(2, 1214242, 'Bacteria', 'Actinobacteria', 'Actinobacteria', 'Streptomycetales', 'Streptomycetaceae', 'Streptomyces', 'collinus', 'Streptomyces collinus Tu 365');
+
+INSERT INTO live.entries (entry_id, accession, version, status, quality, completeness, tax_id, organism_name, data) VALUES
+('BGC0000535.1', 'BGC0000535', 1, 'active', 'high', 'complete', 1, 'Lactococcus lactis subsp. lactis',
+ '{"accession": "BGC0000535", "version": 1, "compounds": [{"name": "nisin A", "synonyms": ["nisin"]}], "loci": [{"accession": "HM219853.1", "location": {"from": 1, "to": 12000}}], "biosynthesis": {"classes": [{"class": "lanthipeptide"}]}}'),
+('BGC0001070.1', 'BGC0001070', 1, 'active', 'medium', 'complete', 2, 'Streptomyces collinus Tu 365',
+ '{"accession": "BGC0001070", "version": 1, "compounds": [{"name": "kirromycin", "synonyms": ["mocimycin", "delvomycin"]}], "loci": [{"accession": "AM746336.1", "location": {"from": 1, "to": 82000}}], "biosynthesis": {"classes": [{"class": "NRPS"}, {"class": "PKS"}]}}');
+
+UPDATE live.entries SET search_text = data.entry_search_text(data);
+
+INSERT INTO live.compounds (entry_id, position, name) VALUES
+('BGC0000535.1', 1, 'nisin A'),
+('BGC0001070.1', 1, 'kirromycin');
+
+INSERT INTO live.compound_synonyms (entry_id, position, synonym) VALUES
+('BGC0000535.1', 1, 'nisin'),
+('BGC0001070.1', 1, 'mocimycin'),
+('BGC0001070.1', 1, 'delvomycin');
+
+INSERT INTO live.loci (entry_id, position, accession, start_coord, end_coord) VALUES
+('BGC0000535.1', 1, 'HM219853.1', 1, 12000),
+('BGC0001070.1', 1, 'AM746336.1', 1, 82000);
+
+INSERT INTO live.rel_entries_types (entry_id, bgc_type_id)
+SELECT 'BGC0000535.1', bgc_type_id FROM data.bgc_types WHERE term = 'lanthipeptide'
+UNION SELECT 'BGC0001070.1', bgc_type_id FROM data.bgc_types WHERE term IN ('nrps', 'pks');
+
+REFRESH MATERIALIZED VIEW live.entry_bgc_info;
+REFRESH MATERIALIZED VIEW live.entry_compounds;
+REFRESH MATERIALIZED VIEW live.entry_genes;
+REFRESH MATERIALIZED VIEW live.entry_domains;
+
+INSERT INTO auth.users (user_id, email, active, password_hash) VALUES
+(1, 'mibig@example.org', FALSE, 'unused'),
+(2, 'alice@example.org', TRUE, 'unused'),
+(3, 'bob@example.org', TRUE, 'unused'),
+(4, 'chuck@example.org', TRUE, 'unused');
+
+INSERT INTO auth.user_info (user_id, alias, name, call_name, organisation_1, public) VALUES
+(1, 'AAAAAAAAAAAAAAAAAAAAAAAA', 'MIBiG Submitters', 'MIBiG', 'MIBiG', TRUE),
+(2, 'AAAAAAAAAAAAAAAAAAAAAAAB', 'Alice User', 'Alice', 'Testing', TRUE),
+(3, 'AAAAAAAAAAAAAAAAAAAAAAAC', 'Bob User', 'Bob', 'Testing', TRUE),
+(4, 'AAAAAAAAAAAAAAAAAAAAAAAD', 'Chuck User', 'Chuck', 'Testing', FALSE);
+
+INSERT INTO auth.rel_user_roles (user_id, role_id) VALUES
+(1, 1),
+(2, 3),
+(3, 2),
+(4, 1);
@@ -32,7 +32,7 @@ func newSubmitterTestDB(t *testing.T) *SubmitterModelTest {
 		t.Fatal(err)
 	}
 	migration, err := migrate.NewWithDatabaseInstance(
-		"file://../../../migrations",
+		"file://../../migrations",
 		"postgres", driver)
 	if err != nil {
 		t.Fatal(err)
@@ -93,13 +93,12 @@ func (mt *SubmitterModelTest) Ping(t *testing.T) {
 
 func (mt *SubmitterModelTest) GetRolesById(t *testing.T) {
 	expected := []data.Role{
-		{Id: 1, Name: "admin", Description: "Users who can manage other users"},
-		{Id: 2, Name: "curator", Description: "Users who can approve new entries"},
-		{Id: 3, Name: "submitter", Description: "Users who can edit entries"},
-		{Id: 4, Name: "guest", Description: "Users with read only access"},
+		{Id: 1, Name: "submitter", Description: "Users who can edit entries"},
+		{Id: 2, Name: "reviewer", Description: "Users who can approve new entries"},
+		{Id: 3, Name: "admin", Description: "Users who can manage other users"},
 	}
 
-	roles, err := mt.m.GetRolesById([]int64{1, 2, 3, 4})
+	roles, err := mt.m.GetRolesById([]int64{1, 2, 3})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -111,13 +110,12 @@ func (mt *SubmitterModelTest) GetRolesById(t *testing.T) {
 
 func (mt *SubmitterModelTest) GetRolesByName(t *testing.T) {
 	expected := []data.Role{
-		{Id: 1, Name: "admin", Description: "Users who can manage other users"},
-		{Id: 2, Name: "curator", Description: "Users who can approve new entries"},
-		{Id: 3, Name: "submitter", Description: "Users who can edit entries"},
-		{Id: 4, Name: "guest", Description: "Users with read only access"},
+		{Id: 1, Name: "submitter", Description: "Users who can edit entries"},
+		{Id: 2, Name: "reviewer", Description: "Users who can approve new entries"},
+		{Id: 3, Name: "admin", Description: "Users who can manage other users"},
 	}
 
-	roles, err := mt.m.GetRolesByName([]string{"admin", "curator", "submitter", "guest"})
+	roles, err := mt.m.GetRolesByName([]string{"submitter", "reviewer", "admin"})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -129,20 +127,21 @@ func (mt *SubmitterModelTest) GetRolesByName(t *testing.T) {
 
 func (mt *SubmitterModelTest) Insert(t *testing.T) {
 	passwd := "secret"
-	roles, err := mt.m.GetRolesByName([]string{"guest"})
+	roles, err := mt.m.GetRolesByName([]string{"submitter"})
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	submitter := data.User{
-		Email:       "eve@example.org",
-		Name:        "Eve User",
-		CallName:    "Eve",
-		Institution: "Testing",
-		Public:      true,
-		GDPRConsent: true,
-		Active:      true,
-		Roles:       roles,
+		Email:  "eve@example.org",
+		Active: true,
+		Info: data.UserInfo{
+			Name:     "Eve User",
+			CallName: "Eve",
+			Org1:     "Testing",
+			Public:   true,
+		},
+		Roles: roles,
 	}
 
 	err = mt.m.Insert(&submitter, passwd)
@@ -150,23 +149,26 @@ func (mt *SubmitterModelTest) Insert(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if submitter.Id == "" {
+	if submitter.Id == 0 {
 		t.Errorf("Failed to set user ID on Insert")
 	}
 }
 
 func (mt *SubmitterModelTest) Get(t *testing.T) {
 	expected := &data.User{
-		Id:           "AAAAAAAAAAAAAAAAAAAAAAAB",
+		Id:           2,
 		Email:        "alice@example.org",
-		Name:         "Alice User",
-		CallName:     "Alice",
-		Institution:  "Testing",
 		PasswordHash: []uint8{0x75, 0x6e, 0x75, 0x73, 0x65, 0x64},
-		Public:       true,
-		GDPRConsent:  true,
 		Active:       true,
-		Roles:        []data.Role{{Id: 1, Name: "admin", Description: "Users who can manage other users"}},
+		Info: data.UserInfo{
+			Id:       2,
+			Alias:    "AAAAAAAAAAAAAAAAAAAAAAAB",
+			Name:     "Alice User",
+			CallName: "Alice",
+			Org1:     "Testing",
+			Public:   true,
+		},
+		Roles: []data.Role{{Id: 3, Name: "admin", Description: "Users who can manage other users"}},
 	}
 
 	submitter, err := mt.m.Get("alice@example.org", false)
@@ -188,14 +190,10 @@ func (mt *SubmitterModelTest) Authenticate(t *testing.T) {
 	expected := &data.User{
 		Id:           eve.Id,
 		Email:        "eve@example.org",
-		Name:         "Eve User",
-		CallName:     "Eve",
-		Institution:  "Testing",
 		PasswordHash: eve.PasswordHash,
-		Public:       true,
-		GDPRConsent:  true,
 		Active:       true,
-		Roles:        []data.Role{{Id: 4, Name: "guest", Description: "Users with read only access"}},
+		Info:         eve.Info,
+		Roles:        []data.Role{{Id: 1, Name: "submitter", Description: "Users who can edit entries"}},
 	}
 
 	submitter, err := mt.m.Authenticate("eve@example.org", "secret")
@@ -253,30 +251,30 @@ func (mt *SubmitterModelTest) Update(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if eve.Institution != "Testing" {
-		t.Errorf("Unexpected Institution %s", eve.Institution)
+	if eve.Info.Org1 != "Testing" {
+		t.Errorf("Unexpected Org1 %s", eve.Info.Org1)
 	}
 
-	eve.Institution = "Somewhere Else"
+	eve.Info.Org1 = "Somewhere Else"
 	mt.m.Update(eve, "")
 
 	submitter, err := mt.m.Authenticate("eve@example.org", "supersecret")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if submitter.Institution != eve.Institution {
-		t.Errorf("Expected %s, got %s", eve.Institution, submitter.Institution)
+	if submitter.Info.Org1 != eve.Info.Org1 {
+		t.Errorf("Expected %s, got %s", eve.Info.Org1, submitter.Info.Org1)
 	}
 
-	eve.CallName = "Dr. Eve"
+	eve.Info.CallName = "Dr. Eve"
 	mt.m.Update(eve, "secret")
 
 	submitter, err = mt.m.Authenticate("eve@example.org", "secret")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if submitter.CallName != eve.CallName {
-		t.Errorf("Expected %s, got %s", eve.CallName, submitter.CallName)
+	if submitter.Info.CallName != eve.Info.CallName {
+		t.Errorf("Expected %s, got %s", eve.Info.CallName, submitter.Info.CallName)
 	}
 
 }
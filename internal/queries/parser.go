@@ -0,0 +1,359 @@
+package queries
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Grammar of the search strings, from lowest to highest precedence:
+//
+//	query      := or END
+//	or         := and ( "OR" and )*
+//	and        := unary ( ( "AND" | "EXCEPT" )? unary )*
+//	unary      := "NOT" unary | primary
+//	primary    := "(" or ")" | expression
+//	expression := ( "[" category "]" )? ( word | '"' phrase '"' | comparison | range )
+//
+// Two expressions without keyword are ANDed. Comparisons look like [length]>20000,
+// ranges like [length]10000..20000.
+
+// ParseError is returned for invalid search strings.
+// Column is the 1-based position of the offending token in the input.
+type ParseError struct {
+	Message string
+	Column  int
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at column %d", e.Message, e.Column)
+}
+
+type TokenKind int
+
+const (
+	TokenEnd TokenKind = iota
+	TokenWord
+	TokenOpen
+	TokenClose
+)
+
+type Token struct {
+	Kind     TokenKind
+	Text     string
+	Category string
+	Quoted   bool
+	Column   int
+}
+
+func (t Token) String() string {
+	switch t.Kind {
+	case TokenEnd:
+		return "END"
+	case TokenOpen:
+		return "("
+	case TokenClose:
+		return ")"
+	}
+	text := t.Text
+	if t.Quoted {
+		text = strconv.Quote(text)
+	}
+	if t.Category != "" {
+		return fmt.Sprintf("[%s]%s", t.Category, text)
+	}
+	return text
+}
+
+type Parser struct {
+	tokens   []Token
+	keywords map[string]OperationType
+}
+
+func NewParser(input string) (*Parser, error) {
+	parser := Parser{keywords: STRING_OP_MAP}
+
+	tokens, err := generateTokens(input)
+	if err != nil {
+		return nil, err
+	}
+	parser.tokens = tokens
+
+	return &parser, nil
+}
+
+func (p *Parser) Peek() Token {
+	return p.tokens[0]
+}
+
+func (p *Parser) Consume() Token {
+	token := p.tokens[0]
+	// Keep the END token around so Peek never runs out of tokens
+	if token.Kind != TokenEnd {
+		p.tokens = p.tokens[1:]
+	}
+	return token
+}
+
+func (p *Parser) ConsumeExpected(expected TokenKind) bool {
+	if p.Peek().Kind == expected {
+		p.Consume()
+		return true
+	}
+	return false
+}
+
+// keyword returns the lower-cased keyword a token represents, or an empty string.
+// Quoted or categorised words are never keywords.
+func (p *Parser) keyword(token Token) string {
+	if token.Kind != TokenWord || token.Quoted || token.Category != "" {
+		return ""
+	}
+	word := strings.ToLower(token.Text)
+	if _, ok := p.keywords[word]; ok || word == "not" {
+		return word
+	}
+	return ""
+}
+
+// Parse parses the full input into a query term
+func (p *Parser) Parse() (QueryTerm, error) {
+	if p.Peek().Kind == TokenEnd {
+		return nil, &ParseError{Message: "Unexpected end of expression", Column: p.Peek().Column}
+	}
+
+	term, err := getTerm(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if token := p.Peek(); token.Kind != TokenEnd {
+		return nil, &ParseError{Message: fmt.Sprintf("Invalid token %s", token), Column: token.Column}
+	}
+	return term, nil
+}
+
+func getTerm(parser *Parser) (QueryTerm, error) {
+	left, err := getConjunction(parser)
+	if err != nil {
+		return nil, err
+	}
+
+	for parser.keyword(parser.Peek()) == "or" {
+		parser.Consume()
+		right, err := getConjunction(parser)
+		if err != nil {
+			return nil, err
+		}
+		left = &Operation{Operation: OR, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func getConjunction(parser *Parser) (QueryTerm, error) {
+	left, err := getUnary(parser)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		token := parser.Peek()
+		op := AND
+		switch parser.keyword(token) {
+		case "and":
+			parser.Consume()
+		case "except":
+			parser.Consume()
+			op = EXCEPT
+		case "or":
+			return left, nil
+		case "not":
+			// Two expressions without keyword will be ANDed
+		default:
+			if token.Kind == TokenEnd || token.Kind == TokenClose {
+				return left, nil
+			}
+		}
+
+		right, err := getUnary(parser)
+		if err != nil {
+			return nil, err
+		}
+		if negation, ok := right.(*Negation); ok && op == AND {
+			op = EXCEPT
+			right = negation.Term
+		}
+		left = &Operation{Operation: op, Left: left, Right: right}
+	}
+}
+
+func getUnary(parser *Parser) (QueryTerm, error) {
+	if parser.keyword(parser.Peek()) != "not" {
+		return getExpression(parser)
+	}
+
+	parser.Consume()
+	term, err := getUnary(parser)
+	if err != nil {
+		return nil, err
+	}
+	if negation, ok := term.(*Negation); ok {
+		return negation.Term, nil
+	}
+	return &Negation{Term: term}, nil
+}
+
+func getExpression(parser *Parser) (QueryTerm, error) {
+	token := parser.Peek()
+	switch token.Kind {
+	case TokenOpen:
+		parser.Consume()
+		term, err := getTerm(parser)
+		if err != nil {
+			return nil, err
+		}
+		if !parser.ConsumeExpected(TokenClose) {
+			return nil, &ParseError{Message: fmt.Sprintf("Invalid token %s", parser.Peek()), Column: parser.Peek().Column}
+		}
+		return term, nil
+	case TokenEnd:
+		return nil, &ParseError{Message: "Unexpected end of expression", Column: token.Column}
+	case TokenClose:
+		return nil, &ParseError{Message: "Invalid token )", Column: token.Column}
+	}
+
+	if keyword := parser.keyword(token); keyword != "" {
+		return nil, &ParseError{Message: fmt.Sprintf("Invalid use of keyword %s", token.Text), Column: token.Column}
+	}
+	parser.Consume()
+
+	return newExpression(token)
+}
+
+func newExpression(token Token) (QueryTerm, error) {
+	category := "unknown"
+	if token.Category != "" {
+		category = token.Category
+	}
+
+	if token.Quoted {
+		return &Expression{Term: token.Text, Category: category}, nil
+	}
+
+	for _, comparison := range COMPARISONS {
+		if !strings.HasPrefix(token.Text, comparison) {
+			continue
+		}
+		if token.Category == "" {
+			return nil, &ParseError{Message: fmt.Sprintf("Comparison %s needs a category, like [length]>20000", token.Text), Column: token.Column}
+		}
+		number := token.Text[len(comparison):]
+		if _, err := strconv.ParseInt(number, 10, 64); err != nil {
+			return nil, &ParseError{Message: fmt.Sprintf("Invalid number %s", number), Column: token.Column}
+		}
+		return &Expression{Term: number, Category: category, Comparison: comparison}, nil
+	}
+
+	if low, high, found := strings.Cut(token.Text, ".."); found && token.Category != "" {
+		low_value, low_err := strconv.ParseInt(low, 10, 64)
+		high_value, high_err := strconv.ParseInt(high, 10, 64)
+		if low_err != nil || high_err != nil {
+			return nil, &ParseError{Message: fmt.Sprintf("Invalid range %s", token.Text), Column: token.Column}
+		}
+		if low_value > high_value {
+			return nil, &ParseError{Message: fmt.Sprintf("Empty range %s", token.Text), Column: token.Column}
+		}
+		return &Operation{
+			Operation: AND,
+			Left:      &Expression{Term: low, Category: category, Comparison: ">="},
+			Right:     &Expression{Term: high, Category: category, Comparison: "<="},
+		}, nil
+	}
+
+	return &Expression{Term: token.Text, Category: category}, nil
+}
+
+func generateTokens(input string) ([]Token, error) {
+	runes := []rune(input)
+	tokens := []Token{}
+
+	for i := 0; i < len(runes); {
+		switch r := runes[i]; {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, Token{Kind: TokenOpen, Column: i + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, Token{Kind: TokenClose, Column: i + 1})
+			i++
+		default:
+			token := Token{Kind: TokenWord, Column: i + 1}
+			if r == '[' {
+				end := indexRune(runes, i, ']')
+				if end == -1 {
+					return nil, &ParseError{Message: "Unterminated category", Column: token.Column}
+				}
+				token.Category = string(runes[i+1 : end])
+				if token.Category == "" {
+					return nil, &ParseError{Message: "Empty category", Column: token.Column}
+				}
+				i = end + 1
+			}
+
+			if i < len(runes) && runes[i] == '"' {
+				text, next, err := readQuoted(runes, i)
+				if err != nil {
+					return nil, err
+				}
+				token.Text = text
+				token.Quoted = true
+				i = next
+			} else {
+				start := i
+				for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune(`()"`, runes[i]) {
+					i++
+				}
+				token.Text = string(runes[start:i])
+				if token.Text == "" {
+					return nil, &ParseError{Message: fmt.Sprintf("Missing search term for category %s", token.Category), Column: token.Column}
+				}
+			}
+			tokens = append(tokens, token)
+		}
+	}
+
+	tokens = append(tokens, Token{Kind: TokenEnd, Column: len(runes) + 1})
+
+	return tokens, nil
+}
+
+func indexRune(runes []rune, start int, needle rune) int {
+	for i := start; i < len(runes); i++ {
+		if runes[i] == needle {
+			return i
+		}
+	}
+	return -1
+}
+
+// readQuoted reads a quoted phrase starting at the opening quote.
+// Backslashes escape quotes and backslashes inside the phrase.
+func readQuoted(runes []rune, start int) (string, int, error) {
+	var phrase strings.Builder
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+			}
+			phrase.WriteRune(runes[i])
+		case '"':
+			return phrase.String(), i + 1, nil
+		default:
+			phrase.WriteRune(runes[i])
+		}
+	}
+	return "", -1, &ParseError{Message: "Unterminated quoted string", Column: start + 1}
+}
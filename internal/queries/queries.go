@@ -2,9 +2,11 @@ package queries
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
 type QueryTerm interface {
@@ -63,10 +65,12 @@ type Query struct {
 }
 
 func NewQueryFromString(input string) (*Query, error) {
-	var err error
 	query := Query{QueryType: Cluster, ReturnType: Json}
-	parser := NewParser(input)
-	if query.Terms, err = getTerm(parser); err != nil {
+	parser, err := NewParser(input)
+	if err != nil {
+		return nil, err
+	}
+	if query.Terms, err = parser.Parse(); err != nil {
 		return nil, err
 	}
 
@@ -134,40 +138,85 @@ func (q *Query) UnmarshalJSON(data []byte) error {
 type Expression struct {
 	Category string `json:"category"`
 	Term     string `json:"term"`
+	// Comparison is set for numeric comparisons like [length]>20000,
+	// Term then holds the number to compare to.
+	Comparison string `json:"comparison,omitempty"`
 }
 
+var COMPARISONS = []string{">=", "<=", ">", "<", "="}
+
 func (e *Expression) Query() string {
 	category := ""
 	if e.Category != "unknown" {
 		category = fmt.Sprintf("[%s]", e.Category)
 	}
-	return fmt.Sprintf("%s%s", category, e.Term)
+	term := e.Term
+	if e.Comparison == "" && strings.ContainsFunc(term, func(r rune) bool { return unicode.IsSpace(r) || r == '(' || r == ')' }) {
+		term = strconv.Quote(term)
+	}
+	return fmt.Sprintf("%s%s%s", category, e.Comparison, term)
 }
 
 func (e *Expression) MarshalJSON() ([]byte, error) {
 	return json.Marshal(struct {
-		Type     string `json:"term_type"`
-		Category string `json:"category"`
-		Term     string `json:"term"`
-	}{Type: "expr", Category: e.Category, Term: e.Term})
+		Type       string `json:"term_type"`
+		Category   string `json:"category"`
+		Term       string `json:"term"`
+		Comparison string `json:"comparison,omitempty"`
+	}{Type: "expr", Category: e.Category, Term: e.Term, Comparison: e.Comparison})
 }
 
 func (e *Expression) UnmarshalJSON(data []byte) error {
 	var tmp struct {
-		Category string `json:"category"`
-		Term     string `json:"term"`
+		Category   string `json:"category"`
+		Term       string `json:"term"`
+		Comparison string `json:"comparison"`
 	}
 	err := json.Unmarshal(data, &tmp)
 	if err != nil {
 		return err
 	}
 
+	if tmp.Comparison != "" && !slices.Contains(COMPARISONS, tmp.Comparison) {
+		return fmt.Errorf("Invalid comparison '%s'", tmp.Comparison)
+	}
+
 	e.Category = tmp.Category
 	e.Term = tmp.Term
+	e.Comparison = tmp.Comparison
 
 	return nil
 }
 
+// Negation matches everything not matched by Term.
+// The parser turns "a AND NOT b" into an EXCEPT operation instead.
+type Negation struct {
+	Term QueryTerm `json:"term"`
+}
+
+func (n *Negation) Query() string {
+	return fmt.Sprintf("NOT %s", n.Term.Query())
+}
+
+func (n *Negation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string    `json:"term_type"`
+		Term QueryTerm `json:"term"`
+	}{Type: "not", Term: n.Term})
+}
+
+func (n *Negation) UnmarshalJSON(data []byte) error {
+	var tmp struct {
+		Term json.RawMessage `json:"term"`
+	}
+	err := json.Unmarshal(data, &tmp)
+	if err != nil {
+		return err
+	}
+	n.Term, err = unmarshalTerm(tmp.Term)
+	return err
+}
+
 type Operation struct {
 	Operation OperationType `json:"operation"`
 	Left      QueryTerm     `json:"left"`
@@ -248,11 +297,38 @@ func unmarshalTerm(data json.RawMessage) (QueryTerm, error) {
 			}
 			return &op, nil
 		}
+	case "not":
+		{
+			var neg Negation
+			err := json.Unmarshal(data, &neg)
+			if err != nil {
+				return nil, err
+			}
+			return &neg, nil
+		}
 	}
 
 	return nil, fmt.Errorf("Invalid term_type '%s'", spy.Type)
 }
 
+// TermsInCategory collects the terms of all expressions in a category that a hit has
+// to match, so negated terms and the right side of EXCEPT operations are skipped.
+func TermsInCategory(t QueryTerm, category string) []string {
+	switch v := t.(type) {
+	case *Expression:
+		if v.Category == category && v.Comparison == "" {
+			return []string{v.Term}
+		}
+	case *Operation:
+		terms := TermsInCategory(v.Left, category)
+		if v.Operation != EXCEPT {
+			terms = append(terms, TermsInCategory(v.Right, category)...)
+		}
+		return terms
+	}
+	return nil
+}
+
 type TermSpy struct {
 	Type string `json:"term_type"`
 }
@@ -276,113 +352,3 @@ var STRING_OP_MAP = map[string]OperationType{
 	"or":     OR,
 	"except": EXCEPT,
 }
-
-type Parser struct {
-	tokens   []string
-	keywords map[string]OperationType
-}
-
-func (p *Parser) Peek() string {
-	return p.tokens[0]
-}
-
-func (p *Parser) Consume() string {
-	token := p.tokens[0]
-	p.tokens = p.tokens[1:]
-	return token
-}
-
-func (p *Parser) ConsumeExpected(expected string) bool {
-	token := p.Peek()
-	if token == expected {
-		p.Consume()
-		return true
-	}
-	return false
-}
-
-func NewParser(input string) *Parser {
-	parser := Parser{keywords: STRING_OP_MAP}
-
-	parser.tokens = generateTokens(input)
-
-	return &parser
-}
-
-func getTerm(parser *Parser) (QueryTerm, error) {
-	if len(parser.tokens) < 2 {
-		return nil, errors.New("Unexpected end of expression")
-	}
-
-	left, err := getExpression(parser)
-	if err != nil {
-		return nil, err
-	}
-
-	next_token := parser.Peek()
-	if op, ok := parser.keywords[strings.ToLower(next_token)]; ok {
-		parser.Consume()
-		right, err := getExpression(parser)
-		if err != nil {
-			return nil, err
-		}
-		return &Operation{Operation: op, Left: left, Right: right}, nil
-	}
-	if next_token == "END" || next_token == ")" {
-		return left, nil
-	}
-	// Two expressions without keyword will be ANDed
-
-	right, err := getExpression(parser)
-	if err != nil {
-		return nil, err
-	}
-	return &Operation{Operation: AND, Left: left, Right: right}, nil
-}
-
-func getExpression(parser *Parser) (QueryTerm, error) {
-	if parser.ConsumeExpected("(") {
-		term, err := getTerm(parser)
-		if err != nil {
-			return nil, err
-		}
-		if !parser.ConsumeExpected(")") {
-			return nil, fmt.Errorf("Invalid token %s", parser.Peek())
-		}
-		return term, nil
-	}
-
-	raw_expression := parser.Consume()
-	if _, ok := parser.keywords[strings.ToLower(raw_expression)]; ok {
-		return nil, fmt.Errorf("Invalid use of keyword %s", raw_expression)
-	}
-	if raw_expression == "END" {
-		return nil, fmt.Errorf("Malformatted input")
-	}
-	category := "unknown"
-	term := raw_expression
-
-	if strings.HasPrefix(raw_expression, "[") {
-		end := strings.Index(raw_expression, "]")
-		if end > -1 {
-			category = raw_expression[1:end]
-			term = raw_expression[end+1:]
-		}
-	}
-
-	return &Expression{Term: term, Category: category}, nil
-}
-
-func generateTokens(input string) []string {
-	final_tokens := []string{}
-
-	tokens := strings.Fields(strings.ReplaceAll(strings.ReplaceAll(input, ")", " ) "), "(", " ( "))
-
-	for _, t := range tokens {
-		final_tokens = append(final_tokens, t)
-	}
-
-	final_tokens = append(final_tokens, "END")
-
-	return final_tokens
-}
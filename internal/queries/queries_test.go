@@ -2,6 +2,7 @@ package queries
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/google/go-cmp/cmp"
 	"strings"
 	"testing"
@@ -63,9 +64,84 @@ func TestQueryFromString(t *testing.T) {
 				},
 			},
 		}},
-		{"AND ripp", "Invalid use of keyword AND", nil},
-		{"( ripp", "Invalid token END", nil},
-		{"END", "Malformatted input", nil},
+		{"a AND b OR c", "", &Query{QueryType: Cluster, ReturnType: Json,
+			Terms: &Operation{Operation: OR,
+				Left: &Operation{Operation: AND,
+					Left:  &Expression{Term: "a", Category: "unknown"},
+					Right: &Expression{Term: "b", Category: "unknown"},
+				},
+				Right: &Expression{Term: "c", Category: "unknown"},
+			},
+		}},
+		{"a OR b c", "", &Query{QueryType: Cluster, ReturnType: Json,
+			Terms: &Operation{Operation: OR,
+				Left: &Expression{Term: "a", Category: "unknown"},
+				Right: &Operation{Operation: AND,
+					Left:  &Expression{Term: "b", Category: "unknown"},
+					Right: &Expression{Term: "c", Category: "unknown"},
+				},
+			},
+		}},
+		{"a AND b AND c", "", &Query{QueryType: Cluster, ReturnType: Json,
+			Terms: &Operation{Operation: AND,
+				Left: &Operation{Operation: AND,
+					Left:  &Expression{Term: "a", Category: "unknown"},
+					Right: &Expression{Term: "b", Category: "unknown"},
+				},
+				Right: &Expression{Term: "c", Category: "unknown"},
+			},
+		}},
+		{`[compound]"actinomycin D" AND "Streptomyces coelicolor"`, "", &Query{QueryType: Cluster, ReturnType: Json,
+			Terms: &Operation{Operation: AND,
+				Left:  &Expression{Term: "actinomycin D", Category: "compound"},
+				Right: &Expression{Term: "Streptomyces coelicolor", Category: "unknown"},
+			},
+		}},
+		{`"AND"`, "", &Query{QueryType: Cluster, ReturnType: Json,
+			Terms: &Expression{Term: "AND", Category: "unknown"},
+		}},
+		{"nrps AND NOT streptomyces", "", &Query{QueryType: Cluster, ReturnType: Json,
+			Terms: &Operation{Operation: EXCEPT,
+				Left:  &Expression{Term: "nrps", Category: "unknown"},
+				Right: &Expression{Term: "streptomyces", Category: "unknown"},
+			},
+		}},
+		{"NOT nrps", "", &Query{QueryType: Cluster, ReturnType: Json,
+			Terms: &Negation{Term: &Expression{Term: "nrps", Category: "unknown"}},
+		}},
+		{"NOT NOT nrps", "", &Query{QueryType: Cluster, ReturnType: Json,
+			Terms: &Expression{Term: "nrps", Category: "unknown"},
+		}},
+		{"NOT nrps OR ripp", "", &Query{QueryType: Cluster, ReturnType: Json,
+			Terms: &Operation{Operation: OR,
+				Left:  &Negation{Term: &Expression{Term: "nrps", Category: "unknown"}},
+				Right: &Expression{Term: "ripp", Category: "unknown"},
+			},
+		}},
+		{"[compound]actino*", "", &Query{QueryType: Cluster, ReturnType: Json,
+			Terms: &Expression{Term: "actino*", Category: "compound"},
+		}},
+		{"[length]>20000", "", &Query{QueryType: Cluster, ReturnType: Json,
+			Terms: &Expression{Term: "20000", Category: "length", Comparison: ">"},
+		}},
+		{"[length]10000..20000", "", &Query{QueryType: Cluster, ReturnType: Json,
+			Terms: &Operation{Operation: AND,
+				Left:  &Expression{Term: "10000", Category: "length", Comparison: ">="},
+				Right: &Expression{Term: "20000", Category: "length", Comparison: "<="},
+			},
+		}},
+		{"END", "", &Query{QueryType: Cluster, ReturnType: Json,
+			Terms: &Expression{Term: "END", Category: "unknown"},
+		}},
+		{"AND ripp", "Invalid use of keyword AND at column 1", nil},
+		{"( ripp", "Invalid token END at column 7", nil},
+		{"ripp )", "Invalid token ) at column 6", nil},
+		{"nrps OR", "Unexpected end of expression at column 8", nil},
+		{`nrps "actinomycin D`, "Unterminated quoted string at column 6", nil},
+		{"nrps [type", "Unterminated category at column 6", nil},
+		{">20000", "needs a category", nil},
+		{"[length]>big", "Invalid number big at column 1", nil},
+		{"[length]20..10", "Empty range", nil},
 	}
 
 	for _, tt := range tests {
@@ -85,6 +161,8 @@ func TestQueryFromJson(t *testing.T) {
 		expected Query
 	}{
 		{[]byte(`{"search":"cluster","return_type":"json","terms":{"term_type":"expr","category":"type","term":"nrps"}}`), Query{Terms: &Expression{Category: "type", Term: "nrps"}}},
+		{[]byte(`{"search":"cluster","return_type":"json","terms":{"term_type":"not","term":{"term_type":"expr","category":"length","term":"5000","comparison":"<"}}}`),
+			Query{Terms: &Negation{Term: &Expression{Category: "length", Term: "5000", Comparison: "<"}}}},
 	}
 
 	for _, tt := range queryTests {
@@ -126,16 +204,36 @@ func TestGenerateTokens(t *testing.T) {
 		{"foo", []string{"foo", "END"}},
 		{"(foo)", []string{"(", "foo", ")", "END"}},
 		{"foo (foo) foo", []string{"foo", "(", "foo", ")", "foo", "END"}},
+		{`[compound]"actinomycin D" foo`, []string{`[compound]"actinomycin D"`, "foo", "END"}},
+		{`"say \"hi\""`, []string{`"say \"hi\""`, "END"}},
 	}
 
 	for _, tt := range tokenTests {
-		actual := generateTokens(tt.input)
+		tokens, err := generateTokens(tt.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := make([]string, len(tokens))
+		for i, token := range tokens {
+			actual[i] = token.String()
+		}
 		if !cmp.Equal(actual, tt.expected) {
 			t.Errorf("generateTokens(%s): expected %v, got %v", tt.input, tt.expected, actual)
 		}
 	}
 }
 
+func TestParseErrorColumn(t *testing.T) {
+	_, err := NewQueryFromString("nrps AND (ripp OR")
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("Expected ParseError, got %v", err)
+	}
+	if parseError.Column != 18 {
+		t.Errorf("Expected column %d, got %d", 18, parseError.Column)
+	}
+}
+
 func TestExpressionQuery(t *testing.T) {
 	var queryTests = []struct {
 		expr     Expression
@@ -315,3 +413,27 @@ func ErrorContains(out error, want string) bool {
 	}
 	return strings.Contains(out.Error(), want)
 }
+
+func TestTermsInCategory(t *testing.T) {
+	var termTests = []struct {
+		input    string
+		expected []string
+	}{
+		{"[text]antifungal", []string{"antifungal"}},
+		{"[type]nrps", nil},
+		{"[text]antifungal OR ( [text]siderophore AND [genus]Streptomyces )", []string{"antifungal", "siderophore"}},
+		{"[text]antifungal AND NOT [text]cytotoxic", []string{"antifungal"}},
+		{"NOT [text]cytotoxic", nil},
+	}
+
+	for _, tt := range termTests {
+		query, err := NewQueryFromString(tt.input)
+		if err != nil {
+			t.Fatalf("NewQueryFromString(%s) unexpected error: %s", tt.input, err)
+		}
+		actual := TermsInCategory(query.Terms, "text")
+		if !cmp.Equal(tt.expected, actual) {
+			t.Errorf("TermsInCategory(%s): %s", tt.input, cmp.Diff(tt.expected, actual))
+		}
+	}
+}
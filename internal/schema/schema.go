@@ -0,0 +1,137 @@
+// Package schema validates MIBiG entries against the JSON schema embedded in the binary.
+package schema
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+//go:embed mibig.schema.json
+var rawSchema []byte
+
+const schemaUrl = "mibig.schema.json"
+
+var (
+	compiled    *jsonschema.Schema
+	compileErr  error
+	compileOnce sync.Once
+)
+
+func mibigSchema() (*jsonschema.Schema, error) {
+	compileOnce.Do(func() {
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(rawSchema))
+		if err != nil {
+			compileErr = err
+			return
+		}
+		compiler := jsonschema.NewCompiler()
+		if err = compiler.AddResource(schemaUrl, doc); err != nil {
+			compileErr = err
+			return
+		}
+		compiled, compileErr = compiler.Compile(schemaUrl)
+	})
+	return compiled, compileErr
+}
+
+// FieldError is a single schema violation. Pointer is the JSON pointer of the offending value.
+type FieldError struct {
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) String() string {
+	pointer := e.Pointer
+	if pointer == "" {
+		pointer = "(root)"
+	}
+	return fmt.Sprintf("%s: %s", pointer, e.Message)
+}
+
+// ValidationError lists all schema violations of an entry
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Errors))
+	for _, field := range e.Errors {
+		parts = append(parts, field.String())
+	}
+	return fmt.Sprintf("entry does not validate against the schema: %s", strings.Join(parts, "; "))
+}
+
+// Validate checks a raw MIBiG JSON entry against the schema.
+// Schema violations are returned as *ValidationError, invalid JSON as a plain error.
+func Validate(raw []byte) error {
+	sch, err := mibigSchema()
+	if err != nil {
+		return fmt.Errorf("failed to load MIBiG schema: %w", err)
+	}
+
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	err = sch.Validate(instance)
+	if err == nil {
+		return nil
+	}
+
+	invalid, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return err
+	}
+
+	return &ValidationError{Errors: fieldErrors(invalid)}
+}
+
+var printer = message.NewPrinter(language.English)
+
+// fieldErrors collects the leaves of the error tree, which hold the specific violations.
+// The inner nodes only summarise their children.
+func fieldErrors(err *jsonschema.ValidationError) []FieldError {
+	seen := make(map[FieldError]bool)
+	var errors []FieldError
+
+	var collect func(*jsonschema.ValidationError)
+	collect = func(err *jsonschema.ValidationError) {
+		for _, cause := range err.Causes {
+			collect(cause)
+		}
+		if len(err.Causes) > 0 {
+			return
+		}
+		field := FieldError{Pointer: pointer(err.InstanceLocation), Message: err.ErrorKind.LocalizedString(printer)}
+		if !seen[field] {
+			seen[field] = true
+			errors = append(errors, field)
+		}
+	}
+	collect(err)
+
+	sort.SliceStable(errors, func(i, j int) bool {
+		return errors[i].Pointer < errors[j].Pointer
+	})
+	return errors
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func pointer(location []string) string {
+	var builder strings.Builder
+	for _, token := range location {
+		builder.WriteString("/")
+		builder.WriteString(pointerEscaper.Replace(token))
+	}
+	return builder.String()
+}
@@ -0,0 +1,136 @@
+package schema
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const validEntry = `{
+	"accession": "BGC0000535",
+	"version": 1,
+	"status": "active",
+	"quality": "high",
+	"completeness": "complete",
+	"taxonomy": {"name": "Lactococcus lactis subsp. lactis", "ncbiTaxId": 1360},
+	"loci": [{"accession": "HM219853.1", "location": {"from": 1, "to": 12000}}],
+	"biosynthesis": {"classes": [{"class": "ribosomal"}]},
+	"compounds": [{"name": "nisin A", "synonyms": ["nisin"]}]
+}`
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Raw      string
+		Expected []FieldError
+	}{
+		{Name: "valid", Raw: validEntry},
+		{Name: "wrong types", Raw: `{
+			"accession": "BGC535",
+			"version": "1",
+			"status": "active",
+			"quality": "high",
+			"completeness": "complete",
+			"taxonomy": {"name": "Lactococcus lactis", "ncbiTaxId": 1360},
+			"loci": [{"accession": "HM219853.1", "location": {"from": 1}}],
+			"biosynthesis": {"classes": [{"class": "lanthipeptide"}]},
+			"compounds": []
+		}`, Expected: []FieldError{
+			{Pointer: "/accession", Message: "'BGC535' does not match pattern '^BGC[0-9]{7}$'"},
+			{Pointer: "/biosynthesis/classes/0/class", Message: "value must be one of 'NRPS', 'PKS', 'ribosomal', 'saccharide', 'terpene', 'other'"},
+			{Pointer: "/compounds", Message: "minItems: got 0, want 1"},
+			{Pointer: "/loci/0/location", Message: "missing property 'to'"},
+			{Pointer: "/version", Message: "got string, want integer"},
+		}},
+		{Name: "embargoed without date", Raw: `{
+			"accession": "BGC0000535",
+			"version": 1,
+			"status": "embargoed",
+			"quality": "high",
+			"completeness": "complete",
+			"taxonomy": {"name": "Lactococcus lactis", "ncbiTaxId": 1360},
+			"loci": [{"accession": "HM219853.1", "location": {"from": 1, "to": 12000}}],
+			"biosynthesis": {"classes": [{"class": "ribosomal"}]},
+			"compounds": [{"name": "nisin A"}]
+		}`, Expected: []FieldError{
+			{Pointer: "", Message: "missing property 'embargo_until'"},
+		}},
+		{Name: "sequences", Raw: `{
+			"accession": "BGC0000535",
+			"version": 1,
+			"status": "active",
+			"quality": "high",
+			"completeness": "complete",
+			"taxonomy": {"name": "Lactococcus lactis", "ncbiTaxId": 1360},
+			"loci": [{"accession": "HM219853.1", "location": {"from": 1, "to": 12000}, "sequence": "ATGXX"}],
+			"biosynthesis": {"classes": [{"class": "ribosomal"}]},
+			"compounds": [{"name": "nisin A"}],
+			"genes": {
+				"to_add": [{"id": "orf1", "location": {"exons": [{"from": 1, "to": 300}], "strand": 1}}],
+				"annotations": [{"id": "nisA", "translation": "MSTK-DFNL"}]
+			}
+		}`, Expected: []FieldError{
+			{Pointer: "/genes/annotations/0/translation", Message: `'MSTK-DFNL' does not match pattern '^[ACDEFGHIKLMNPQRSTVWYBZXJUO]+\\*?$'`},
+			{Pointer: "/genes/to_add/0", Message: "missing property 'translation'"},
+			{Pointer: "/loci/0/sequence", Message: "'ATGXX' does not match pattern '^[ACGTURYSWKMBDHVNacgturyswkmbdhvn]+$'"},
+		}},
+		{Name: "unknown property", Raw: `{
+			"accession": "BGC0000535",
+			"version": 1,
+			"status": "active",
+			"quality": "high",
+			"completeness": "complete",
+			"taxonomy": {"name": "Lactococcus lactis", "ncbiTaxId": 1360, "taxid": 1360},
+			"loci": [{"accession": "HM219853.1", "location": {"from": 1, "to": 12000}}],
+			"biosynthesis": {"classes": [{"class": "ribosomal"}]},
+			"compounds": [{"name": "nisin A"}],
+			"compound": [{"name": "nisin A"}]
+		}`, Expected: []FieldError{
+			{Pointer: "", Message: "additional properties 'compound' not allowed"},
+			{Pointer: "/taxonomy", Message: "additional properties 'taxid' not allowed"},
+		}},
+		{Name: "missing", Raw: `{"accession": "BGC0000535"}`, Expected: []FieldError{
+			{Pointer: "", Message: "missing properties 'version', 'status', 'quality', 'completeness', 'taxonomy', 'loci', 'biosynthesis', 'compounds'"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := Validate([]byte(tt.Raw))
+			if tt.Expected == nil {
+				if err != nil {
+					t.Fatalf("Unexpected error %s", err)
+				}
+				return
+			}
+			var invalid *ValidationError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("Expected a ValidationError, got %v", err)
+			}
+			if !cmp.Equal(tt.Expected, invalid.Errors) {
+				t.Errorf("Unexpected errors:\n%s", cmp.Diff(tt.Expected, invalid.Errors))
+			}
+		})
+	}
+}
+
+func TestValidateInvalidJson(t *testing.T) {
+	err := Validate([]byte(`{"accession": `))
+	if err == nil {
+		t.Fatal("Expected an error for invalid JSON")
+	}
+	var invalid *ValidationError
+	if errors.As(err, &invalid) {
+		t.Errorf("Expected a plain error for invalid JSON, got %v", err)
+	}
+}
+
+func TestFieldErrorString(t *testing.T) {
+	if actual := (FieldError{Pointer: "/loci/0", Message: "oops"}).String(); actual != "/loci/0: oops" {
+		t.Errorf("Unexpected string %q", actual)
+	}
+	if actual := (FieldError{Message: "oops"}).String(); actual != "(root): oops" {
+		t.Errorf("Unexpected string %q", actual)
+	}
+}
@@ -0,0 +1,163 @@
+package seqsearch
+
+import "math"
+
+// Gap costs and Karlin-Altschul parameters for BLOSUM62, as used by BLAST.
+// Like in BLAST, a gap of length k costs gapOpen + k*gapExtend, the parameters assume that.
+const (
+	gapOpen   = 11
+	gapExtend = 1
+	lambda    = 0.267
+	kappa     = 0.041
+)
+
+const blosumAlphabet = "ARNDCQEGHILKMFPSTWYVBZX*"
+
+var blosum62 = [24][24]int32{
+	{4, -1, -2, -2, 0, -1, -1, 0, -2, -1, -1, -1, -1, -2, -1, 1, 0, -3, -2, 0, -2, -1, 0, -4},
+	{-1, 5, 0, -2, -3, 1, 0, -2, 0, -3, -2, 2, -1, -3, -2, -1, -1, -3, -2, -3, -1, 0, -1, -4},
+	{-2, 0, 6, 1, -3, 0, 0, 0, 1, -3, -3, 0, -2, -3, -2, 1, 0, -4, -2, -3, 3, 0, -1, -4},
+	{-2, -2, 1, 6, -3, 0, 2, -1, -1, -3, -4, -1, -3, -3, -1, 0, -1, -4, -3, -3, 4, 1, -1, -4},
+	{0, -3, -3, -3, 9, -3, -4, -3, -3, -1, -1, -3, -1, -2, -3, -1, -1, -2, -2, -1, -3, -3, -2, -4},
+	{-1, 1, 0, 0, -3, 5, 2, -2, 0, -3, -2, 1, 0, -3, -1, 0, -1, -2, -1, -2, 0, 3, -1, -4},
+	{-1, 0, 0, 2, -4, 2, 5, -2, 0, -3, -3, 1, -2, -3, -1, 0, -1, -3, -2, -2, 1, 4, -1, -4},
+	{0, -2, 0, -1, -3, -2, -2, 6, -2, -4, -4, -2, -3, -3, -2, 0, -2, -2, -3, -3, -1, -2, -1, -4},
+	{-2, 0, 1, -1, -3, 0, 0, -2, 8, -3, -3, -1, -2, -1, -2, -1, -2, -2, 2, -3, 0, 0, -1, -4},
+	{-1, -3, -3, -3, -1, -3, -3, -4, -3, 4, 2, -3, 1, 0, -3, -2, -1, -3, -1, 3, -3, -3, -1, -4},
+	{-1, -2, -3, -4, -1, -2, -3, -4, -3, 2, 4, -2, 2, 0, -3, -2, -1, -2, -1, 1, -4, -3, -1, -4},
+	{-1, 2, 0, -1, -3, 1, 1, -2, -1, -3, -2, 5, -1, -3, -1, 0, -1, -3, -2, -2, 0, 1, -1, -4},
+	{-1, -1, -2, -3, -1, 0, -2, -3, -2, 1, 2, -1, 5, 0, -2, -1, -1, -1, -1, 1, -3, -1, -1, -4},
+	{-2, -3, -3, -3, -2, -3, -3, -3, -1, 0, 0, -3, 0, 6, -4, -2, -2, 1, 3, -1, -3, -3, -1, -4},
+	{-1, -2, -2, -1, -3, -1, -1, -2, -2, -3, -3, -1, -2, -4, 7, -1, -1, -4, -3, -2, -2, -1, -2, -4},
+	{1, -1, 1, 0, -1, 0, 0, 0, -1, -2, -2, 0, -1, -2, -1, 4, 1, -3, -2, -2, 0, 0, 0, -4},
+	{0, -1, 0, -1, -1, -1, -1, -2, -2, -1, -1, -1, -1, -2, -1, 1, 5, -2, -2, 0, -1, -1, 0, -4},
+	{-3, -3, -4, -4, -2, -2, -3, -2, -2, -3, -2, -3, -1, 1, -4, -3, -2, 11, 2, -3, -4, -3, -2, -4},
+	{-2, -2, -2, -3, -2, -1, -2, -3, 2, -1, -1, -2, -1, 3, -3, -2, -2, 2, 7, -1, -3, -2, -1, -4},
+	{0, -3, -3, -3, -1, -2, -2, -3, -3, 3, 1, -2, 1, -1, -2, -2, 0, -3, -1, 4, -3, -2, -1, -4},
+	{-2, -1, 3, 4, -3, 0, 1, -1, 0, -3, -4, 0, -3, -3, -2, 0, -1, -4, -3, -3, 4, 1, -1, -4},
+	{-1, 0, 0, 1, -3, 3, 4, -2, 0, -3, -3, 1, -1, -3, -1, 0, -1, -3, -2, -2, 1, 4, -1, -4},
+	{0, -1, -1, -1, -2, -1, -1, -1, -1, -1, -1, -1, -1, -1, -2, 0, 0, -2, -1, -1, -1, -1, -1, -4},
+	{-4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, 1},
+}
+
+// blosumIndex maps residue letters to rows of the scoring matrix, unknown letters score like X
+var blosumIndex = func() [256]uint8 {
+	var index [256]uint8
+	for i := range index {
+		index[i] = uint8(len(blosumAlphabet) - 2)
+	}
+	for i := range len(blosumAlphabet) {
+		index[blosumAlphabet[i]] = uint8(i)
+	}
+	return index
+}()
+
+// alignment is the best local alignment of a query against a target.
+// Start and end positions are 0-based and exclusive at the end.
+type alignment struct {
+	Score       int32
+	QueryStart  int
+	QueryEnd    int
+	TargetStart int
+	TargetEnd   int
+	Identities  int
+	Length      int
+}
+
+// cell is a dynamic programming cell, carrying the start and stats of the path leading to it
+type cell struct {
+	score          int32
+	qStart, tStart int32
+	ident, length  int32
+}
+
+const negInf int32 = -1 << 29
+
+// align runs a Smith-Waterman alignment with affine gaps in linear memory.
+// Instead of a traceback, every cell carries along where its path started and
+// how many columns and identities it has.
+func align(query, target []byte) alignment {
+	n := len(target)
+	prevH := make([]cell, n+1)
+	curH := make([]cell, n+1)
+	f := make([]cell, n+1)
+	for j := range f {
+		f[j].score = negInf
+	}
+
+	var best cell
+	bestQ, bestT := 0, 0
+	for i := 1; i <= len(query); i++ {
+		row := &blosum62[blosumIndex[query[i-1]]]
+		curH[0] = cell{}
+		e := cell{score: negInf}
+		for j := 1; j <= n; j++ {
+			// gap in the query
+			if left := curH[j-1]; left.score-gapOpen-gapExtend > e.score-gapExtend {
+				e = left
+				e.score -= gapOpen + gapExtend
+			} else {
+				e.score -= gapExtend
+			}
+			e.length++
+
+			// gap in the target
+			if up := prevH[j]; up.score-gapOpen-gapExtend > f[j].score-gapExtend {
+				f[j] = up
+				f[j].score -= gapOpen + gapExtend
+			} else {
+				f[j].score -= gapExtend
+			}
+			f[j].length++
+
+			h := prevH[j-1]
+			if h.score == 0 {
+				h = cell{qStart: int32(i - 1), tStart: int32(j - 1)}
+			}
+			h.score += row[blosumIndex[target[j-1]]]
+			h.length++
+			if query[i-1] == target[j-1] {
+				h.ident++
+			}
+			if e.score > h.score {
+				h = e
+			}
+			if f[j].score > h.score {
+				h = f[j]
+			}
+			if h.score <= 0 {
+				h = cell{}
+			}
+			curH[j] = h
+
+			if h.score > best.score {
+				best = h
+				bestQ, bestT = i, j
+			}
+		}
+		prevH, curH = curH, prevH
+	}
+
+	if best.score == 0 {
+		return alignment{}
+	}
+	return alignment{
+		Score:       best.score,
+		QueryStart:  int(best.qStart),
+		QueryEnd:    bestQ,
+		TargetStart: int(best.tStart),
+		TargetEnd:   bestT,
+		Identities:  int(best.ident),
+		Length:      int(best.length),
+	}
+}
+
+// bitscore normalises a raw alignment score
+func bitscore(score int32) float64 {
+	return (lambda*float64(score) - math.Log(kappa)) / math.Ln2
+}
+
+// evalue is the number of hits with at least this bitscore expected by chance
+func evalue(bits float64, queryLength, databaseLength int) float64 {
+	return float64(queryLength) * float64(databaseLength) * math.Pow(2, -bits)
+}
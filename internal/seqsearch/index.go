@@ -0,0 +1,229 @@
+// Package seqsearch finds homologs of protein and nucleotide sequences among the
+// genes of all MIBiG entries. Candidate genes are found through shared k-mers and
+// then aligned to the query with a Smith-Waterman local alignment.
+package seqsearch
+
+import (
+	"encoding/gob"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+const (
+	// length of the words used to seed alignments
+	wordSize = 4
+	// amino acids that k-mers are built of, anything else breaks a k-mer
+	wordAlphabet = "ARNDCQEGHILKMFPSTWYV"
+	// number of possible k-mers
+	wordCount = 20 * 20 * 20 * 20
+	// separates targets in the concatenated residues, it is not part of any k-mer
+	separator = '#'
+)
+
+var wordIndex = func() [256]int8 {
+	var index [256]int8
+	for i := range index {
+		index[i] = -1
+	}
+	for i := range len(wordAlphabet) {
+		index[wordAlphabet[i]] = int8(i)
+	}
+	return index
+}()
+
+// Target is an indexed gene
+type Target struct {
+	EntryId  string
+	LocusTag string
+	Product  string
+	Sequence string
+}
+
+// TargetsFromFasta turns the protein records of the entry model into targets
+func TargetsFromFasta(records []data.FastaRecord) []Target {
+	targets := make([]Target, 0, len(records))
+	for _, record := range records {
+		entryId, locusTag, _ := strings.Cut(record.Id, "|")
+		targets = append(targets, Target{
+			EntryId:  entryId,
+			LocusTag: locusTag,
+			Product:  record.Description,
+			Sequence: strings.ToUpper(record.Sequence),
+		})
+	}
+	return targets
+}
+
+// Index is a k-mer index of the target proteins.
+// The k-mer positions point into the concatenation of all target sequences,
+// stored compactly as offsets into a single postings list per k-mer.
+type Index struct {
+	Targets  []Target
+	residues []byte
+	starts   []uint32
+	offsets  []uint32
+	postings []uint32
+}
+
+func NewIndex(targets []Target) *Index {
+	index := Index{Targets: targets}
+	index.concatenate()
+
+	index.offsets = make([]uint32, wordCount+1)
+	index.eachWord(func(code int, pos uint32) {
+		index.offsets[code+1]++
+	})
+	for i := 1; i < len(index.offsets); i++ {
+		index.offsets[i] += index.offsets[i-1]
+	}
+
+	index.postings = make([]uint32, index.offsets[wordCount])
+	fill := make([]uint32, wordCount)
+	copy(fill, index.offsets[:wordCount])
+	index.eachWord(func(code int, pos uint32) {
+		index.postings[fill[code]] = pos
+		fill[code]++
+	})
+
+	return &index
+}
+
+func (index *Index) concatenate() {
+	size := 0
+	for _, target := range index.Targets {
+		size += len(target.Sequence) + 1
+	}
+	index.residues = make([]byte, 0, size)
+	index.starts = make([]uint32, 0, len(index.Targets))
+	for _, target := range index.Targets {
+		index.starts = append(index.starts, uint32(len(index.residues)))
+		index.residues = append(index.residues, target.Sequence...)
+		index.residues = append(index.residues, separator)
+	}
+}
+
+// eachWord calls fn for every k-mer of the target residues, in order
+func (index *Index) eachWord(fn func(code int, pos uint32)) {
+	eachWord(index.residues, func(code, pos int) {
+		fn(code, uint32(pos))
+	})
+}
+
+// eachWord calls fn with the code and start of every k-mer made of standard amino acids
+func eachWord(residues []byte, fn func(code, pos int)) {
+	code, valid := 0, 0
+	for i, residue := range residues {
+		letter := wordIndex[residue]
+		if letter < 0 {
+			valid = 0
+			continue
+		}
+		code = (code*20 + int(letter)) % wordCount
+		valid++
+		if valid >= wordSize {
+			fn(code, i-wordSize+1)
+		}
+	}
+}
+
+// targetAt finds the target a position in the concatenated residues belongs to
+func (index *Index) targetAt(pos uint32) int {
+	return sort.Search(len(index.starts), func(i int) bool { return index.starts[i] > pos }) - 1
+}
+
+// Residues is the total length of all targets
+func (index *Index) Residues() int {
+	return len(index.residues) - len(index.Targets)
+}
+
+// indexFile is the stored form of an index
+type indexFile struct {
+	Targets  []Target
+	Offsets  []uint32
+	Postings []uint32
+}
+
+// WriteFile stores the index. The file is replaced atomically, so a server
+// can keep reading the old index while a new one is written.
+func (index *Index) WriteFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	stored := indexFile{Targets: index.Targets, Offsets: index.offsets, Postings: index.postings}
+	if err = gob.NewEncoder(tmp).Encode(&stored); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func ReadIndexFile(path string) (*Index, error) {
+	handle, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer handle.Close()
+
+	var stored indexFile
+	if err = gob.NewDecoder(handle).Decode(&stored); err != nil {
+		return nil, err
+	}
+	if len(stored.Offsets) != wordCount+1 || int(stored.Offsets[wordCount]) != len(stored.Postings) {
+		return nil, errors.New("corrupt sequence index")
+	}
+
+	index := Index{Targets: stored.Targets, offsets: stored.Offsets, postings: stored.Postings}
+	index.concatenate()
+	return &index, nil
+}
+
+// IndexFile loads the index stored at a path, and reloads it when the file changes
+type IndexFile struct {
+	path    string
+	mu      sync.Mutex
+	index   *Index
+	modTime time.Time
+}
+
+func NewIndexFile(path string) *IndexFile {
+	return &IndexFile{path: path}
+}
+
+// Get returns the current index, or data.ErrNoSequenceIndex if none was built yet
+func (f *IndexFile) Get() (*Index, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, data.ErrNoSequenceIndex
+		}
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.index != nil && info.ModTime().Equal(f.modTime) {
+		return f.index, nil
+	}
+
+	index, err := ReadIndexFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+	f.index = index
+	f.modTime = info.ModTime()
+	return index, nil
+}
@@ -0,0 +1,162 @@
+package seqsearch
+
+import (
+	"math"
+	"sort"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+const (
+	// at most this many targets per query sequence are aligned
+	maxCandidates = 100
+	// targets need this many k-mers on one diagonal with the query to be aligned
+	minSeeds = 2
+	// k-mers this common are low complexity and make poor seeds
+	maxPostings = 20000
+)
+
+type Options struct {
+	MaxHits     int
+	MaxEvalue   float64
+	MinIdentity float64
+	MinCoverage float64
+}
+
+func DefaultOptions() Options {
+	return Options{MaxHits: 50, MaxEvalue: 1e-5}
+}
+
+// Search finds the targets similar to a protein or nucleotide sequence.
+// Nucleotide sequences are searched in all six reading frames, keeping the
+// best frame per target.
+func (index *Index) Search(record data.FastaRecord, opts Options) data.SequenceQueryResult {
+	result := data.SequenceQueryResult{
+		Query:       record.Id,
+		Description: record.Description,
+		Type:        "protein",
+		Length:      len(record.Sequence),
+		Hits:        []data.SequenceHit{},
+	}
+
+	best := map[int]data.SequenceHit{}
+	keep := func(target int, hit data.SequenceHit) {
+		if hit.Evalue > opts.MaxEvalue || hit.Identity < opts.MinIdentity || hit.Coverage < opts.MinCoverage {
+			return
+		}
+		if existing, ok := best[target]; !ok || hit.Bitscore > existing.Bitscore {
+			best[target] = hit
+		}
+	}
+
+	if IsNucleotide(record.Sequence) {
+		result.Type = "nucleotide"
+		for _, frame := range sixFrames(record.Sequence) {
+			for target, aln := range index.searchProtein(frame.Protein) {
+				hit := index.hit(target, aln, len(frame.Protein))
+				hit.Frame = frame.Frame
+				hit.QueryStart, hit.QueryEnd = nucleotideRange(frame.Frame, aln.QueryStart, aln.QueryEnd, len(record.Sequence))
+				hit.Coverage = percent(hit.QueryEnd-hit.QueryStart+1, len(record.Sequence))
+				keep(target, hit)
+			}
+		}
+	} else {
+		for target, aln := range index.searchProtein(record.Sequence) {
+			hit := index.hit(target, aln, len(record.Sequence))
+			hit.QueryStart, hit.QueryEnd = aln.QueryStart+1, aln.QueryEnd
+			hit.Coverage = percent(aln.QueryEnd-aln.QueryStart, len(record.Sequence))
+			keep(target, hit)
+		}
+	}
+
+	for _, hit := range best {
+		result.Hits = append(result.Hits, hit)
+	}
+	sort.Slice(result.Hits, func(i, j int) bool {
+		if result.Hits[i].Bitscore != result.Hits[j].Bitscore {
+			return result.Hits[i].Bitscore > result.Hits[j].Bitscore
+		}
+		if result.Hits[i].Accession != result.Hits[j].Accession {
+			return result.Hits[i].Accession < result.Hits[j].Accession
+		}
+		return result.Hits[i].LocusTag < result.Hits[j].LocusTag
+	})
+	if opts.MaxHits > 0 && len(result.Hits) > opts.MaxHits {
+		result.Hits = result.Hits[:opts.MaxHits]
+	}
+	return result
+}
+
+// searchProtein seeds alignments with shared k-mers and aligns the query to the
+// targets with the most seeds on a single diagonal
+func (index *Index) searchProtein(query string) map[int]alignment {
+	type diagonal struct {
+		target int
+		offset int
+	}
+	diagonals := map[diagonal]int{}
+	eachWord([]byte(query), func(code, pos int) {
+		postings := index.postings[index.offsets[code]:index.offsets[code+1]]
+		if len(postings) > maxPostings {
+			return
+		}
+		for _, posting := range postings {
+			target := index.targetAt(posting)
+			diagonals[diagonal{target, int(posting-index.starts[target]) - pos}]++
+		}
+	})
+
+	seeds := map[int]int{}
+	for d, count := range diagonals {
+		if count >= minSeeds && count > seeds[d.target] {
+			seeds[d.target] = count
+		}
+	}
+
+	candidates := make([]int, 0, len(seeds))
+	for target := range seeds {
+		candidates = append(candidates, target)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if seeds[candidates[i]] != seeds[candidates[j]] {
+			return seeds[candidates[i]] > seeds[candidates[j]]
+		}
+		return candidates[i] < candidates[j]
+	})
+	if len(candidates) > maxCandidates {
+		candidates = candidates[:maxCandidates]
+	}
+
+	alignments := make(map[int]alignment, len(candidates))
+	for _, target := range candidates {
+		aln := align([]byte(query), []byte(index.Targets[target].Sequence))
+		if aln.Score > 0 {
+			alignments[target] = aln
+		}
+	}
+	return alignments
+}
+
+func (index *Index) hit(target int, aln alignment, queryLength int) data.SequenceHit {
+	t := index.Targets[target]
+	bits := bitscore(aln.Score)
+	return data.SequenceHit{
+		Accession:      t.EntryId,
+		LocusTag:       t.LocusTag,
+		Product:        t.Product,
+		Identity:       percent(aln.Identities, aln.Length),
+		TargetCoverage: percent(aln.TargetEnd-aln.TargetStart, len(t.Sequence)),
+		Bitscore:       math.Round(bits*10) / 10,
+		Evalue:         evalue(bits, queryLength, index.Residues()),
+		TargetStart:    aln.TargetStart + 1,
+		TargetEnd:      aln.TargetEnd,
+	}
+}
+
+// percent rounds to one decimal
+func percent(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(1000*float64(part)/float64(whole)) / 10
+}
@@ -0,0 +1,235 @@
+package seqsearch
+
+import (
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+func randomProtein(rng *rand.Rand, length int) string {
+	protein := make([]byte, length)
+	for i := range protein {
+		protein[i] = wordAlphabet[rng.Intn(len(wordAlphabet))]
+	}
+	return string(protein)
+}
+
+// mutate changes every nth residue
+func mutate(protein string, n int) string {
+	mutated := []byte(protein)
+	for i := 0; i < len(mutated); i += n {
+		mutated[i] = wordAlphabet[(strings.IndexByte(wordAlphabet, mutated[i])+1)%len(wordAlphabet)]
+	}
+	return string(mutated)
+}
+
+func backTranslate(protein string) string {
+	codons := map[byte]string{}
+	bases := "TCAG"
+	for i := range len(geneticCode) {
+		if _, ok := codons[geneticCode[i]]; !ok {
+			codons[geneticCode[i]] = string([]byte{bases[i/16], bases[i/4%4], bases[i%4]})
+		}
+	}
+	var dna strings.Builder
+	for i := range len(protein) {
+		dna.WriteString(codons[protein[i]])
+	}
+	return dna.String()
+}
+
+func testTargets() []Target {
+	rng := rand.New(rand.NewSource(42))
+	targets := []Target{}
+	for i, locus := range []string{"geneA", "geneB", "geneC", "geneD"} {
+		targets = append(targets, Target{
+			EntryId:  []string{"BGC0000001.1", "BGC0000001.1", "BGC0000002.1", "BGC0000003.1"}[i],
+			LocusTag: locus,
+			Sequence: randomProtein(rng, 300+50*i),
+		})
+	}
+	return targets
+}
+
+func TestBlosumSymmetric(t *testing.T) {
+	for i := range blosum62 {
+		for j := range blosum62[i] {
+			if blosum62[i][j] != blosum62[j][i] {
+				t.Errorf("BLOSUM62 not symmetric for %c/%c", blosumAlphabet[i], blosumAlphabet[j])
+			}
+		}
+	}
+}
+
+func TestAlign(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Query    string
+		Target   string
+		Expected alignment
+	}{
+		{"identical", "MKVLAAGIW", "MKVLAAGIW", alignment{Score: 47, QueryEnd: 9, TargetEnd: 9, Identities: 9, Length: 9}},
+		{"local", "WWMKVLAAGIW", "PPPMKVLAAGIWPPP", alignment{Score: 47, QueryStart: 2, QueryEnd: 11, TargetStart: 3, TargetEnd: 12, Identities: 9, Length: 9}},
+		{"unrelated", "WWWW", "PPPP", alignment{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			actual := align([]byte(tt.Query), []byte(tt.Target))
+			if diff := cmp.Diff(tt.Expected, actual); diff != "" {
+				t.Errorf("unexpected alignment (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAlignGap(t *testing.T) {
+	target := randomProtein(rand.New(rand.NewSource(1)), 120)
+	query := target[:60] + target[70:]
+
+	aln := align([]byte(query), []byte(target))
+	if aln.QueryStart != 0 || aln.QueryEnd != len(query) || aln.TargetStart != 0 || aln.TargetEnd != len(target) {
+		t.Errorf("expected the full sequences to align, got %+v", aln)
+	}
+	if aln.Identities != len(query) || aln.Length != len(target) {
+		t.Errorf("expected %d identities in %d columns, got %d in %d", len(query), len(target), aln.Identities, aln.Length)
+	}
+}
+
+func TestAlignGapCost(t *testing.T) {
+	target := randomProtein(rand.New(rand.NewSource(2)), 81)
+	query := target[:40] + target[41:]
+
+	// a single-residue gap costs open plus one extension, like in BLAST
+	expected := align([]byte(target[:40]), []byte(target[:40])).Score +
+		align([]byte(target[41:]), []byte(target[41:])).Score - (gapOpen + gapExtend)
+	if aln := align([]byte(query), []byte(target)); aln.Score != expected {
+		t.Errorf("expected score %d, got %d", expected, aln.Score)
+	}
+}
+
+func TestParseFasta(t *testing.T) {
+	input := `>first some protein
+MKV LAA
+gi w*
+>second
+ACGT-ACGT
+`
+	expected := []data.FastaRecord{
+		{Id: "first", Description: "some protein", Sequence: "MKVLAAGIW*"},
+		{Id: "second", Sequence: "ACGTACGT"},
+	}
+	records, err := ParseFasta(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff := cmp.Diff(expected, records); diff != "" {
+		t.Errorf("unexpected records (-want +got):\n%s", diff)
+	}
+
+	records, err = ParseFasta(strings.NewReader("MKVLAAGIW\n"))
+	if err != nil || len(records) != 1 || records[0].Id != "query" {
+		t.Errorf("expected a bare sequence to be read as query, got %v, %v", records, err)
+	}
+
+	for _, invalid := range []string{">empty\n>second\nMKV\n", ">bad\nMKV%\n"} {
+		if _, err = ParseFasta(strings.NewReader(invalid)); err == nil {
+			t.Errorf("expected an error parsing %q", invalid)
+		}
+	}
+}
+
+func TestIsNucleotide(t *testing.T) {
+	tests := map[string]bool{
+		"ACGTACGTNNACGT": true,
+		"ACGUACGU":       true,
+		"MKVLAAGIW":      false,
+		"ACDEFGHIK":      false,
+	}
+	for sequence, expected := range tests {
+		if actual := IsNucleotide(sequence); actual != expected {
+			t.Errorf("IsNucleotide(%s): expected %v, got %v", sequence, expected, actual)
+		}
+	}
+}
+
+func TestSixFrames(t *testing.T) {
+	dna := "ATGAAAGTTTGA"
+	frames := sixFrames(dna)
+	if len(frames) != 6 {
+		t.Fatalf("expected 6 frames, got %d", len(frames))
+	}
+	if frames[0].Frame != 1 || frames[0].Protein != "MKV*" {
+		t.Errorf("unexpected frame +1: %+v", frames[0])
+	}
+	if frames[3].Frame != -1 || frames[3].Protein != "SNFH" {
+		t.Errorf("unexpected frame -1: %+v", frames[3])
+	}
+
+	// the reverse frame residues 1-2 ("NF") are the bases 4-9 of the forward strand
+	start, end := nucleotideRange(-1, 1, 3, len(dna))
+	if start != 4 || end != 9 {
+		t.Errorf("expected 4-9, got %d-%d", start, end)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	targets := testTargets()
+	index := NewIndex(targets)
+
+	query := data.FastaRecord{Id: "homolog", Sequence: mutate(targets[2].Sequence[50:250], 5)}
+	result := index.Search(query, DefaultOptions())
+	if result.Type != "protein" || len(result.Hits) != 1 {
+		t.Fatalf("expected a single protein hit, got %+v", result)
+	}
+	hit := result.Hits[0]
+	if hit.Accession != "BGC0000002.1" || hit.LocusTag != "geneC" {
+		t.Errorf("unexpected hit %s %s", hit.Accession, hit.LocusTag)
+	}
+	// the first residue is mutated, so the alignment starts at the second one
+	if hit.Identity != 80.4 || hit.Coverage != 99.5 || hit.TargetStart != 52 || hit.TargetEnd != 250 {
+		t.Errorf("unexpected hit stats %+v", hit)
+	}
+
+	dna := reverseComplement("CC" + backTranslate(targets[3].Sequence[100:200]))
+	result = index.Search(data.FastaRecord{Id: "dna", Sequence: dna}, DefaultOptions())
+	if result.Type != "nucleotide" || len(result.Hits) != 1 {
+		t.Fatalf("expected a single nucleotide hit, got %+v", result)
+	}
+	hit = result.Hits[0]
+	if hit.LocusTag != "geneD" || hit.Frame != -3 || hit.QueryStart != 1 || hit.QueryEnd != 300 || hit.Identity != 100 {
+		t.Errorf("unexpected nucleotide hit %+v", hit)
+	}
+
+	result = index.Search(data.FastaRecord{Id: "unrelated", Sequence: randomProtein(rand.New(rand.NewSource(7)), 200)}, DefaultOptions())
+	if len(result.Hits) != 0 {
+		t.Errorf("expected no hits for an unrelated protein, got %+v", result.Hits)
+	}
+}
+
+func TestIndexFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sequences.idx")
+	file := NewIndexFile(path)
+	if _, err := file.Get(); err != data.ErrNoSequenceIndex {
+		t.Fatalf("expected ErrNoSequenceIndex, got %v", err)
+	}
+
+	index := NewIndex(testTargets())
+	if err := index.WriteFile(path); err != nil {
+		t.Fatalf("unexpected error writing the index: %s", err)
+	}
+
+	loaded, err := file.Get()
+	if err != nil {
+		t.Fatalf("unexpected error loading the index: %s", err)
+	}
+	query := data.FastaRecord{Id: "q", Sequence: index.Targets[1].Sequence[:80]}
+	if diff := cmp.Diff(index.Search(query, DefaultOptions()), loaded.Search(query, DefaultOptions())); diff != "" {
+		t.Errorf("loaded index gives different results (-want +got):\n%s", diff)
+	}
+}
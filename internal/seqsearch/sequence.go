@@ -0,0 +1,172 @@
+package seqsearch
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+// ParseFasta reads FASTA records. A bare sequence without a header line is read as a
+// single record called "query". Whitespace, digits and gaps in sequences are ignored.
+func ParseFasta(r io.Reader) ([]data.FastaRecord, error) {
+	var (
+		records  []data.FastaRecord
+		current  *data.FastaRecord
+		sequence strings.Builder
+	)
+
+	finish := func() {
+		if current != nil {
+			current.Sequence = strings.ToUpper(sequence.String())
+			records = append(records, *current)
+		}
+		sequence.Reset()
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, ">") {
+			finish()
+			id, description, _ := strings.Cut(strings.TrimSpace(line[1:]), " ")
+			if id == "" {
+				id = fmt.Sprintf("query_%d", len(records)+1)
+			}
+			current = &data.FastaRecord{Id: id, Description: strings.TrimSpace(description)}
+			continue
+		}
+		if current == nil {
+			current = &data.FastaRecord{Id: "query"}
+		}
+		for _, r := range line {
+			switch {
+			case unicode.IsSpace(r) || unicode.IsDigit(r) || r == '-':
+				continue
+			case r == '*' || (r < unicode.MaxASCII && unicode.IsLetter(r)):
+				sequence.WriteRune(r)
+			default:
+				return nil, fmt.Errorf("line %d: invalid sequence character %q", lineNumber, r)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	finish()
+
+	for _, record := range records {
+		if record.Sequence == "" {
+			return nil, fmt.Errorf("record %s has no sequence", record.Id)
+		}
+	}
+	return records, nil
+}
+
+// IsNucleotide guesses if a sequence is DNA or RNA rather than protein
+func IsNucleotide(sequence string) bool {
+	nucleotides := 0
+	for _, r := range sequence {
+		switch r {
+		case 'A', 'C', 'G', 'T', 'U', 'N':
+			nucleotides++
+		default:
+			if !strings.ContainsRune("RYKMSWBDHV", r) {
+				return false
+			}
+		}
+	}
+	return nucleotides > 0 && nucleotides*10 >= len(sequence)*9
+}
+
+// codons of the standard genetic code, in TCAG order
+const geneticCode = "FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG"
+
+func baseIndex(b byte) int {
+	switch b {
+	case 'T', 'U':
+		return 0
+	case 'C':
+		return 1
+	case 'A':
+		return 2
+	case 'G':
+		return 3
+	}
+	return -1
+}
+
+// translate translates a nucleotide sequence from its first base, codons with
+// ambiguous bases become X
+func translate(sequence string) string {
+	protein := make([]byte, 0, len(sequence)/3)
+	for i := 0; i+3 <= len(sequence); i += 3 {
+		first, second, third := baseIndex(sequence[i]), baseIndex(sequence[i+1]), baseIndex(sequence[i+2])
+		if first < 0 || second < 0 || third < 0 {
+			protein = append(protein, 'X')
+			continue
+		}
+		protein = append(protein, geneticCode[first*16+second*4+third])
+	}
+	return string(protein)
+}
+
+var complements = strings.NewReplacer(
+	"A", "T", "T", "A", "U", "A", "G", "C", "C", "G",
+	"R", "Y", "Y", "R", "K", "M", "M", "K", "B", "V", "V", "B", "D", "H", "H", "D",
+)
+
+func reverseComplement(sequence string) string {
+	complement := []byte(complements.Replace(sequence))
+	for i, j := 0, len(complement)-1; i < j; i, j = i+1, j-1 {
+		complement[i], complement[j] = complement[j], complement[i]
+	}
+	return string(complement)
+}
+
+// frameTranslation is the translation of a nucleotide sequence in one reading frame
+type frameTranslation struct {
+	Frame   int
+	Protein string
+}
+
+// sixFrames translates a nucleotide sequence in all six reading frames, +1 to +3 and -1 to -3
+func sixFrames(sequence string) []frameTranslation {
+	reverse := reverseComplement(sequence)
+	frames := make([]frameTranslation, 0, 6)
+	for offset := range 3 {
+		if offset < len(sequence) {
+			frames = append(frames, frameTranslation{Frame: offset + 1, Protein: translate(sequence[offset:])})
+		}
+	}
+	for offset := range 3 {
+		if offset < len(reverse) {
+			frames = append(frames, frameTranslation{Frame: -(offset + 1), Protein: translate(reverse[offset:])})
+		}
+	}
+	return frames
+}
+
+// nucleotideRange maps a 0-based, end-exclusive range of a frame translation back to
+// 1-based, inclusive coordinates on the forward strand of the nucleotide sequence
+func nucleotideRange(frame, start, end, length int) (int, int) {
+	offset := frame - 1
+	if frame < 0 {
+		offset = -frame - 1
+	}
+	first := offset + 3*start
+	last := offset + 3*end - 1
+	if frame < 0 {
+		first, last = length-1-last, length-1-first
+	}
+	return first + 1, last + 1
+}
@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ProjectJson returns a JSON document containing only the given dot-separated field paths.
+// Paths descend into arrays, so "compounds.name" keeps the name of every compound.
+// Paths not present in the document are ignored.
+func ProjectJson(raw []byte, fields []string) ([]byte, error) {
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+
+	var result any
+	for _, field := range fields {
+		if field == "" {
+			continue
+		}
+		if projected, ok := project(doc, strings.Split(field, ".")); ok {
+			result = mergeProjections(result, projected)
+		}
+	}
+
+	if result == nil {
+		result = map[string]any{}
+	}
+	return json.Marshal(result)
+}
+
+func project(value any, path []string) (any, bool) {
+	if len(path) == 0 {
+		return value, true
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		child, ok := v[path[0]]
+		if !ok {
+			return nil, false
+		}
+		projected, ok := project(child, path[1:])
+		if !ok {
+			return nil, false
+		}
+		return map[string]any{path[0]: projected}, true
+	case []any:
+		// Keep one element per original element so projections of the same array line up
+		result := make([]any, 0, len(v))
+		for _, item := range v {
+			projected, ok := project(item, path)
+			if !ok {
+				projected = map[string]any{}
+			}
+			result = append(result, projected)
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+func mergeProjections(a, b any) any {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			return b
+		}
+		merged := make(map[string]any, len(av)+len(bv))
+		for key, value := range av {
+			merged[key] = value
+		}
+		for key, value := range bv {
+			if existing, ok := merged[key]; ok {
+				merged[key] = mergeProjections(existing, value)
+			} else {
+				merged[key] = value
+			}
+		}
+		return merged
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) {
+			return b
+		}
+		merged := make([]any, len(av))
+		for i := range av {
+			merged[i] = mergeProjections(av[i], bv[i])
+		}
+		return merged
+	}
+	return b
+}
+
+// JsonChange is a single difference between two JSON documents.
+// Path is a JSON pointer (RFC 6901) to the changed value.
+// From and Value are always serialized, as null is a valid old or new value.
+type JsonChange struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  any    `json:"from"`
+	Value any    `json:"value"`
+}
+
+// DiffJson returns the structural differences between two JSON documents.
+// Objects are compared by key and arrays by position, so an element appended to
+// an array shows up as a single "add".
+func DiffJson(from, to []byte) ([]JsonChange, error) {
+	var a, b any
+	if err := json.Unmarshal(from, &a); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(to, &b); err != nil {
+		return nil, err
+	}
+
+	changes := []JsonChange{}
+	diffValues("", a, b, &changes)
+	return changes, nil
+}
+
+func diffValues(path string, a, b any, changes *[]JsonChange) {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(av)+len(bv))
+		for key := range av {
+			keys = append(keys, key)
+		}
+		for key := range bv {
+			if _, ok := av[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			child := path + "/" + escapePointer(key)
+			old_value, in_a := av[key]
+			new_value, in_b := bv[key]
+			switch {
+			case !in_b:
+				*changes = append(*changes, JsonChange{Op: "remove", Path: child, From: old_value})
+			case !in_a:
+				*changes = append(*changes, JsonChange{Op: "add", Path: child, Value: new_value})
+			default:
+				diffValues(child, old_value, new_value, changes)
+			}
+		}
+		return
+	case []any:
+		bv, ok := b.([]any)
+		if !ok {
+			break
+		}
+		for i := range max(len(av), len(bv)) {
+			child := fmt.Sprintf("%s/%d", path, i)
+			switch {
+			case i >= len(bv):
+				*changes = append(*changes, JsonChange{Op: "remove", Path: child, From: av[i]})
+			case i >= len(av):
+				*changes = append(*changes, JsonChange{Op: "add", Path: child, Value: bv[i]})
+			default:
+				diffValues(child, av[i], bv[i], changes)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, JsonChange{Op: "replace", Path: path, From: a, Value: b})
+	}
+}
+
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
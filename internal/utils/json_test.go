@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestProjectJson(t *testing.T) {
+	doc := []byte(`{
+		"accession": "BGC0000001",
+		"compounds": [{"name": "abyssomicin C", "synonyms": ["atrop-abyssomicin C"]}, {"name": "abyssomicin B"}],
+		"biosynthesis": {"classes": [{"class": "PKS"}], "modules": []},
+		"taxonomy": {"name": "Verrucosispora maris", "ncbiTaxId": 263856}
+	}`)
+
+	tests := []struct {
+		Name     string
+		Fields   []string
+		Expected string
+	}{
+		{"top level", []string{"accession"}, `{"accession": "BGC0000001"}`},
+		{"nested", []string{"biosynthesis.classes"}, `{"biosynthesis": {"classes": [{"class": "PKS"}]}}`},
+		{"through arrays", []string{"compounds.name"}, `{"compounds": [{"name": "abyssomicin C"}, {"name": "abyssomicin B"}]}`},
+		{"merged", []string{"compounds.name", "compounds.synonyms", "taxonomy.name"},
+			`{"compounds": [{"name": "abyssomicin C", "synonyms": ["atrop-abyssomicin C"]}, {"name": "abyssomicin B"}], "taxonomy": {"name": "Verrucosispora maris"}}`},
+		{"missing", []string{"nonexistent", "taxonomy.nonexistent"}, `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			raw, err := ProjectJson(doc, tt.Fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got, expected any
+			if err = json.Unmarshal(raw, &got); err != nil {
+				t.Fatal(err)
+			}
+			if err = json.Unmarshal([]byte(tt.Expected), &expected); err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(expected, got) {
+				t.Errorf("Unexpected projection.\n%s", cmp.Diff(expected, got))
+			}
+		})
+	}
+}
+
+func TestDiffJson(t *testing.T) {
+	tests := []struct {
+		Name     string
+		From     string
+		To       string
+		Expected []JsonChange
+	}{
+		{"identical", `{"a": [1, 2], "b": {"c": "d"}}`, `{"b": {"c": "d"}, "a": [1, 2]}`, []JsonChange{}},
+		{"replaced", `{"a": {"b": 1}}`, `{"a": {"b": 2}}`, []JsonChange{{Op: "replace", Path: "/a/b", From: 1.0, Value: 2.0}}},
+		{"added key", `{}`, `{"a/b": true}`, []JsonChange{{Op: "add", Path: "/a~1b", Value: true}}},
+		{"removed key", `{"a": "x", "b": "y"}`, `{"b": "y"}`, []JsonChange{{Op: "remove", Path: "/a", From: "x"}}},
+		{"appended", `{"compounds": [{"name": "a"}]}`, `{"compounds": [{"name": "a"}, {"name": "b"}]}`,
+			[]JsonChange{{Op: "add", Path: "/compounds/1", Value: map[string]any{"name": "b"}}}},
+		{"type change", `{"a": [1]}`, `{"a": "1"}`, []JsonChange{{Op: "replace", Path: "/a", From: []any{1.0}, Value: "1"}}},
+		{"set to null", `{"a": 1}`, `{"a": null}`, []JsonChange{{Op: "replace", Path: "/a", From: 1.0, Value: nil}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			changes, err := DiffJson([]byte(tt.From), []byte(tt.To))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(tt.Expected, changes) {
+				t.Errorf("Unexpected diff.\n%s", cmp.Diff(tt.Expected, changes))
+			}
+		})
+	}
+}
+
+func TestJsonChangeKeepsNull(t *testing.T) {
+	changes, err := DiffJson([]byte(`{"a": null, "b": 1}`), []byte(`{"a": 1, "b": null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := json.Marshal(changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `[{"op":"replace","path":"/a","from":null,"value":1},{"op":"replace","path":"/b","from":1,"value":null}]`
+	if string(out) != expected {
+		t.Errorf("want %s, got %s", expected, out)
+	}
+}
@@ -0,0 +1,114 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"secondarymetabolites.org/mibig-api/internal/antismash"
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+// maxAntismashSize limits the size of uploaded antiSMASH results
+const maxAntismashSize = 64 << 20
+
+// compareAntismash ranks the entries similar to each protocluster of an antiSMASH results JSON
+func (app *application) compareAntismash(c *gin.Context) {
+	var input struct {
+		MaxMatches int `form:"max_matches" binding:"omitempty,min=1,max=100"`
+	}
+	if err := c.ShouldBindQuery(&input); err != nil {
+		app.clientErrorWithMessage(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if input.MaxMatches == 0 {
+		input.MaxMatches = 10
+	}
+
+	profiles, err := app.Models.Entries.Profiles()
+	if err != nil {
+		app.serverError(c, err)
+		return
+	}
+
+	// protoclusters are compared record by record as the upload is read
+	r := app.antismashReader(c)
+	comparisons := []data.ClusterComparison{}
+	records := 0
+	for {
+		record, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			app.antismashFailed(c, err)
+			return
+		}
+		records++
+		comparisons = append(comparisons, antismash.CompareRecord(record, profiles, input.MaxMatches)...)
+	}
+	if records == 0 {
+		app.clientErrorWithMessage(c, http.StatusBadRequest, "antiSMASH result has no records")
+		return
+	}
+
+	c.JSON(http.StatusOK, comparisons)
+}
+
+// draftAntismash drafts a new entry from a protocluster of an antiSMASH results JSON
+func (app *application) draftAntismash(c *gin.Context) {
+	var input struct {
+		Record       string `form:"record"`
+		Protocluster int    `form:"protocluster" binding:"omitempty,min=1"`
+		Accession    string `form:"accession"`
+		TaxId        int64  `form:"taxid" binding:"omitempty,min=1"`
+	}
+	if err := c.ShouldBindQuery(&input); err != nil {
+		app.clientErrorWithMessage(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if input.Protocluster == 0 {
+		input.Protocluster = 1
+	}
+
+	entry, err := antismash.SelectRecord(app.antismashReader(c), input.Record)
+	if err != nil {
+		app.antismashFailed(c, err)
+		return
+	}
+
+	draft, err := antismash.Draft(entry, antismash.DraftOptions{
+		Record:       input.Record,
+		Protocluster: input.Protocluster,
+		Accession:    input.Accession,
+		TaxId:        input.TaxId,
+	})
+	if err != nil {
+		app.antismashFailed(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, draft)
+}
+
+// antismashReader streams the records of the antiSMASH results JSON of a request
+func (app *application) antismashReader(c *gin.Context) *antismash.Reader {
+	return antismash.NewReader(http.MaxBytesReader(c.Writer, c.Request.Body, maxAntismashSize))
+}
+
+// antismashFailed responds to errors reading or drafting from an antiSMASH result
+func (app *application) antismashFailed(c *gin.Context, err error) {
+	var parseErr *antismash.ParseError
+	if errors.As(err, &parseErr) {
+		app.clientErrorWithMessage(c, http.StatusBadRequest, fmt.Sprintf("invalid antiSMASH result: %s", err))
+		return
+	}
+	if errors.Is(err, antismash.ErrNoProtocluster) {
+		app.clientErrorWithMessage(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	app.serverError(c, err)
+}
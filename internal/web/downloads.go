@@ -0,0 +1,139 @@
+package web
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+	"secondarymetabolites.org/mibig-api/internal/queries"
+)
+
+const FASTA_LINE_LENGTH = 80
+
+type csvColumn func(entry *data.RepositoryEntry) string
+
+var csvColumns = map[string]csvColumn{
+	"accession":    func(e *data.RepositoryEntry) string { return e.Accession },
+	"status":       func(e *data.RepositoryEntry) string { return e.Status },
+	"quality":      func(e *data.RepositoryEntry) string { return e.Quality },
+	"completeness": func(e *data.RepositoryEntry) string { return e.Completeness },
+	"organism":     func(e *data.RepositoryEntry) string { return e.OrganismName },
+	"products": func(e *data.RepositoryEntry) string {
+		names := make([]string, 0, len(e.Products))
+		for _, product := range e.Products {
+			names = append(names, product.Name)
+		}
+		return strings.Join(names, "; ")
+	},
+	"classes": func(e *data.RepositoryEntry) string {
+		names := make([]string, 0, len(e.ProductTags))
+		for _, tag := range e.ProductTags {
+			names = append(names, tag.Name)
+		}
+		return strings.Join(names, "; ")
+	},
+}
+
+var defaultCsvColumns = []string{"accession", "status", "quality", "completeness", "organism", "products", "classes"}
+
+func checkCsvColumns(columns []string) error {
+	for _, column := range columns {
+		if _, ok := csvColumns[column]; !ok {
+			return fmt.Errorf("invalid CSV column %s", column)
+		}
+	}
+	return nil
+}
+
+func writeCsv(w io.Writer, entries []data.RepositoryEntry, columns []string) error {
+	if len(columns) == 0 {
+		columns = defaultCsvColumns
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+
+	row := make([]string, len(columns))
+	for i := range entries {
+		for j, column := range columns {
+			row[j] = csvColumns[column](&entries[i])
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeFasta(w io.Writer, records []data.FastaRecord) error {
+	for _, record := range records {
+		header := record.Id
+		if record.Description != "" {
+			header = fmt.Sprintf("%s %s", record.Id, record.Description)
+		}
+		if _, err := fmt.Fprintf(w, ">%s\n", header); err != nil {
+			return err
+		}
+		for start := 0; start < len(record.Sequence); start += FASTA_LINE_LENGTH {
+			end := min(start+FASTA_LINE_LENGTH, len(record.Sequence))
+			if _, err := fmt.Fprintf(w, "%s\n", record.Sequence[start:end]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func setDownloadHeaders(c *gin.Context, contentType, fileName string) {
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Status(http.StatusOK)
+}
+
+// downloadSearch answers a search in one of the download formats.
+// Downloads always cover the full result set, in the requested sort order.
+func (app *application) downloadSearch(c *gin.Context, returnType queries.ReturnType, entry_ids []string, page data.Pagination, columns []string) {
+	switch returnType {
+	case queries.Csv:
+		page.Paginate = 0
+		page.Offset = 0
+		clusters, err := app.entries(c).Get(entry_ids, page)
+		if err != nil {
+			if errors.Is(err, data.ErrInvalidSortOrder) {
+				c.JSON(http.StatusBadRequest, queryError{Message: err.Error(), Error: true})
+				return
+			}
+			app.serverError(c, err)
+			return
+		}
+		setDownloadHeaders(c, "text/csv; charset=utf-8", "mibig_search.csv")
+		if err = writeCsv(c.Writer, clusters, columns); err != nil {
+			app.logger.Errorw("failed to write CSV", "error", err.Error())
+		}
+	case queries.NucleotideFasta, queries.AminoAcidFasta:
+		protein := returnType == queries.AminoAcidFasta
+		records, err := app.entries(c).Sequences(entry_ids, protein)
+		if err != nil {
+			app.serverError(c, err)
+			return
+		}
+		fileName := "mibig_search.fna"
+		if protein {
+			fileName = "mibig_search.faa"
+		}
+		setDownloadHeaders(c, "text/x-fasta; charset=utf-8", fileName)
+		if err = writeFasta(c.Writer, records); err != nil {
+			app.logger.Errorw("failed to write FASTA", "error", err.Error())
+		}
+	}
+}
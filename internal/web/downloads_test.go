@@ -0,0 +1,68 @@
+package web
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+func TestWriteCsv(t *testing.T) {
+	entries := []data.RepositoryEntry{
+		{
+			Accession:    "BGC0000001.1",
+			Status:       "active",
+			Quality:      "high",
+			Completeness: "complete",
+			OrganismName: "Streptomyces collinus Tu 365",
+			Products:     []data.Product{{Name: "kirromycin"}, {Name: "mocimycin"}},
+			ProductTags:  []data.ProductTag{{Name: "NRP", Class: "nrps"}, {Name: "Polyketide", Class: "pks"}},
+		},
+	}
+
+	tests := []struct {
+		Name     string
+		Columns  []string
+		Expected string
+	}{
+		{Name: "default columns", Columns: nil, Expected: "accession,status,quality,completeness,organism,products,classes\n" +
+			"BGC0000001.1,active,high,complete,Streptomyces collinus Tu 365,kirromycin; mocimycin,NRP; Polyketide\n"},
+		{Name: "selected columns", Columns: []string{"organism", "accession"}, Expected: "organism,accession\n" +
+			"Streptomyces collinus Tu 365,BGC0000001.1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := writeCsv(&out, entries, tt.Columns); err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(tt.Expected, out.String()) {
+				t.Errorf("Unexpected CSV.\n%s", cmp.Diff(tt.Expected, out.String()))
+			}
+		})
+	}
+
+	if err := checkCsvColumns([]string{"accession", "foo"}); err == nil {
+		t.Errorf("Expected error for invalid column")
+	}
+}
+
+func TestWriteFasta(t *testing.T) {
+	records := []data.FastaRecord{
+		{Id: "BGC0000001.1|geneA", Description: "NRPS", Sequence: string(bytes.Repeat([]byte("M"), 100))},
+		{Id: "BGC0000001.1|geneB", Sequence: "MKL"},
+	}
+	expected := ">BGC0000001.1|geneA NRPS\n" + string(bytes.Repeat([]byte("M"), 80)) + "\n" + string(bytes.Repeat([]byte("M"), 20)) + "\n" +
+		">BGC0000001.1|geneB\nMKL\n"
+
+	var out bytes.Buffer
+	if err := writeFasta(&out, records); err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(expected, out.String()) {
+		t.Errorf("Unexpected FASTA.\n%s", cmp.Diff(expected, out.String()))
+	}
+}
@@ -0,0 +1,164 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+	"secondarymetabolites.org/mibig-api/internal/utils"
+)
+
+// entry serves the full MIBiG record of an entry.
+// Without a version, the latest version is served.
+func (app *application) entry(c *gin.Context) {
+	acc := c.Param("accession")
+	version := c.Param("version")
+
+	entry_id := acc
+	if version != "" {
+		if v, err := strconv.Atoi(version); err != nil || v < 1 {
+			c.JSON(http.StatusBadRequest, queryError{Message: fmt.Sprintf("Invalid version %s", version), Error: true})
+			return
+		}
+		entry_id = fmt.Sprintf("%s.%s", acc, version)
+	} else if !strings.Contains(acc, ".") {
+		latest, err := app.entries(c).Latest(acc)
+		if err == data.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, queryError{Message: err.Error(), Error: true})
+			return
+		}
+		if err != nil {
+			app.serverError(c, err)
+			return
+		}
+		entry_id = latest.Accession
+	}
+
+	record, err := app.entries(c).Record(entry_id)
+	if err == data.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, queryError{Message: err.Error(), Error: true})
+		return
+	}
+	if err != nil {
+		app.serverError(c, err)
+		return
+	}
+
+	body := record.Data
+	if fields := c.Query("fields"); fields != "" {
+		body, err = utils.ProjectJson(record.Data, strings.Split(fields, ","))
+		if err != nil {
+			app.serverError(c, err)
+			return
+		}
+	}
+
+	sum := sha256.Sum256(body)
+	etag := fmt.Sprintf(`"%s"`, hex.EncodeToString(sum[:]))
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", record.UpdatedAt.UTC().Format(http.TimeFormat))
+
+	if notModified(c.Request, etag, record.UpdatedAt) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// notModified evaluates the conditional request headers against the ETag and
+// modification time of a response. If-None-Match takes precedence.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		return !modified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+func (app *application) entryHistory(c *gin.Context) {
+	versions, err := app.entries(c).History(c.Param("accession"))
+	if err == data.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, queryError{Message: err.Error(), Error: true})
+		return
+	}
+	if err != nil {
+		app.serverError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, versions)
+}
+
+// entryAntismash serves a summary of the antiSMASH results stored for an entry.
+// Without a version, the results of the latest version that has any are served.
+func (app *application) entryAntismash(c *gin.Context) {
+	summary, err := app.entries(c).Antismash(c.Param("accession"))
+	if err == data.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, queryError{Message: err.Error(), Error: true})
+		return
+	}
+	if err != nil {
+		app.serverError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+type entryDiff struct {
+	Accession string             `json:"accession"`
+	From      int                `json:"from"`
+	To        int                `json:"to"`
+	Changes   []utils.JsonChange `json:"changes"`
+}
+
+func (app *application) entryDiff(c *gin.Context) {
+	var req struct {
+		From int `form:"from" binding:"required,min=1"`
+		To   int `form:"to" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, queryError{Message: "from and to must be valid versions", Error: true})
+		return
+	}
+
+	acc := c.Param("accession")
+	records := make([]*data.EntryRecord, 2)
+	for i, version := range []int{req.From, req.To} {
+		record, err := app.entries(c).Record(fmt.Sprintf("%s.%d", acc, version))
+		if err == data.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, queryError{Message: fmt.Sprintf("%s.%d: %s", acc, version, err), Error: true})
+			return
+		}
+		if err != nil {
+			app.serverError(c, err)
+			return
+		}
+		records[i] = record
+	}
+
+	changes, err := utils.DiffJson(records[0].Data, records[1].Data)
+	if err != nil {
+		app.serverError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entryDiff{Accession: acc, From: req.From, To: req.To, Changes: changes})
+}
@@ -1,8 +1,12 @@
 package web
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -16,6 +20,8 @@ type VersionInfo struct {
 	Api        string `json:"api"`
 	BuildTime  string `json:"build_time"`
 	GitVersion string `json:"git_version"`
+	// MIBiG release of the loaded data, if any was recorded
+	DataRelease string `json:"data_release,omitempty"`
 }
 
 func (app *application) version(c *gin.Context) {
@@ -24,6 +30,16 @@ func (app *application) version(c *gin.Context) {
 		BuildTime:  viper.GetString("buildTime"),
 		GitVersion: viper.GetString("gitVer"),
 	}
+
+	release, err := app.Models.Releases.Latest()
+	switch {
+	case err == nil:
+		version_info.DataRelease = release.Name
+	case !errors.Is(err, data.ErrRecordNotFound):
+		app.serverError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, &version_info)
 }
 
@@ -34,19 +50,19 @@ type Stats struct {
 }
 
 func (app *application) stats(c *gin.Context) {
-	counts, err := app.Models.Entries.Counts()
+	counts, err := app.entries(c).Counts()
 	if err != nil {
 		app.serverError(c, err)
 		return
 	}
 
-	clusters, err := app.Models.Entries.ClusterStats()
+	clusters, err := app.entries(c).ClusterStats()
 	if err != nil {
 		app.serverError(c, err)
 		return
 	}
 
-	phyla, err := app.Models.Entries.PhylumStats()
+	phyla, err := app.entries(c).PhylumStats()
 	if err != nil {
 		app.serverError(c, err)
 		return
@@ -62,7 +78,7 @@ func (app *application) stats(c *gin.Context) {
 }
 
 func (app *application) repository(c *gin.Context) {
-	repository_entries, err := app.Models.Entries.Repository()
+	repository_entries, err := app.entries(c).Repository()
 	if err != nil {
 		app.serverError(c, err)
 		return
@@ -76,7 +92,14 @@ type queryContainer struct {
 	SearchString string         `json:"search_string"`
 	Paginate     int            `json:"paginate"`
 	Offset       int            `json:"offset"`
+	Sort         string         `json:"sort"`
+	Order        string         `json:"order"`
+	Cursor       string         `json:"cursor"`
+	SearchType   string         `json:"search_type"`
+	ReturnType   string         `json:"return_type"`
+	Columns      []string       `json:"columns"`
 	Verbose      bool           `json:"verbose"`
+	Explain      bool           `json:"explain"`
 }
 
 type queryResult struct {
@@ -85,11 +108,122 @@ type queryResult struct {
 	Offset   int                    `json:"offset"`
 	Paginate int                    `json:"paginate"`
 	Stats    *data.ResultStats      `json:"stats"`
+	Next     string                 `json:"next,omitempty"`
+	Prev     string                 `json:"prev,omitempty"`
+	// relevance and snippets of the clusters on this page, for searches with [text] terms
+	Matches []data.TextMatch `json:"matches,omitempty"`
+}
+
+type cdsQueryResult struct {
+	Total    int           `json:"total"`
+	Genes    []data.CdsHit `json:"genes"`
+	Offset   int           `json:"offset"`
+	Paginate int           `json:"paginate"`
+	Next     string        `json:"next,omitempty"`
+	Prev     string        `json:"prev,omitempty"`
+}
+
+type domainQueryResult struct {
+	Total    int              `json:"total"`
+	Domains  []data.DomainHit `json:"domains"`
+	Offset   int              `json:"offset"`
+	Paginate int              `json:"paginate"`
+	Next     string           `json:"next,omitempty"`
+	Prev     string           `json:"prev,omitempty"`
 }
 
 type queryError struct {
 	Message string `json:"message"`
 	Error   bool   `json:"error"`
+	// Column of the offending token, for search strings that failed to parse
+	Column int `json:"column,omitempty"`
+}
+
+// parseFailed answers with a client error for invalid search strings,
+// and a server error for everything else
+func (app *application) parseFailed(c *gin.Context, err error) {
+	var parseError *queries.ParseError
+	if errors.As(err, &parseError) {
+		c.JSON(http.StatusBadRequest, queryError{Message: parseError.Message, Error: true, Column: parseError.Column})
+		return
+	}
+	app.serverError(c, err)
+}
+
+// searchFailed answers with a client error for search terms in unknown categories,
+// and treats everything else like parseFailed
+func (app *application) searchFailed(c *gin.Context, err error) {
+	if errors.Is(err, data.ErrInvalidCategory) {
+		c.JSON(http.StatusBadRequest, queryError{Message: err.Error(), Error: true})
+		return
+	}
+	app.parseFailed(c, err)
+}
+
+// searchCursor points to a page of a search result, to be sent along with the same query
+type searchCursor struct {
+	Offset   int    `json:"o"`
+	Paginate int    `json:"p"`
+	Sort     string `json:"s,omitempty"`
+	Order    string `json:"d,omitempty"`
+}
+
+func (sc *searchCursor) encode() string {
+	raw, _ := json.Marshal(sc)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(cursor string) (*searchCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var sc searchCursor
+	if err = json.Unmarshal(raw, &sc); err != nil {
+		return nil, errInvalidCursor
+	}
+	return &sc, nil
+}
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+func (qc *queryContainer) pagination() (data.Pagination, error) {
+	if qc.Cursor != "" {
+		sc, err := decodeCursor(qc.Cursor)
+		if err != nil {
+			return data.Pagination{}, err
+		}
+		qc.Offset, qc.Paginate, qc.Sort, qc.Order = sc.Offset, sc.Paginate, sc.Sort, sc.Order
+	}
+
+	if qc.Offset < 0 || qc.Paginate < 0 {
+		return data.Pagination{}, errors.New("offset and paginate must not be negative")
+	}
+
+	page := data.Pagination{Sort: qc.Sort, Paginate: qc.Paginate, Offset: qc.Offset}
+	switch strings.ToLower(qc.Order) {
+	case "", "asc":
+	case "desc":
+		page.Descending = true
+	default:
+		return data.Pagination{}, data.ErrInvalidSortOrder
+	}
+	return page, nil
+}
+
+func (qc *queryContainer) cursors(total int) (next string, prev string) {
+	if qc.Paginate == 0 {
+		return "", ""
+	}
+	if qc.Offset+qc.Paginate < total {
+		next_cursor := searchCursor{Offset: qc.Offset + qc.Paginate, Paginate: qc.Paginate, Sort: qc.Sort, Order: qc.Order}
+		next = next_cursor.encode()
+	}
+	if qc.Offset > 0 {
+		prev_cursor := searchCursor{Offset: max(qc.Offset-qc.Paginate, 0), Paginate: qc.Paginate, Sort: qc.Sort, Order: qc.Order}
+		prev = prev_cursor.encode()
+	}
+	return next, prev
 }
 
 func (app *application) search(c *gin.Context) {
@@ -105,29 +239,100 @@ func (app *application) search(c *gin.Context) {
 		return
 	}
 
+	page, err := qc.pagination()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, queryError{Message: err.Error(), Error: true})
+		return
+	}
+
 	if qc.Query == nil {
 		qc.Query, err = queries.NewQueryFromString(qc.SearchString)
 		if err != nil {
-			app.serverError(c, err)
+			app.parseFailed(c, err)
+			return
+		}
+	}
+
+	if qc.SearchType != "" {
+		queryType, ok := queries.STRING_QUERY_TYPE_MAP[strings.ToLower(qc.SearchType)]
+		if !ok {
+			c.JSON(http.StatusBadRequest, queryError{Message: fmt.Sprintf("Invalid query type %s", qc.SearchType), Error: true})
+			return
+		}
+		qc.Query.QueryType = queryType
+	}
+
+	if qc.ReturnType != "" {
+		returnType, ok := queries.STRING_RETURN_TYPE_MAP[strings.ToLower(qc.ReturnType)]
+		if !ok {
+			c.JSON(http.StatusBadRequest, queryError{Message: fmt.Sprintf("Invalid return type %s", qc.ReturnType), Error: true})
+			return
+		}
+		qc.Query.ReturnType = returnType
+	}
+
+	if err = checkCsvColumns(qc.Columns); err != nil {
+		c.JSON(http.StatusBadRequest, queryError{Message: err.Error(), Error: true})
+		return
+	}
+
+	// Debug mode, show the compiled statement and its plan instead of running it
+	if qc.Explain {
+		if !app.isReviewer(c) {
+			app.notPermitted(c)
 			return
 		}
+		plan, err := app.entries(c).Explain(qc.Query)
+		if err != nil {
+			app.searchFailed(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, plan)
+		return
+	}
+
+	if qc.Query.QueryType != queries.Cluster {
+		app.searchFeatures(c, &qc, page)
+		return
 	}
 
 	var entry_ids []string
-	entry_ids, err = app.Models.Entries.Search(qc.Query.Terms)
+	entry_ids, err = app.entries(c).Search(qc.Query.Terms)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, queryError{Message: err.Error(), Error: true})
+		app.searchFailed(c, err)
 		return
 	}
 
-	var clusters []data.RepositoryEntry
-	clusters, err = app.Models.Entries.Get(entry_ids)
+	if qc.Query.ReturnType != queries.Json {
+		app.downloadSearch(c, qc.Query.ReturnType, entry_ids, page, qc.Columns)
+		return
+	}
+
+	var (
+		clusters []data.RepositoryEntry
+		matches  []data.TextMatch
+	)
+	// Full-text searches are sorted by relevance unless asked otherwise
+	textTerms := queries.TermsInCategory(qc.Query.Terms, "text")
+	if len(textTerms) > 0 && (page.Sort == "" || page.Sort == "relevance") {
+		clusters, matches, err = app.rankedClusters(c, entry_ids, textTerms, page)
+	} else {
+		clusters, err = app.entries(c).Get(entry_ids, page)
+		if err == nil && len(textTerms) > 0 {
+			matches, err = app.entries(c).TextMatches(clusterIds(clusters), textTerms, data.Pagination{})
+		}
+	}
 	if err != nil {
+		if errors.Is(err, data.ErrInvalidSortOrder) {
+			c.JSON(http.StatusBadRequest, queryError{Message: err.Error(), Error: true})
+			return
+		}
 		app.serverError(c, err)
 		return
 	}
 
-	stats, err := app.Models.Entries.ResultStats(entry_ids)
+	// Stats always cover the full result set, not just the current page
+	stats, err := app.entries(c).ResultStats(entry_ids)
 	if err != nil {
 		app.serverError(c, err)
 		return
@@ -139,15 +344,98 @@ func (app *application) search(c *gin.Context) {
 		Offset:   qc.Offset,
 		Paginate: qc.Paginate,
 		Stats:    stats,
+		Matches:  matches,
 	}
+	result.Next, result.Prev = qc.cursors(result.Total)
 
 	c.JSON(http.StatusOK, &result)
 }
 
+// rankedClusters gets a page of entries ordered by how well their text matches the terms
+func (app *application) rankedClusters(c *gin.Context, ids []string, terms []string, page data.Pagination) ([]data.RepositoryEntry, []data.TextMatch, error) {
+	matches, err := app.entries(c).TextMatches(ids, terms, page)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ranking := make(map[string]int, len(matches))
+	pageIds := make([]string, 0, len(matches))
+	for i, match := range matches {
+		ranking[match.EntryId] = i
+		pageIds = append(pageIds, match.EntryId)
+	}
+
+	clusters, err := app.entries(c).Get(pageIds, data.Pagination{})
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return ranking[clusters[i].Accession] < ranking[clusters[j].Accession]
+	})
+	return clusters, matches, nil
+}
+
+func clusterIds(clusters []data.RepositoryEntry) []string {
+	ids := make([]string, 0, len(clusters))
+	for _, cluster := range clusters {
+		ids = append(ids, cluster.Accession)
+	}
+	return ids
+}
+
+// searchFeatures answers searches for genes or domains.
+// Hits are ordered by their parent entry, sort options do not apply.
+func (app *application) searchFeatures(c *gin.Context, qc *queryContainer, page data.Pagination) {
+	if qc.Query.ReturnType != queries.Json {
+		c.JSON(http.StatusBadRequest, queryError{Message: "Only JSON results are available for CDS and domain searches", Error: true})
+		return
+	}
+
+	switch qc.Query.QueryType {
+	case queries.Cds:
+		hits, err := app.entries(c).SearchCds(qc.Query.Terms)
+		if err != nil {
+			app.searchFailed(c, err)
+			return
+		}
+		result := cdsQueryResult{
+			Total:    len(hits),
+			Genes:    pageOf(hits, page),
+			Offset:   qc.Offset,
+			Paginate: qc.Paginate,
+		}
+		result.Next, result.Prev = qc.cursors(result.Total)
+		c.JSON(http.StatusOK, &result)
+	case queries.Domain:
+		hits, err := app.entries(c).SearchDomains(qc.Query.Terms)
+		if err != nil {
+			app.searchFailed(c, err)
+			return
+		}
+		result := domainQueryResult{
+			Total:    len(hits),
+			Domains:  pageOf(hits, page),
+			Offset:   qc.Offset,
+			Paginate: qc.Paginate,
+		}
+		result.Next, result.Prev = qc.cursors(result.Total)
+		c.JSON(http.StatusOK, &result)
+	}
+}
+
+func pageOf[T any](items []T, page data.Pagination) []T {
+	start := min(page.Offset, len(items))
+	end := len(items)
+	if page.Paginate > 0 {
+		end = min(start+page.Paginate, end)
+	}
+	return items[start:end]
+}
+
 func (app *application) available(c *gin.Context) {
 	category := c.Param("category")
 	term := c.Param("term")
-	available, err := app.Models.Entries.Available(category, term)
+	available, err := app.entries(c).Available(category, term)
 	if err == data.ErrInvalidCategory {
 		c.JSON(http.StatusBadRequest, queryError{Message: err.Error(), Error: true})
 		return
@@ -170,11 +458,11 @@ func (app *application) Convert(c *gin.Context) {
 
 	query, err := queries.NewQueryFromString(req.Search)
 	if err != nil {
-		app.serverError(c, err)
+		app.parseFailed(c, err)
 		return
 	}
 
-	err = app.Models.Entries.GuessCategories(query)
+	err = app.entries(c).GuessCategories(query)
 	if err == data.ErrInvalidCategory {
 		c.JSON(http.StatusBadRequest, queryError{Message: err.Error(), Error: true})
 		return
@@ -195,7 +483,7 @@ func (app *application) Contributors(c *gin.Context) {
 		return
 	}
 
-	contributors, err := app.Models.Entries.LookupContributors(req.Ids)
+	contributors, err := app.entries(c).LookupContributors(req.Ids)
 	if err != nil {
 		app.serverError(c, err)
 		return
@@ -209,9 +497,21 @@ func (app *application) Redirect(c *gin.Context) {
 	extra := c.Param("extra")
 
 	template := "/repository/%s/%s"
+	if strings.Contains(acc, ".") && !app.isReviewer(c) {
+		// versioned ids skip the lookup, so embargoed versions need to be hidden here
+		embargoed, err := app.Models.Entries.IsEmbargoed(acc)
+		if err != nil {
+			app.serverError(c, err)
+			return
+		}
+		if embargoed {
+			c.JSON(http.StatusNotFound, queryError{Message: data.ErrRecordNotFound.Error(), Error: true})
+			return
+		}
+	}
 	if !strings.Contains(acc, ".") {
 
-		entry, err := app.Models.Entries.Latest(acc)
+		entry, err := app.latestSuccessor(c, acc)
 
 		if err == data.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, queryError{Message: err.Error(), Error: true})
@@ -221,6 +521,11 @@ func (app *application) Redirect(c *gin.Context) {
 			app.serverError(c, err)
 			return
 		}
+		// retired entries without a unique successor get a tombstone
+		if entry.Status == "retired" {
+			c.JSON(http.StatusGone, entry)
+			return
+		}
 		acc = entry.Accession
 	}
 
@@ -232,3 +537,27 @@ func (app *application) Redirect(c *gin.Context) {
 	c.Redirect(http.StatusFound, target)
 
 }
+
+// latestSuccessor gets the latest version of an entry. Retired entries with a single
+// successor are followed to that successor, a retired entry is only returned if
+// there's no unique successor.
+func (app *application) latestSuccessor(c *gin.Context, accession string) (*data.RepositoryEntry, error) {
+	entry, err := app.entries(c).Latest(accession)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{accession: true}
+	for entry.Status == "retired" && len(entry.SeeAlso) == 1 && !seen[entry.SeeAlso[0]] {
+		successor, err := app.entries(c).Latest(entry.SeeAlso[0])
+		if err == data.ErrRecordNotFound {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		seen[entry.SeeAlso[0]] = true
+		entry = successor
+	}
+	return entry, nil
+}
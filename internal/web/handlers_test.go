@@ -6,7 +6,9 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/go-cmp/cmp"
@@ -16,6 +18,7 @@ import (
 	"secondarymetabolites.org/mibig-api/internal/mailer"
 	"secondarymetabolites.org/mibig-api/internal/models"
 	"secondarymetabolites.org/mibig-api/internal/queries"
+	"secondarymetabolites.org/mibig-api/internal/seqsearch"
 )
 
 func newTestApp() (*application, *httptest.Server) {
@@ -34,12 +37,20 @@ func newTestApp() (*application, *httptest.Server) {
 
 	viper.Set("buildTime", "Fake time")
 	viper.Set("gitVer", "deadbeef")
+	viper.Set("mail.recipient", "mibig@example.com")
+
+	entries := models.NewMockEntryModel()
+	entries.Embargoed = []string{"BGC0000002", "BGC0000002.1"}
+	mockModels := models.NewMockModes([]string{})
+	mockModels.Entries = entries
 
 	app := &application{
-		logger: logger,
-		Mail:   sender,
-		Models: models.NewMockModes([]string{}),
-		Mux:    mux,
+		logger:         logger,
+		Mail:           sender,
+		Models:         mockModels,
+		Mux:            mux,
+		RepositoryPath: "testdata/repository",
+		Sequences:      seqsearch.NewIndexFile("testdata/missing.idx"),
 	}
 	mux = app.routes()
 	mux.GET("/static/genes_form.html", func(c *gin.Context) {
@@ -141,11 +152,319 @@ func TestRepository(t *testing.T) {
 	}
 }
 
+func TestEmbargoedHidden(t *testing.T) {
+	_, ts := newTestApp()
+	defer ts.Close()
+
+	client := ts.Client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	tests := []struct {
+		Path     string
+		Expected int
+	}{
+		{"/repository/BGC0000001.1/", http.StatusOK},
+		{"/repository/BGC0000002.1/index.html", http.StatusNotFound},
+		{"/go/BGC0000001.1", http.StatusFound},
+		{"/go/BGC0000002.1", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		response, err := client.Get(ts.URL + tt.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+
+		if response.StatusCode != tt.Expected {
+			t.Errorf("%s: expected %d, got %d", tt.Path, tt.Expected, response.StatusCode)
+		}
+	}
+}
+
+func TestRedirectRetired(t *testing.T) {
+	app, ts := newTestApp()
+	defer ts.Close()
+
+	app.Models.Entries.(*models.MockEntryModel).Latests = map[string]data.RepositoryEntry{
+		"BGC0000003": {Accession: "BGC0000003.2", Status: "retired", RetirementReasons: []string{"duplicate"}, SeeAlso: []string{"BGC0000004"}},
+		"BGC0000004": {Accession: "BGC0000004.2", Status: "retired", RetirementReasons: []string{"merged"}, SeeAlso: []string{"BGC0000006"}},
+		"BGC0000005": {Accession: "BGC0000005.2", Status: "retired", RetirementReasons: []string{"not a BGC"}},
+		"BGC0000006": {Accession: "BGC0000006.1", Status: "active"},
+	}
+
+	client := ts.Client()
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	response, err := client.Get(ts.URL + "/go/BGC0000003")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusFound {
+		t.Errorf("Expected %d, got %d", http.StatusFound, response.StatusCode)
+	}
+	if location := response.Header.Get("Location"); location != "/repository/BGC0000006.1/index.html" {
+		t.Errorf("Unexpected redirect to %s", location)
+	}
+
+	response, err = client.Get(ts.URL + "/go/BGC0000005")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusGone {
+		t.Errorf("Expected %d, got %d", http.StatusGone, response.StatusCode)
+	}
+
+	var tombstone data.RepositoryEntry
+	if err := json.NewDecoder(response.Body).Decode(&tombstone); err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal([]string{"not a BGC"}, tombstone.RetirementReasons) {
+		t.Errorf("Unexpected retirement reasons %v", tombstone.RetirementReasons)
+	}
+}
+
+func TestChangelog(t *testing.T) {
+	app, ts := newTestApp()
+	defer ts.Close()
+
+	releases := app.Models.Releases.(*models.MockReleaseModel)
+	releases.AddSnapshot("3.1", []data.ReleaseEntry{{EntryId: "BGC0000001.1", Accession: "BGC0000001", Version: 1, Status: "active"}})
+	releases.AddSnapshot("4.0", []data.ReleaseEntry{{EntryId: "BGC0000001.2", Accession: "BGC0000001", Version: 2, Status: "active"}})
+
+	tests := []struct {
+		Query    string
+		Expected int
+	}{
+		{"from=3.1&to=4.0", http.StatusOK},
+		{"from=3.1&to=4.0&format=markdown", http.StatusOK},
+		{"from=3.1&to=5.0", http.StatusNotFound},
+		{"from=3.1", http.StatusBadRequest},
+		{"from=3.1&to=4.0&format=pdf", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		response, err := ts.Client().Get(ts.URL + "/api/v1/changelog?" + tt.Query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != tt.Expected {
+			t.Errorf("%s: expected %d, got %d", tt.Query, tt.Expected, response.StatusCode)
+		}
+	}
+
+	response, err := ts.Client().Get(ts.URL + "/api/v1/version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	var version VersionInfo
+	if err := json.NewDecoder(response.Body).Decode(&version); err != nil {
+		t.Fatal(err)
+	}
+	if version.DataRelease != "4.0" {
+		t.Errorf("Expected data release 4.0, got %q", version.DataRelease)
+	}
+}
+
+func TestSequenceSearch(t *testing.T) {
+	app, ts := newTestApp()
+	defer ts.Close()
+
+	target := "MSEQNTLKVLAAGIWHRDCPYFTEGSMKRLVNAWQDEHTGCIPSVFAKLMRNDEQWYSTGHVCAPILKM"
+	post := func(body string) *http.Response {
+		response, err := ts.Client().Post(ts.URL+"/api/v1/sequence-search", "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+
+	response := post(`{"fasta": ">q\n` + target + `"}`)
+	response.Body.Close()
+	if response.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected %d without an index, got %d", http.StatusServiceUnavailable, response.StatusCode)
+	}
+
+	path := t.TempDir() + "/sequences.idx"
+	index := seqsearch.NewIndex([]seqsearch.Target{{EntryId: "BGC0000001.1", LocusTag: "geneA", Sequence: target}})
+	if err := index.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+	app.Sequences = seqsearch.NewIndexFile(path)
+
+	for _, body := range []string{`{}`, `{"fasta": ">q\nMKV%"}`, `{"fasta": "MKV", "max_hits": 0.5}`} {
+		response = post(body)
+		response.Body.Close()
+		if response.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected %d, got %d", body, http.StatusBadRequest, response.StatusCode)
+		}
+	}
+
+	response = post(`{"fasta": ">first\n` + target + `\n>second\n` + target[10:50] + `"}`)
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d, got %d", http.StatusOK, response.StatusCode)
+	}
+	var results []data.SequenceQueryResult
+	if err := json.NewDecoder(response.Body).Decode(&results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	for _, result := range results {
+		if len(result.Hits) != 1 || result.Hits[0].Accession != "BGC0000001.1" || result.Hits[0].Identity != 100 {
+			t.Errorf("Unexpected hits for %s: %+v", result.Query, result.Hits)
+		}
+	}
+}
+
+func TestCompareAntismash(t *testing.T) {
+	app, ts := newTestApp()
+	defer ts.Close()
+
+	entries := app.Models.Entries.(*models.MockEntryModel)
+	entries.EntryProfiles = []data.EntryProfile{
+		{Accession: "BGC0000001.1", Classes: []string{"NRPS"}, Domains: []string{"A", "C"}, Lineage: make([]string, 8)},
+		{Accession: "BGC0000003.1", Classes: []string{"terpene"}, Lineage: make([]string, 8)},
+	}
+
+	raw, err := os.ReadFile("../antismash/testdata/minimal.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, body := range []string{`{"records": []}`, `not json`} {
+		response, err := ts.Client().Post(ts.URL+"/api/v1/compare/antismash", "application/json", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected %d, got %d", body, http.StatusBadRequest, response.StatusCode)
+		}
+	}
+
+	response, err := ts.Client().Post(ts.URL+"/api/v1/compare/antismash?max_matches=5", "application/json", bytes.NewBuffer(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d, got %d", http.StatusOK, response.StatusCode)
+	}
+	var comparisons []data.ClusterComparison
+	if err := json.NewDecoder(response.Body).Decode(&comparisons); err != nil {
+		t.Fatal(err)
+	}
+	if len(comparisons) != 2 {
+		t.Fatalf("Expected 2 protoclusters, got %d", len(comparisons))
+	}
+	for i, expected := range []string{"BGC0000001.1", "BGC0000003.1"} {
+		if len(comparisons[i].Matches) != 1 || comparisons[i].Matches[0].Accession != expected {
+			t.Errorf("Expected %s to match %s, got %+v", comparisons[i].Product, expected, comparisons[i].Matches)
+		}
+	}
+}
+
+func TestDraftAntismash(t *testing.T) {
+	_, ts := newTestApp()
+	defer ts.Close()
+
+	raw, err := os.ReadFile("../antismash/testdata/minimal.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Name           string
+		Query          string
+		ExpectedStatus int
+		ExpectedClass  string
+	}{
+		{"default", "", http.StatusOK, "NRPS"},
+		{"second protocluster", "?record=NC_003888.3&protocluster=2&taxid=100226", http.StatusOK, "terpene"},
+		{"missing protocluster", "?protocluster=3", http.StatusBadRequest, ""},
+		{"missing record", "?record=missing", http.StatusBadRequest, ""},
+		{"invalid taxid", "?taxid=-1", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			response, err := ts.Client().Post(ts.URL+"/api/v1/draft/antismash"+tt.Query, "application/json", bytes.NewBuffer(raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer response.Body.Close()
+			if response.StatusCode != tt.ExpectedStatus {
+				t.Fatalf("Expected %d, got %d", tt.ExpectedStatus, response.StatusCode)
+			}
+			if tt.ExpectedStatus != http.StatusOK {
+				return
+			}
+			var draft data.AntismashDraft
+			if err := json.NewDecoder(response.Body).Decode(&draft); err != nil {
+				t.Fatal(err)
+			}
+			if draft.Entry.Status != "pending" || draft.Entry.Biosynthesis.Classes[0].Class != tt.ExpectedClass {
+				t.Errorf("Unexpected draft %+v", draft.Entry)
+			}
+		})
+	}
+}
+
+func TestEntryAntismash(t *testing.T) {
+	app, ts := newTestApp()
+	defer ts.Close()
+
+	summary := data.AntismashSummary{
+		EntryId: "BGC0000001.2",
+		Version: "7.1.0",
+		Protoclusters: []data.AntismashProtocluster{{
+			Record: "NC_003888.3", Number: 1, Location: "[1000:40000]", Product: "NRPS", Category: "NRPS",
+			Cdses: []data.AntismashCds{{Name: "SCO3230", Domains: []string{"AMP-binding", "Condensation"}}},
+		}},
+	}
+	app.Models.Entries.(*models.MockEntryModel).AntismashSummaries = map[string]data.AntismashSummary{"BGC0000001": summary}
+
+	response, err := ts.Client().Get(ts.URL + "/api/v1/entry/BGC0000001/antismash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d, got %d", http.StatusOK, response.StatusCode)
+	}
+	var actual data.AntismashSummary
+	if err := json.NewDecoder(response.Body).Decode(&actual); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(summary, actual); diff != "" {
+		t.Errorf("Unexpected summary (-want +got):\n%s", diff)
+	}
+
+	response, err = ts.Client().Get(ts.URL + "/api/v1/entry/BGC0000002/antismash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected %d, got %d", http.StatusNotFound, response.StatusCode)
+	}
+}
+
 func TestSearch(t *testing.T) {
 	app, ts := newTestApp()
 	defer ts.Close()
 
-	fake_clusters, _ := app.Models.Entries.Get([]string{"BGC0000001", "BGC0000023", "BGC0000042"})
+	fake_clusters, _ := app.Models.Entries.Get([]string{"BGC0000001", "BGC0000023", "BGC0000042"}, data.Pagination{})
 	tests := []struct {
 		Name             string
 		Query            *queries.Query
@@ -175,6 +494,16 @@ func TestSearch(t *testing.T) {
 				Error:   true,
 			},
 		},
+		{
+			Name:           "invalid string",
+			SearchString:   "nrps AND (ripp",
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedError: &queryError{
+				Message: "Invalid token END",
+				Error:   true,
+				Column:  15,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -231,6 +560,26 @@ func TestSearch(t *testing.T) {
 	}
 }
 
+func TestSearchExplainNeedsReviewer(t *testing.T) {
+	_, ts := newTestApp()
+	defer ts.Close()
+
+	raw_req, err := json.Marshal(&queryContainer{SearchString: "nrps", Explain: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := ts.Client().Post(ts.URL+"/api/v1/search", "application/json", bytes.NewReader(raw_req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected %d, got %d", http.StatusUnauthorized, response.StatusCode)
+	}
+}
+
 func TestAvailable(t *testing.T) {
 	_, ts := newTestApp()
 	defer ts.Close()
@@ -334,3 +683,109 @@ func TestContributors(t *testing.T) {
 		t.Errorf("Expected repository of length %d, got %d: %v", 1, len(contributors), contributors)
 	}
 }
+
+func TestSearchCursors(t *testing.T) {
+	tests := []struct {
+		Name         string
+		Offset       int
+		Paginate     int
+		Total        int
+		ExpectedNext *searchCursor
+		ExpectedPrev *searchCursor
+	}{
+		{Name: "no pagination", Offset: 0, Paginate: 0, Total: 50},
+		{Name: "first page", Offset: 0, Paginate: 20, Total: 50, ExpectedNext: &searchCursor{Offset: 20, Paginate: 20}},
+		{Name: "middle page", Offset: 20, Paginate: 20, Total: 50,
+			ExpectedNext: &searchCursor{Offset: 40, Paginate: 20}, ExpectedPrev: &searchCursor{Offset: 0, Paginate: 20}},
+		{Name: "last page", Offset: 40, Paginate: 20, Total: 50, ExpectedPrev: &searchCursor{Offset: 20, Paginate: 20}},
+		{Name: "odd offset", Offset: 5, Paginate: 20, Total: 50,
+			ExpectedNext: &searchCursor{Offset: 25, Paginate: 20}, ExpectedPrev: &searchCursor{Offset: 0, Paginate: 20}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			qc := queryContainer{Offset: tt.Offset, Paginate: tt.Paginate}
+			next, prev := qc.cursors(tt.Total)
+
+			for _, cursor := range []struct {
+				Raw      string
+				Expected *searchCursor
+			}{{next, tt.ExpectedNext}, {prev, tt.ExpectedPrev}} {
+				if cursor.Expected == nil {
+					if cursor.Raw != "" {
+						t.Errorf("Expected no cursor, got %s", cursor.Raw)
+					}
+					continue
+				}
+				parsed, err := decodeCursor(cursor.Raw)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !cmp.Equal(*cursor.Expected, *parsed) {
+					t.Errorf("Unexpected cursor.\n%s", cmp.Diff(*cursor.Expected, *parsed))
+				}
+			}
+		})
+	}
+
+	if _, err := decodeCursor("not a cursor"); err != errInvalidCursor {
+		t.Errorf("Expected %v, got %v", errInvalidCursor, err)
+	}
+}
+
+func TestPageOf(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+	tests := []struct {
+		Name     string
+		Page     data.Pagination
+		Expected []int
+	}{
+		{Name: "no pagination", Page: data.Pagination{}, Expected: items},
+		{Name: "first page", Page: data.Pagination{Paginate: 2}, Expected: []int{1, 2}},
+		{Name: "last page", Page: data.Pagination{Paginate: 2, Offset: 4}, Expected: []int{5}},
+		{Name: "past the end", Page: data.Pagination{Paginate: 2, Offset: 10}, Expected: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			got := pageOf(items, tt.Page)
+			if !cmp.Equal(tt.Expected, got) {
+				t.Errorf("Unexpected page.\n%s", cmp.Diff(tt.Expected, got))
+			}
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	etag := `"abc"`
+
+	tests := []struct {
+		Name     string
+		Headers  map[string]string
+		Expected bool
+	}{
+		{Name: "no headers", Headers: map[string]string{}, Expected: false},
+		{Name: "matching etag", Headers: map[string]string{"If-None-Match": `"xyz", "abc"`}, Expected: true},
+		{Name: "weak etag", Headers: map[string]string{"If-None-Match": `W/"abc"`}, Expected: true},
+		{Name: "other etag", Headers: map[string]string{"If-None-Match": `"xyz"`}, Expected: false},
+		{Name: "etag takes precedence", Headers: map[string]string{
+			"If-None-Match":     `"xyz"`,
+			"If-Modified-Since": modified.Format(http.TimeFormat),
+		}, Expected: false},
+		{Name: "not modified since", Headers: map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, Expected: true},
+		{Name: "modified since", Headers: map[string]string{"If-Modified-Since": modified.Add(-time.Hour).Format(http.TimeFormat)}, Expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/entry/BGC0000001", nil)
+			for key, value := range tt.Headers {
+				req.Header.Set(key, value)
+			}
+			if got := notModified(req, etag, modified); got != tt.Expected {
+				t.Errorf("Expected %v, got %v", tt.Expected, got)
+			}
+		})
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/gin-gonic/gin"
 	zap "go.uber.org/zap"
 	"secondarymetabolites.org/mibig-api/internal/data"
+	"secondarymetabolites.org/mibig-api/internal/models"
+	"secondarymetabolites.org/mibig-api/internal/utils"
 )
 
 func (app *application) clientError(c *gin.Context, status int) {
@@ -48,6 +50,23 @@ func (app *application) GetCurrentUser(c *gin.Context) *data.User {
 	return c.MustGet("user").(*data.User)
 }
 
+// isReviewer checks if the current user may see entries that are hidden from the public
+func (app *application) isReviewer(c *gin.Context) bool {
+	user := app.GetCurrentUser(c)
+	if user.IsAnonymous() || !user.Active {
+		return false
+	}
+	return len(utils.Intersect([]string{"reviewer", "admin"}, data.RolesToStrings(user.Roles))) > 0
+}
+
+// entries returns the entry model for the current user, reviewers also get to see embargoed entries
+func (app *application) entries(c *gin.Context) models.EntryModel {
+	if app.isReviewer(c) {
+		return app.Models.Entries.WithEmbargoed()
+	}
+	return app.Models.Entries
+}
+
 func (app *application) background(fn func()) {
 	go func() {
 		defer func() {
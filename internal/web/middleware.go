@@ -128,3 +128,34 @@ func (app *application) RequireRoles(requiredRoles []string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// HideEmbargoed answers requests for files of embargoed entries with a 404,
+// unless the user is a reviewer. The entry is the first path element after the prefix.
+func (app *application) HideEmbargoed(prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if app.isReviewer(c) {
+			c.Next()
+			return
+		}
+
+		id, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(c.Request.URL.Path, prefix), "/"), "/")
+		if id == "" {
+			c.Next()
+			return
+		}
+
+		embargoed, err := app.Models.Entries.IsEmbargoed(id)
+		if err != nil {
+			app.serverError(c, err)
+			c.Abort()
+			return
+		}
+		if embargoed {
+			app.clientError(c, http.StatusNotFound)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
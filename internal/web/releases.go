@@ -0,0 +1,48 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+func (app *application) listReleases(c *gin.Context) {
+	releases, err := app.Models.Releases.List()
+	if err != nil {
+		app.serverError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, releases)
+}
+
+// changelog reports the changes between two releases, as JSON or with format=markdown as Markdown
+func (app *application) changelog(c *gin.Context) {
+	var input struct {
+		From   string `form:"from" binding:"required"`
+		To     string `form:"to" binding:"required"`
+		Format string `form:"format" binding:"omitempty,oneof=json markdown"`
+	}
+	if err := c.ShouldBindQuery(&input); err != nil {
+		app.clientErrorWithMessage(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	changelog, err := app.Models.Releases.Changelog(input.From, input.To)
+	if err != nil {
+		if errors.Is(err, data.ErrRecordNotFound) {
+			app.clientErrorWithMessage(c, http.StatusNotFound, "unknown release")
+			return
+		}
+		app.serverError(c, err)
+		return
+	}
+
+	if input.Format == "markdown" {
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(changelog.Markdown()))
+		return
+	}
+	c.JSON(http.StatusOK, changelog)
+}
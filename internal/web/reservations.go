@@ -0,0 +1,75 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+	"secondarymetabolites.org/mibig-api/internal/models"
+)
+
+func (app *application) listReservations(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && !data.ValidReservationStatus(status) {
+		app.clientErrorWithMessage(c, http.StatusBadRequest, fmt.Sprintf("invalid status %s", status))
+		return
+	}
+
+	reservations, err := app.Models.Reservations.List(status)
+	if err != nil {
+		app.serverError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reservations)
+}
+
+func (app *application) reserve(c *gin.Context) {
+	var input struct {
+		Ticket string `json:"ticket"`
+		Days   int    `json:"days" binding:"min=0"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			app.clientErrorWithMessage(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	ttl := models.DefaultReservationTTL
+	if input.Days > 0 {
+		ttl = time.Duration(input.Days) * 24 * time.Hour
+	}
+
+	reservation, err := app.Models.Reservations.Reserve(input.Ticket, app.GetCurrentUser(c), ttl)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.clientErrorWithMessage(c, http.StatusBadRequest, fmt.Sprintf("no accession request %s", input.Ticket))
+		case errors.Is(err, data.ErrRequestClosed):
+			app.clientErrorWithMessage(c, http.StatusConflict, err.Error())
+		default:
+			app.serverError(c, err)
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, reservation)
+}
+
+func (app *application) releaseReservation(c *gin.Context) {
+	if err := app.Models.Reservations.Release(c.Param("accession")); err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.clientError(c, http.StatusNotFound)
+		case errors.Is(err, data.ErrReservationClosed):
+			app.clientErrorWithMessage(c, http.StatusConflict, err.Error())
+		default:
+			app.serverError(c, err)
+		}
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -12,13 +12,39 @@ func (app *application) routes() *gin.Engine {
 		{
 			v1.GET("/version", app.version)
 			v1.GET("/stats", app.stats)
+			v1.GET("/releases", app.listReleases)
+			v1.GET("/changelog", app.changelog)
+			v1.GET("/taxonomy", app.taxonomy)
+			v1.POST("/sequence-search", app.sequenceSearch)
+			v1.POST("/compare/antismash", app.compareAntismash)
+			v1.POST("/draft/antismash", app.draftAntismash)
 			v1.GET("/repository", app.repository)
+			v1.GET("/entry/:accession", app.entry)
+			v1.GET("/entry/:accession/:version", app.entry)
+			v1.GET("/entry/:accession/history", app.entryHistory)
+			v1.GET("/entry/:accession/diff", app.entryDiff)
+			v1.GET("/entry/:accession/antismash", app.entryAntismash)
 			v1.POST("/search", app.search)
 			v1.GET("/available/:category/:term", app.available)
 			v1.GET("/convert", app.Convert)
 			v1.GET("/contributors", app.Contributors)
 			v1.POST("/submit", app.submit)
 
+			requests := v1.Group("/requests", app.RequireRoles([]string{"reviewer", "admin"}))
+			{
+				requests.GET("", app.listRequests)
+				requests.GET("/:ticket", app.getRequest)
+				requests.PUT("/:ticket/assign", app.assignRequest)
+				requests.PUT("/:ticket/resolve", app.resolveRequest)
+			}
+
+			reservations := v1.Group("/reservations", app.RequireRoles([]string{"reviewer", "admin"}))
+			{
+				reservations.GET("", app.listReservations)
+				reservations.POST("", app.reserve)
+				reservations.DELETE("/:accession", app.releaseReservation)
+			}
+
 			/*
 				user := v1.Group("/user")
 				{
@@ -47,7 +73,7 @@ func (app *application) routes() *gin.Engine {
 		redirect.GET("/:accession/:extra", app.Redirect)
 	}
 
-	repository := app.Mux.Group("/repository")
+	repository := app.Mux.Group("/repository", app.HideEmbargoed("/repository"))
 	{
 		repository.Static("/", app.RepositoryPath)
 	}
@@ -0,0 +1,86 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+	"secondarymetabolites.org/mibig-api/internal/seqsearch"
+)
+
+// Limits keeping sequence searches within the request timeout
+const (
+	maxSearchSequences  = 25
+	maxProteinLength    = 10000
+	maxNucleotideLength = 30000
+)
+
+// sequenceSearch finds the MIBiG genes similar to the protein or nucleotide sequences
+// of a FASTA input, with the hits of each sequence listed separately
+func (app *application) sequenceSearch(c *gin.Context) {
+	var input struct {
+		Fasta       string  `json:"fasta" binding:"required"`
+		MaxHits     int     `json:"max_hits" binding:"omitempty,min=1,max=500"`
+		MaxEvalue   float64 `json:"max_evalue" binding:"omitempty,gt=0"`
+		MinIdentity float64 `json:"min_identity" binding:"omitempty,min=0,max=100"`
+		MinCoverage float64 `json:"min_coverage" binding:"omitempty,min=0,max=100"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		app.clientErrorWithMessage(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	records, err := seqsearch.ParseFasta(strings.NewReader(input.Fasta))
+	if err != nil {
+		app.clientErrorWithMessage(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(records) == 0 {
+		app.clientErrorWithMessage(c, http.StatusBadRequest, "no sequences found")
+		return
+	}
+	if len(records) > maxSearchSequences {
+		app.clientErrorWithMessage(c, http.StatusBadRequest, fmt.Sprintf("at most %d sequences can be searched at once", maxSearchSequences))
+		return
+	}
+	for _, record := range records {
+		limit := maxProteinLength
+		if seqsearch.IsNucleotide(record.Sequence) {
+			limit = maxNucleotideLength
+		}
+		if len(record.Sequence) > limit {
+			app.clientErrorWithMessage(c, http.StatusBadRequest, fmt.Sprintf("sequence %s is longer than %d", record.Id, limit))
+			return
+		}
+	}
+
+	index, err := app.Sequences.Get()
+	if err != nil {
+		if errors.Is(err, data.ErrNoSequenceIndex) {
+			app.clientErrorWithMessage(c, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		app.serverError(c, err)
+		return
+	}
+
+	opts := seqsearch.DefaultOptions()
+	if input.MaxHits > 0 {
+		opts.MaxHits = input.MaxHits
+	}
+	if input.MaxEvalue > 0 {
+		opts.MaxEvalue = input.MaxEvalue
+	}
+	opts.MinIdentity = input.MinIdentity
+	opts.MinCoverage = input.MinCoverage
+
+	results := make([]data.SequenceQueryResult, 0, len(records))
+	for _, record := range records {
+		results = append(results, index.Search(record, opts))
+	}
+	c.JSON(http.StatusOK, results)
+}
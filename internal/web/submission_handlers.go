@@ -1,6 +1,8 @@
 package web
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -20,6 +22,12 @@ func (app *application) submit(c *gin.Context) {
 		return
 	}
 
+	request := data.StoredAccessionRequest{AccessionRequest: req}
+	if err := app.Models.Requests.Insert(&request); err != nil {
+		app.serverError(c, err)
+		return
+	}
+
 	compound := strings.Join(req.Compounds, ", ")
 	var loci_parts []string
 	for _, locus := range req.Loci {
@@ -28,18 +36,116 @@ func (app *application) submit(c *gin.Context) {
 	loci := strings.Join(loci_parts, "\n")
 
 	email_data := struct {
+		Ticket   string
 		Name     string
 		Email    string
 		Compound string
 		Loci     string
-	}{req.Name, req.Email, compound, loci}
+	}{request.Ticket, req.Name, req.Email, compound, loci}
 
-	if err := app.Mail.SendFromTemplate(viper.GetString("mail.recipient"), "accession_request.tmpl", email_data); err != nil {
+	// The request is stored already, so failing to send mail only needs logging
+	app.background(func() {
+		if err := app.Mail.SendFromTemplate(req.Email, "accession_request_received.tmpl", email_data); err != nil {
+			app.logger.Errorw("failed to send confirmation email", "ticket", request.Ticket, "error", err.Error())
+		}
+		if err := app.Mail.SendFromTemplate(viper.GetString("mail.recipient"), "accession_request.tmpl", email_data); err != nil {
+			app.logger.Errorw("failed to send notification email", "ticket", request.Ticket, "error", err.Error())
+		}
+	})
+
+	c.JSON(http.StatusAccepted, gin.H{"ticket": request.Ticket, "status": request.Status})
+}
+
+func (app *application) listRequests(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && !data.ValidRequestStatus(status) {
+		app.clientErrorWithMessage(c, http.StatusBadRequest, fmt.Sprintf("invalid status %s", status))
+		return
+	}
+
+	requests, err := app.Models.Requests.List(status)
+	if err != nil {
 		app.serverError(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, requests)
+}
+
+func (app *application) getRequest(c *gin.Context) {
+	request, err := app.Models.Requests.Get(c.Param("ticket"))
+	if err != nil {
+		app.requestError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, request)
+}
+
+func (app *application) assignRequest(c *gin.Context) {
+	var input struct {
+		Reviewer string `json:"reviewer"`
+	}
+	// An empty body assigns the request to the current user
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			app.clientErrorWithMessage(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	reviewer := app.GetCurrentUser(c)
+	if input.Reviewer != "" && input.Reviewer != reviewer.Email {
+		var err error
+		reviewer, err = app.Models.Users.Get(input.Reviewer, true)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				app.clientErrorWithMessage(c, http.StatusBadRequest, fmt.Sprintf("no active user %s", input.Reviewer))
+				return
+			}
+			app.serverError(c, err)
+			return
+		}
+	}
+
+	ticket := c.Param("ticket")
+	if err := app.Models.Requests.Assign(ticket, reviewer); err != nil {
+		app.requestError(c, err)
+		return
+	}
+	app.getRequest(c)
+}
 
-	c.String(http.StatusAccepted, "")
+func (app *application) resolveRequest(c *gin.Context) {
+	var input struct {
+		Status    string `json:"status" binding:"required,oneof=accession_assigned rejected"`
+		Accession string `json:"accession"`
+		Comment   string `json:"comment"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		app.clientErrorWithMessage(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if input.Status == data.RequestAccessionAssigned && input.Accession == "" {
+		app.clientErrorWithMessage(c, http.StatusBadRequest, "an accession is required to resolve a request as accession_assigned")
+		return
+	}
+
+	ticket := c.Param("ticket")
+	if err := app.Models.Requests.Resolve(ticket, input.Status, input.Accession, input.Comment); err != nil {
+		app.requestError(c, err)
+		return
+	}
+	app.getRequest(c)
+}
+
+func (app *application) requestError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, data.ErrRecordNotFound):
+		app.clientError(c, http.StatusNotFound)
+	case errors.Is(err, data.ErrRequestClosed):
+		app.clientErrorWithMessage(c, http.StatusConflict, err.Error())
+	default:
+		app.serverError(c, err)
+	}
 }
 
 /*
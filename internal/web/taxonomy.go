@@ -0,0 +1,146 @@
+package web
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+	"secondarymetabolites.org/mibig-api/internal/queries"
+)
+
+var taxonomyRanks = []string{"superkingdom", "kingdom", "phylum", "class", "order", "family", "genus", "species"}
+
+type taxonNode struct {
+	Rank       string         `json:"rank"`
+	Name       string         `json:"name"`
+	Count      int            `json:"count"`
+	Classes    map[string]int `json:"classes"`
+	Children   []*taxonNode   `json:"children,omitempty"`
+	Expandable bool           `json:"expandable,omitempty"`
+	children   map[string]*taxonNode
+}
+
+func newTaxonNode(rank, name string) *taxonNode {
+	return &taxonNode{Rank: rank, Name: name, Classes: map[string]int{}, children: map[string]*taxonNode{}}
+}
+
+func (n *taxonNode) add(entry data.TaxonomyEntry) {
+	n.Count++
+	for _, class := range entry.Classes {
+		n.Classes[class]++
+	}
+}
+
+func (n *taxonNode) child(rank, name string) *taxonNode {
+	child, ok := n.children[name]
+	if !ok {
+		child = newTaxonNode(rank, name)
+		n.children[name] = child
+	}
+	return child
+}
+
+// sortChildren turns the children lookup into a list, largest taxa first
+func (n *taxonNode) sortChildren() {
+	for _, child := range n.children {
+		child.sortChildren()
+		n.Children = append(n.Children, child)
+	}
+	sort.Slice(n.Children, func(i, j int) bool {
+		if n.Children[i].Count != n.Children[j].Count {
+			return n.Children[i].Count > n.Children[j].Count
+		}
+		return n.Children[i].Name < n.Children[j].Name
+	})
+}
+
+// buildTaxonomyTree builds the subtree below path from the entry lineages.
+// A depth above 0 limits the number of levels below path, nodes with hidden
+// children are marked as expandable. The bool is false if no entry is in path.
+func buildTaxonomyTree(entries []data.TaxonomyEntry, path []string, depth int) (*taxonNode, bool) {
+	root := newTaxonNode("root", "")
+	if len(path) > 0 {
+		root = newTaxonNode(taxonomyRanks[len(path)-1], path[len(path)-1])
+	}
+
+	found := false
+	for _, entry := range entries {
+		if len(entry.Lineage) < len(taxonomyRanks) || !slices.Equal(entry.Lineage[:len(path)], path) {
+			continue
+		}
+		found = true
+		root.add(entry)
+
+		node := root
+		for i := len(path); i < len(taxonomyRanks); i++ {
+			if depth > 0 && i-len(path) >= depth {
+				node.Expandable = true
+				break
+			}
+			node = node.child(taxonomyRanks[i], entry.Lineage[i])
+			node.add(entry)
+		}
+	}
+
+	root.sortChildren()
+	return root, found || len(path) == 0
+}
+
+// taxonomy serves the taxonomy tree of all active entries with the number of entries
+// and BGC classes per taxon. The tree can be limited to the subtree below a path of taxa
+// from superkingdom down, to a number of levels and to the hits of a search.
+func (app *application) taxonomy(c *gin.Context) {
+	var input struct {
+		Path   string `form:"path"`
+		Depth  int    `form:"depth" binding:"min=0"`
+		Search string `form:"q"`
+	}
+	if err := c.ShouldBindQuery(&input); err != nil {
+		app.clientErrorWithMessage(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var path []string
+	if input.Path != "" {
+		path = strings.Split(strings.Trim(input.Path, "/"), "/")
+	}
+	if len(path) > len(taxonomyRanks) {
+		app.clientErrorWithMessage(c, http.StatusBadRequest, "path is longer than the lineage")
+		return
+	}
+
+	var ids []string
+	if input.Search != "" {
+		query, err := queries.NewQueryFromString(input.Search)
+		if err != nil {
+			app.parseFailed(c, err)
+			return
+		}
+		ids, err = app.entries(c).Search(query.Terms)
+		if err != nil {
+			app.searchFailed(c, err)
+			return
+		}
+		if ids == nil {
+			ids = []string{}
+		}
+	}
+
+	entries, err := app.entries(c).Taxonomy(ids)
+	if err != nil {
+		app.serverError(c, err)
+		return
+	}
+
+	tree, found := buildTaxonomyTree(entries, path, input.Depth)
+	if !found {
+		app.clientErrorWithMessage(c, http.StatusNotFound, "unknown taxon")
+		return
+	}
+
+	c.JSON(http.StatusOK, tree)
+}
@@ -0,0 +1,66 @@
+package web
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"secondarymetabolites.org/mibig-api/internal/data"
+)
+
+func TestBuildTaxonomyTree(t *testing.T) {
+	streptomyces := []string{"Bacteria", "Bacillati", "Actinomycetota", "Actinomycetes", "Kitasatosporales", "Streptomycetaceae", "Streptomyces", "coelicolor"}
+	griseus := []string{"Bacteria", "Bacillati", "Actinomycetota", "Actinomycetes", "Kitasatosporales", "Streptomycetaceae", "Streptomyces", "griseus"}
+	aspergillus := []string{"Eukaryota", "Fungi", "Ascomycota", "Eurotiomycetes", "Eurotiales", "Aspergillaceae", "Aspergillus", "nidulans"}
+
+	entries := []data.TaxonomyEntry{
+		{Lineage: streptomyces, Classes: []string{"NRPS", "PKS"}},
+		{Lineage: griseus, Classes: []string{"NRPS"}},
+		{Lineage: aspergillus, Classes: []string{"PKS"}},
+	}
+
+	tests := []struct {
+		Name     string
+		Path     []string
+		Depth    int
+		Expected *taxonNode
+		Found    bool
+	}{
+		{"top level", nil, 1, &taxonNode{
+			Rank: "root", Count: 3, Classes: map[string]int{"NRPS": 2, "PKS": 2},
+			Children: []*taxonNode{
+				{Rank: "superkingdom", Name: "Bacteria", Count: 2, Classes: map[string]int{"NRPS": 2, "PKS": 1}, Expandable: true},
+				{Rank: "superkingdom", Name: "Eukaryota", Count: 1, Classes: map[string]int{"PKS": 1}, Expandable: true},
+			},
+		}, true},
+		{"subtree", griseus[:6], 0, &taxonNode{
+			Rank: "family", Name: "Streptomycetaceae", Count: 2, Classes: map[string]int{"NRPS": 2, "PKS": 1},
+			Children: []*taxonNode{
+				{Rank: "genus", Name: "Streptomyces", Count: 2, Classes: map[string]int{"NRPS": 2, "PKS": 1},
+					Children: []*taxonNode{
+						{Rank: "species", Name: "coelicolor", Count: 1, Classes: map[string]int{"NRPS": 1, "PKS": 1}},
+						{Rank: "species", Name: "griseus", Count: 1, Classes: map[string]int{"NRPS": 1}},
+					}},
+			},
+		}, true},
+		{"leaf", griseus, 1, &taxonNode{
+			Rank: "species", Name: "griseus", Count: 1, Classes: map[string]int{"NRPS": 1},
+		}, true},
+		{"unknown", []string{"Archaea"}, 0, &taxonNode{
+			Rank: "superkingdom", Name: "Archaea", Classes: map[string]int{},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			tree, found := buildTaxonomyTree(entries, tt.Path, tt.Depth)
+			if found != tt.Found {
+				t.Errorf("expected found to be %v, got %v", tt.Found, found)
+			}
+			if diff := cmp.Diff(tt.Expected, tree, cmpopts.IgnoreUnexported(taxonNode{})); diff != "" {
+				t.Errorf("unexpected tree (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -21,6 +21,7 @@ import (
 
 	"secondarymetabolites.org/mibig-api/internal/mailer"
 	"secondarymetabolites.org/mibig-api/internal/models"
+	"secondarymetabolites.org/mibig-api/internal/seqsearch"
 )
 
 type application struct {
@@ -29,6 +30,7 @@ type application struct {
 	Mail           mailer.Mailer
 	Mux            *gin.Engine
 	RepositoryPath string
+	Sequences      *seqsearch.IndexFile
 }
 
 func Run(debug bool) {
@@ -69,10 +71,17 @@ func Run(debug bool) {
 		Mail:           mailSender,
 		Mux:            mux,
 		RepositoryPath: repositoryPath,
+		Sequences:      seqsearch.NewIndexFile(viper.GetString("search.sequence_index")),
 	}
 
 	mux = app.routes()
 
+	stopPublishing := make(chan struct{})
+	defer close(stopPublishing)
+	app.background(func() {
+		app.publishEmbargoed(viper.GetDuration("server.publish_interval"), stopPublishing)
+	})
+
 	address := fmt.Sprintf("%s:%d", viper.GetString("server.address"), viper.GetInt("server.port"))
 
 	srv := &http.Server{
@@ -116,6 +125,32 @@ func Run(debug bool) {
 
 }
 
+// publishEmbargoed publishes entries whose embargo has lifted, once at startup
+// and then every interval until stop is closed
+func (app *application) publishEmbargoed(interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		published, err := app.Models.Entries.PublishEmbargoed()
+		if err != nil {
+			app.logger.Errorw("failed to publish embargoed entries", zap.Error(err))
+		}
+		for _, entry_id := range published {
+			app.logger.Infow("published embargoed entry", "entry_id", entry_id)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
 func setupMux(debug bool, logger *zap.Logger) *gin.Engine {
 	var mux *gin.Engine
 	if !debug {
@@ -0,0 +1,2 @@
+DROP MATERIALIZED VIEW IF EXISTS live.entry_domains;
+DROP MATERIALIZED VIEW IF EXISTS live.entry_genes;
@@ -0,0 +1,11 @@
+DO $$ BEGIN
+    CREATE MATERIALIZED VIEW live.entry_genes AS SELECT row_number() OVER () AS gene_id, entry_id, gene.id AS locus_tag, gene.name, gene.product, ARRAY(SELECT func.value ->> 'function' FROM jsonb_array_elements(COALESCE(gene.functions, '[]'::jsonb)) AS func(value)) AS functions FROM live.entries, jsonb_to_recordset(COALESCE(live.entries.data -> 'genes' -> 'annotations', '[]'::jsonb)) AS gene(id text, name text, product text, functions jsonb);
+EXCEPTION
+    WHEN duplicate_table THEN null;
+END $$;
+
+DO $$ BEGIN
+    CREATE MATERIALIZED VIEW live.entry_domains AS SELECT row_number() OVER () AS domain_id, entry_id, COALESCE(dom.value ->> 'gene', module.value -> 'genes' ->> 0) AS locus_tag, module.value ->> 'name' AS module, upper(replace(dom.key, '_domain', '')) AS domain, ARRAY(SELECT sub.value ->> 'name' FROM jsonb_array_elements(COALESCE(dom.value -> 'substrates', '[]'::jsonb)) AS sub(value)) AS substrates FROM live.entries, jsonb_array_elements(COALESCE(live.entries.data -> 'biosynthesis' -> 'modules', '[]'::jsonb)) AS module(value), jsonb_each(module.value) AS dom(key, value) WHERE dom.key LIKE '%\_domain' AND jsonb_typeof(dom.value) = 'object';
+EXCEPTION
+    WHEN duplicate_table THEN null;
+END $$;
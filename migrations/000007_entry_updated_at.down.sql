@@ -0,0 +1 @@
+ALTER TABLE live.entries DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1 @@
+ALTER TABLE live.entries ADD COLUMN IF NOT EXISTS updated_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW();
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS live.loci;
+DROP TABLE IF EXISTS live.compound_synonyms;
+DROP TABLE IF EXISTS live.compounds;
+
+ALTER TABLE data.bgc_types DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,53 @@
+ALTER TABLE data.bgc_types ADD COLUMN IF NOT EXISTS parent_id int REFERENCES data.bgc_types ON DELETE CASCADE;
+
+CREATE TABLE IF NOT EXISTS live.compounds (
+    entry_id text REFERENCES live.entries ON DELETE CASCADE,
+    position int NOT NULL,
+    name text NOT NULL,
+    PRIMARY KEY (entry_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS live.compound_synonyms (
+    entry_id text NOT NULL,
+    position int NOT NULL,
+    synonym text NOT NULL,
+    FOREIGN KEY (entry_id, position) REFERENCES live.compounds ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS live.loci (
+    entry_id text REFERENCES live.entries ON DELETE CASCADE,
+    position int NOT NULL,
+    accession text NOT NULL,
+    start_coord bigint,
+    end_coord bigint,
+    PRIMARY KEY (entry_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS compounds_name_idx ON live.compounds (lower(name));
+CREATE INDEX IF NOT EXISTS compound_synonyms_synonym_idx ON live.compound_synonyms (lower(synonym));
+CREATE INDEX IF NOT EXISTS loci_accession_idx ON live.loci (accession);
+
+--- fill the new tables for entries imported before they existed
+INSERT INTO live.compounds (entry_id, position, name)
+SELECT entry_id, c.position, c.compound ->> 'name'
+FROM live.entries, jsonb_array_elements(COALESCE(data -> 'compounds', '[]'::jsonb)) WITH ORDINALITY AS c(compound, position)
+WHERE c.compound ->> 'name' IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+INSERT INTO live.compound_synonyms (entry_id, position, synonym)
+SELECT entry_id, c.position, s.synonym
+FROM live.entries, jsonb_array_elements(COALESCE(data -> 'compounds', '[]'::jsonb)) WITH ORDINALITY AS c(compound, position),
+    jsonb_array_elements_text(COALESCE(c.compound -> 'synonyms', '[]'::jsonb)) AS s(synonym)
+WHERE c.compound ->> 'name' IS NOT NULL;
+
+INSERT INTO live.loci (entry_id, position, accession, start_coord, end_coord)
+SELECT entry_id, l.position, l.locus ->> 'accession', (l.locus -> 'location' ->> 'from')::bigint, (l.locus -> 'location' ->> 'to')::bigint
+FROM live.entries, jsonb_array_elements(COALESCE(data -> 'loci', '[]'::jsonb)) WITH ORDINALITY AS l(locus, position)
+WHERE l.locus ->> 'accession' IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+INSERT INTO live.rel_entries_types (entry_id, bgc_type_id)
+SELECT DISTINCT entry_id, bgc_type_id
+FROM live.entries, jsonb_to_recordset(COALESCE(data -> 'biosynthesis' -> 'classes', '[]'::jsonb)) AS specs(class text)
+JOIN data.bgc_types ON LOWER(class) = term
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS submissions.accession_requests;
+
+DROP TYPE IF EXISTS submissions.request_status;
+
+DROP SCHEMA IF EXISTS submissions;
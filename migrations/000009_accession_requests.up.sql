@@ -0,0 +1,24 @@
+CREATE SCHEMA IF NOT EXISTS submissions;
+
+DO $$ BEGIN
+    CREATE TYPE submissions.request_status AS ENUM ('received', 'under_review', 'accession_assigned', 'rejected');
+EXCEPTION
+    WHEN duplicate_object THEN null;
+END $$;
+
+CREATE TABLE IF NOT EXISTS submissions.accession_requests (
+    request_id bigserial PRIMARY KEY,
+    ticket text UNIQUE NOT NULL,
+    name text NOT NULL,
+    email citext NOT NULL,
+    compounds text[] NOT NULL,
+    loci jsonb NOT NULL,
+    status submissions.request_status NOT NULL DEFAULT 'received',
+    reviewer_id bigint REFERENCES auth.users ON DELETE SET NULL,
+    accession text,
+    comment text,
+    submitted_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS accession_requests_status_idx ON submissions.accession_requests (status);
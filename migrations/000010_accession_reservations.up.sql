@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS submissions.reservations (
+    accession text PRIMARY KEY,
+    request_id bigint REFERENCES submissions.accession_requests ON DELETE SET NULL,
+    reserved_by bigint REFERENCES auth.users ON DELETE SET NULL,
+    reserved_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at timestamp(0) WITH TIME ZONE,
+    released_at timestamp(0) WITH TIME ZONE
+);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS live.entries_embargo_idx;
+
+ALTER TABLE live.entries DROP COLUMN IF EXISTS embargo_until;
+
+-- Postgres can't drop values from an enum, so embargoed entries are removed and the value stays
+DELETE FROM live.entries WHERE status = 'embargoed';
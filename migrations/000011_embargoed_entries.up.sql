@@ -0,0 +1,5 @@
+ALTER TYPE live.entry_status ADD VALUE IF NOT EXISTS 'embargoed';
+
+ALTER TABLE live.entries ADD COLUMN IF NOT EXISTS embargo_until timestamp(0) WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS entries_embargo_idx ON live.entries (embargo_until) WHERE embargo_until IS NOT NULL;
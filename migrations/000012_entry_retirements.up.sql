@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS data.retirements (
+    retirement_id bigserial PRIMARY KEY,
+    accession text NOT NULL,
+    retired_entry_id text NOT NULL,
+    entry_id text NOT NULL,
+    reasons text[] NOT NULL,
+    see_also text[] NOT NULL DEFAULT '{}',
+    retired_by text NOT NULL,
+    retired_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS retirements_accession_idx ON data.retirements (accession);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS data.release_entries;
+DROP TABLE IF EXISTS data.releases;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS data.releases (
+    release_id bigserial PRIMARY KEY,
+    name text UNIQUE NOT NULL,
+    notes text NOT NULL DEFAULT '',
+    created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Snapshot of the public entries at release time, the live tables get replaced by later releases
+CREATE TABLE IF NOT EXISTS data.release_entries (
+    release_id bigint NOT NULL REFERENCES data.releases ON DELETE CASCADE,
+    entry_id text NOT NULL,
+    accession text NOT NULL,
+    version int NOT NULL,
+    status text NOT NULL,
+    quality text NOT NULL,
+    completeness text NOT NULL,
+    PRIMARY KEY (release_id, entry_id)
+);
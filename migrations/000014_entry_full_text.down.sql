@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS live.entries_search_text_idx;
+ALTER TABLE live.entries DROP COLUMN IF EXISTS search_text;
+
+DROP FUNCTION IF EXISTS data.entry_search_document(jsonb);
+DROP FUNCTION IF EXISTS data.entry_search_text(jsonb);
+DROP FUNCTION IF EXISTS data.jsonb_path_text(jsonb, jsonpath);
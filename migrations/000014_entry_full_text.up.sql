@@ -0,0 +1,38 @@
+CREATE OR REPLACE FUNCTION data.jsonb_path_text(entry jsonb, path jsonpath) RETURNS text
+    LANGUAGE sql IMMUTABLE AS $$
+    SELECT COALESCE(string_agg(value #>> '{}', ' '), '') FROM jsonb_path_query(entry, path) AS value
+$$;
+
+--- the free text of an entry, compound names weigh most and comments least
+CREATE OR REPLACE FUNCTION data.entry_search_text(entry jsonb) RETURNS tsvector
+    LANGUAGE sql IMMUTABLE AS $$
+    SELECT setweight(to_tsvector('english', concat_ws(' ',
+            data.jsonb_path_text(entry, '$.compounds[*].name'),
+            data.jsonb_path_text(entry, '$.compounds[*].synonyms[*]'))), 'A')
+        || setweight(to_tsvector('english', concat_ws(' ',
+            data.jsonb_path_text(entry, '$.genes.annotations[*].functions[*].function'),
+            data.jsonb_path_text(entry, '$.genes.annotations[*].product'),
+            data.jsonb_path_text(entry, '$.genes.annotations[*].name'),
+            data.jsonb_path_text(entry, '$.biosynthesis.modules[*].name'))), 'B')
+        || setweight(to_tsvector('english', data.jsonb_path_text(entry, '$.comment')), 'C')
+$$;
+
+--- the same text as a single document, to build result snippets from
+CREATE OR REPLACE FUNCTION data.entry_search_document(entry jsonb) RETURNS text
+    LANGUAGE sql IMMUTABLE AS $$
+    SELECT concat_ws(' ... ',
+        data.jsonb_path_text(entry, '$.compounds[*].name'),
+        data.jsonb_path_text(entry, '$.compounds[*].synonyms[*]'),
+        data.jsonb_path_text(entry, '$.genes.annotations[*].functions[*].function'),
+        data.jsonb_path_text(entry, '$.genes.annotations[*].product'),
+        data.jsonb_path_text(entry, '$.genes.annotations[*].name'),
+        data.jsonb_path_text(entry, '$.biosynthesis.modules[*].name'),
+        data.jsonb_path_text(entry, '$.comment'))
+$$;
+
+ALTER TABLE live.entries ADD COLUMN IF NOT EXISTS search_text tsvector;
+
+CREATE INDEX IF NOT EXISTS entries_search_text_idx ON live.entries USING gin (search_text);
+
+--- fill the new column for entries imported before it existed
+UPDATE live.entries SET search_text = data.entry_search_text(data);
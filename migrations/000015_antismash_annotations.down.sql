@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS data.antismash_domain_hits;
+DROP TABLE IF EXISTS data.antismash_cdses;
+DROP TABLE IF EXISTS data.antismash_protoclusters;
+DROP TABLE IF EXISTS data.antismash_results;
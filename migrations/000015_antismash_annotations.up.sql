@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS data.antismash_results (
+    entry_id text PRIMARY KEY,
+    antismash_version text NOT NULL,
+    input_file text NOT NULL DEFAULT '',
+    imported_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS data.antismash_protoclusters (
+    protocluster_id bigserial PRIMARY KEY,
+    entry_id text NOT NULL REFERENCES data.antismash_results ON DELETE CASCADE,
+    record_id text NOT NULL,
+    position int NOT NULL,
+    location text NOT NULL,
+    product text NOT NULL,
+    category text NOT NULL DEFAULT '',
+    detection_rule text NOT NULL DEFAULT '',
+    qualifiers jsonb NOT NULL DEFAULT '{}',
+    UNIQUE (entry_id, record_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS data.antismash_cdses (
+    protocluster_id bigint REFERENCES data.antismash_protoclusters ON DELETE CASCADE,
+    cds_name text NOT NULL,
+    position int NOT NULL,
+    definition_domains jsonb NOT NULL DEFAULT '{}',
+    PRIMARY KEY (protocluster_id, cds_name)
+);
+
+CREATE TABLE IF NOT EXISTS data.antismash_domain_hits (
+    protocluster_id bigint NOT NULL,
+    cds_name text NOT NULL,
+    position int NOT NULL,
+    name text NOT NULL,
+    evalue double precision NOT NULL,
+    bitscore double precision NOT NULL,
+    seeds int NOT NULL,
+    tool text NOT NULL DEFAULT '',
+    PRIMARY KEY (protocluster_id, cds_name, position),
+    FOREIGN KEY (protocluster_id, cds_name) REFERENCES data.antismash_cdses ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS antismash_domain_hits_name_idx ON data.antismash_domain_hits (lower(name));
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS data.antismash_domain_hits_name_idx;
+CREATE INDEX IF NOT EXISTS antismash_domain_hits_name_idx ON data.antismash_domain_hits (lower(name) text_pattern_ops);
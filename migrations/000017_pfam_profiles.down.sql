@@ -0,0 +1 @@
+DROP TABLE IF EXISTS data.pfam_profiles;
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS data.pfam_profiles (
+    name text PRIMARY KEY,
+    accession text UNIQUE NOT NULL,
+    description text NOT NULL
+);
+
+INSERT INTO data.pfam_profiles (name, accession, description)
+VALUES
+    ('AMP-binding', 'PF00501', 'AMP-binding enzyme'),
+    ('Condensation', 'PF00668', 'Condensation domain'),
+    ('PP-binding', 'PF00550', 'Phosphopantetheine attachment site'),
+    ('Thioesterase', 'PF00975', 'Thioesterase domain'),
+    ('NAD_binding_4', 'PF07993', 'Male sterility protein'),
+    ('Terpene_synth', 'PF01397', 'Terpene synthase, N-terminal domain'),
+    ('Terpene_synth_C', 'PF03936', 'Terpene synthase family, metal binding domain'),
+    ('Lycopene_cycl', 'PF05834', 'Lycopene cyclase protein'),
+    ('Chal_sti_synt_N', 'PF00195', 'Chalcone and stilbene synthases, N-terminal domain'),
+    ('Chal_sti_synt_C', 'PF02797', 'Chalcone and stilbene synthases, C-terminal domain'),
+    ('LANC_like', 'PF05147', 'Lanthionine synthetase C-like protein'),
+    ('Lant_dehydr_N', 'PF04738', 'Lantibiotic dehydratase, N terminus'),
+    ('Lant_dehydr_C', 'PF04737', 'Lantibiotic dehydratase, C terminus'),
+    ('YcaO', 'PF02624', 'YcaO cyclodehydratase, ATP-ad Mg2+-binding'),
+    ('Asn_synthase', 'PF00733', 'Asparagine synthase'),
+    ('AfsA', 'PF03756', 'A-factor biosynthesis hotdog domain'),
+    ('IucA_IucC', 'PF04183', 'IucA / IucC family'),
+    ('Ectoine_synth', 'PF06339', 'Ectoine synthase'),
+    ('Tyrosinase', 'PF00264', 'Common central domain of tyrosinase'),
+    ('PEP_mutase', 'PF13714', 'Phosphoenolpyruvate phosphomutase'),
+    ('Radical_SAM', 'PF04055', 'Radical SAM superfamily'),
+    ('Autoind_synth', 'PF00765', 'Autoinducer synthase'),
+    ('Trp_halogenase', 'PF04820', 'Tryptophan halogenase')
+ON CONFLICT DO NOTHING;